@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// instanceTags returns the tags of an instance with the name, and the owner and expiry
+// tags if they are set
+func (p *Provider) instanceTags(name string, expires time.Time) []*ec2.TagSpecification {
+	return p.resourceTags(ec2.ResourceTypeInstance, name, expires)
+}
+
+// resourceTags returns the tags of a resource of the type with the name, and the owner
+// and expiry tags if they are set
+func (p *Provider) resourceTags(resourceType string, name string, expires time.Time) []*ec2.TagSpecification {
+	tags := []*ec2.Tag{
+		{Key: aws.String("Name"), Value: aws.String(name)},
+	}
+	if len(p.info.OwnerTag) > 0 {
+		tags = append(tags, &ec2.Tag{Key: aws.String(common.OwnerLabel), Value: aws.String(p.info.OwnerTag)})
+	}
+	if !expires.IsZero() {
+		tags = append(tags, &ec2.Tag{Key: aws.String(common.ExpiresLabel), Value: aws.String(common.ExpiresValue(expires))})
+	}
+
+	return []*ec2.TagSpecification{
+		{ResourceType: aws.String(resourceType), Tags: tags},
+	}
+}
+
+// tagValue returns the value of the tag with the key, or ""
+func tagValue(tags []*ec2.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == key {
+			return aws.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
+
+// adoptInstance looks up an instance tagged with the name, and returns it if it can be
+// adopted, or nil if there is none. Pending instances are waited for by the caller.
+func (p *Provider) adoptInstance(ctx context.Context, name string, want *ec2.RunInstancesInput) (*ec2.Instance, error) {
+	found, err := p.describeInstances(ctx, &ec2.Filter{
+		Name:   aws.String("tag:Name"),
+		Values: []*string{aws.String(name)},
+	})
+	if err != nil {
+		return nil, wrapErr("CreateServer", err)
+	}
+
+	conflict := func(format string, args ...interface{}) (*ec2.Instance, error) {
+		return nil, common.SpecConflict("aws", common.OpCreateServer, name, format, args...)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return conflict("is the name of %d instances", len(found))
+	}
+
+	ins := found[0]
+	if len(p.info.OwnerTag) > 0 && tagValue(ins.Tags, common.OwnerLabel) != p.info.OwnerTag {
+		return conflict("is not tagged %s=%s", common.OwnerLabel, p.info.OwnerTag)
+	}
+	if got, size := aws.StringValue(ins.InstanceType), aws.StringValue(want.InstanceType); got != size {
+		return conflict("has instance type %s, not %s", got, size)
+	}
+	if got, image := aws.StringValue(ins.ImageId), aws.StringValue(want.ImageId); len(image) > 0 && got != image {
+		return conflict("runs image %s, not %s", got, image)
+	}
+	if state := aws.StringValue(ins.State.Name); state != ec2.InstanceStateNamePending && state != ec2.InstanceStateNameRunning {
+		return conflict("is %s", state)
+	}
+
+	return ins, nil
+}
+
+// instanceAddress returns the Elastic IP associated with the instance, or nil if there is
+// none; op names the operation in errors
+func (p *Provider) instanceAddress(ctx context.Context, op string, instanceID string) (*ec2.Address, error) {
+	var resp *ec2.DescribeAddressesOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		resp, err = p.client.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{
+			Filters: []*ec2.Filter{
+				{Name: aws.String("instance-id"), Values: []*string{aws.String(instanceID)}},
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, wrapErr(op, err)
+	}
+
+	if len(resp.Addresses) == 0 {
+		return nil, nil
+	}
+	return resp.Addresses[0], nil
+}
+
+// adoptRecord looks up an A record with the name of want, and reports whether it
+// points to the same address
+func (p *Provider) adoptRecord(ctx context.Context, subDomain string, want *route53.ResourceRecordSet) (bool, error) {
+	var resp *route53.ListResourceRecordSetsOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		resp, err = p.router.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
+			HostedZoneId:    aws.String(p.zone),
+			StartRecordName: want.Name,
+			StartRecordType: want.Type,
+			MaxItems:        aws.String("1"),
+		})
+		return err
+	})
+	if err != nil {
+		return false, wrapErr("CreateDNSRecord", err)
+	}
+
+	for _, set := range resp.ResourceRecordSets {
+		if strings.TrimSuffix(aws.StringValue(set.Name), ".") != strings.TrimSuffix(aws.StringValue(want.Name), ".") ||
+			aws.StringValue(set.Type) != aws.StringValue(want.Type) {
+			continue
+		}
+
+		if got, ip := recordValues(set), recordValues(want); got != ip {
+			return false, common.SpecConflict("aws", common.OpCreateDNSRecord, subDomain, "points to %s", got)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// recordValues returns the values of a record set, joined by commas
+func recordValues(set *route53.ResourceRecordSet) string {
+	var values []string
+	for _, r := range set.ResourceRecords {
+		values = append(values, aws.StringValue(r.Value))
+	}
+	return strings.Join(values, ",")
+}
@@ -2,9 +2,8 @@ package aws
 
 import (
 	"context"
-	"errors"
-	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -13,30 +12,27 @@ import (
 	"github.com/aws/aws-sdk-go/service/route53"
 
 	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
 )
 
 // NewClient creates a new EC2 client for server operations
-func NewClient() *ec2.EC2 {
+func NewClient() (*ec2.EC2, error) {
 	sess, err := session.NewSession()
-
 	if err != nil {
-		log.Println("Could not create EC2 client", err)
-		return nil
+		return nil, common.NewError("aws", "NewClient", nil, err)
 	}
 
-	return ec2.New(sess)
+	return ec2.New(sess), nil
 }
 
 // NewRouter creates a new Route53 client for DNS operations
-func NewRouter() *route53.Route53 {
+func NewRouter() (*route53.Route53, error) {
 	sess, err := session.NewSession()
-
 	if err != nil {
-		log.Println("Could not create Route53 client", err)
-		return nil
+		return nil, common.NewError("aws", "NewRouter", nil, err)
 	}
 
-	return route53.New(sess)
+	return route53.New(sess), nil
 }
 
 // Provider implements common.CloudProvider
@@ -44,26 +40,88 @@ type Provider struct {
 	client *ec2.EC2         // EC2 client
 	router *route53.Route53 // Route53 client
 	domain string           // server domain name
-	alloc  string           // IP allocation ID, needed for later deletion
 	zone   string           // hosted zone ID, needed for DNS operations
+	info   *common.ProviderInfo
 }
 
-// NewProvider returns a new Provider instance
-func NewProvider(domain string) *Provider {
-	return &Provider{NewClient(), NewRouter(), domain, "", ""}
+// NewProvider returns a new Provider instance, or an error if the options are invalid or
+// no AWS session can be created from the environment
+func NewProvider(domain string, opts ...common.ProviderOption) (*Provider, error) {
+	info, err := common.NewProviderInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+	router, err := NewRouter()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{client, router, domain, "", info}, nil
+}
+
+// ref returns the reference to a resource in the region of the EC2 client
+func (p *Provider) ref(kind common.Kind, id string) common.Ref {
+	ref := common.Ref{
+		Provider: "aws",
+		Kind:     kind,
+		ID:       id,
+	}
+	if p.client != nil {
+		ref.Region = aws.StringValue(p.client.Config.Region)
+	}
+	return ref
 }
 
-// CreateServer creates an EC2 instance on AWS
+// Capabilities returns the operations and options supported by AWS
+func (p *Provider) Capabilities() *common.Capabilities {
+	return &common.Capabilities{
+		Provider: "aws",
+		Operations: map[common.Operation][]common.OptionName{
+			common.OpCreateServer:    {common.OptSize, common.OptImage, common.OptExpires, common.OptVolumes, common.OptFirewall, common.OptSSHKeys, common.OptNetwork},
+			common.OpRemoveServer:    nil,
+			common.OpCreateDNSRecord: nil,
+			common.OpRemoveDNSRecord: nil,
+			common.OpListServers:     nil,
+			common.OpGetServer:       nil,
+			common.OpListDNSRecords:  nil,
+			common.OpStopServer:      nil,
+			common.OpStartServer:     nil,
+			common.OpRebootServer:    nil,
+			common.OpResizeServer:    nil,
+			common.OpCreateImage:     nil,
+			common.OpListImages:      nil,
+			common.OpRemoveImage:     nil,
+			common.OpCreateVolume:    nil,
+			common.OpAttachVolume:    nil,
+			common.OpDetachVolume:    nil,
+			common.OpRemoveVolume:    nil,
+			common.OpCreateFirewall:  nil,
+			common.OpRemoveFirewall:  nil,
+			common.OpImportSSHKey:    nil,
+			common.OpRemoveSSHKey:    nil,
+			common.OpCreateNetwork:   nil,
+			common.OpRemoveNetwork:   nil,
+		},
+		// RunInstances refills its request token bucket at two requests per second
+		CreateInterval: 500 * time.Millisecond,
+	}
+}
+
+// CreateServer creates an EC2 instance on AWS. Volumes are attached once the instance
+// runs; if that or any later step fails, the server is returned with the error so it can
+// be removed.
 func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerResponse, error) {
 	var instanceID string
 	var instanceIP string
 
-	s := &common.ServerInfo{
-		Name: name,
-	}
-
-	for _, opt := range opts {
-		opt.Set(s)
+	s, err := common.NewServerInfo(name, opts...)
+	if err != nil {
+		return nil, err
 	}
 
 	var imageIDStr string
@@ -73,68 +131,265 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 		imageIDStr = s.Image
 	}
 
+	zone, err := volumeZone(s.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetID, err := p.networkSubnet(ctx, s.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	keyName, err := instanceKeyName(s)
+	if err != nil {
+		return nil, err
+	}
+
 	svc := p.client
 
-	log.Println("Creating server instance")
-	runResult, err := svc.RunInstances(&ec2.RunInstancesInput{
+	log := p.info.StartOp(ctx, "aws", common.OpCreateServer, name)
+	log.Info("creating instance", "size", s.Size, "image", imageIDStr)
+	input := &ec2.RunInstancesInput{
+		ClientToken:  aws.String(clientToken()),
 		ImageId:      aws.String(imageIDStr),
 		InstanceType: aws.String(s.Size),
 		MinCount:     aws.Int64(1),
 		MaxCount:     aws.Int64(1),
-	})
 
-	if err != nil {
-		log.Println("Could not create instance", err)
-		return nil, err
+		TagSpecifications: p.instanceTags(name, s.Expires),
+	}
+	if len(keyName) > 0 {
+		input.KeyName = aws.String(keyName)
+	}
+	if len(subnetID) > 0 {
+		input.SubnetId = aws.String(subnetID)
+	}
+	if len(zone) > 0 {
+		// EBS volumes can only be attached in their own availability zone
+		input.Placement = &ec2.Placement{AvailabilityZone: aws.String(zone)}
+	}
+
+	if log.DryRun("ec2.RunInstances", input) {
+		instanceID = common.PlaceholderID(name)
+		generated, privateKey, err := p.ephemeralSSHKey(ctx, s)
+		if err != nil {
+			return nil, log.Done(err)
+		}
+		resp := &common.CreateServerResponse{
+			Name:          name,
+			ServerID:      p.ref(common.KindServer, instanceID),
+			ServerIP:      common.PlaceholderIP,
+			Expires:       s.Expires,
+			SSHKeyID:      generated.KeyID,
+			SSHPrivateKey: privateKey,
+		}
+		if len(s.Firewall) > 0 {
+			firewall, err := p.CreateFirewall(ctx, common.FirewallName(name), &common.FirewallRequest{Rules: s.Firewall, Network: s.Network})
+			if err != nil {
+				return nil, log.Done(err)
+			}
+			resp.FirewallID = firewall.FirewallID
+		}
+		// the address is planned here, since planning must not change the provider
+		log.DryRun("ec2.AllocateAddress", &ec2.AllocateAddressInput{Domain: aws.String("vpc")})
+		return resp, log.Done(nil)
 	}
 
-	log.Println("Created server instance", *runResult.Instances[0].InstanceId)
+	var adopted *ec2.Instance
+	if p.info.Adopt {
+		adopted, err = p.adoptInstance(ctx, name, input)
+		if err != nil {
+			return nil, log.Done(err)
+		}
+	}
+
+	firewall := &common.CreateFirewallResponse{}
+	generated := &common.ImportSSHKeyResponse{}
+	var privateKey string
+	if adopted != nil {
+		instanceID = *adopted.InstanceId
+		// an adopted instance keeps the key pair and security group it was created with
+		log.Info("adopting existing instance", "id", instanceID)
+		if len(s.Firewall) > 0 {
+			if firewall, err = p.existingFirewall(ctx, common.FirewallName(name), s.Network); err != nil {
+				return nil, log.Done(err)
+			}
+		}
+	} else {
+		if generated, privateKey, err = p.ephemeralSSHKey(ctx, s); err != nil {
+			return nil, log.Done(err)
+		}
+		if !generated.KeyID.IsZero() {
+			input.KeyName = aws.String(generated.KeyID.ID)
+		}
+		if len(s.Firewall) > 0 {
+			if firewall, err = p.serverFirewall(ctx, name, s.Network, s.Firewall); err != nil {
+				p.removeServerKey(ctx, log, generated)
+				return nil, log.Done(err)
+			}
+			input.SecurityGroupIds = []*string{aws.String(firewall.FirewallID.ID)}
+		}
+
+		var runResult *ec2.Reservation
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			var err error
+			runResult, err = svc.RunInstancesWithContext(ctx, input)
+			return err
+		})
+
+		if err != nil {
+			if !firewall.FirewallID.IsZero() {
+				if rmErr := p.RemoveFirewall(context.WithoutCancel(ctx), firewall); rmErr != nil {
+					log.Warn("could not remove the security group of the failed server", "error", rmErr)
+				}
+			}
+			p.removeServerKey(ctx, log, generated)
+			return nil, log.Done(wrapErr("CreateServer", err))
+		}
+
+		instanceID = *runResult.Instances[0].InstanceId
+	}
+	log.Started(instanceID)
+
+	// the instance exists from here on, so failures return it to be removed along with
+	// its security group, key pair and address
+	resp := &common.CreateServerResponse{
+		Name:          name,
+		ServerID:      p.ref(common.KindServer, instanceID),
+		Expires:       s.Expires,
+		FirewallID:    firewall.FirewallID,
+		SSHKeyID:      generated.KeyID,
+		SSHPrivateKey: privateKey,
+	}
 
-	instanceID = *runResult.Instances[0].InstanceId
+	err = wait.Poll(ctx, wait.Server, func(ctx context.Context) (bool, error) {
+		desc, err := svc.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []*string{aws.String(instanceID)},
+		})
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		for _, res := range desc.Reservations {
+			for _, ins := range res.Instances {
+				if ins.State == nil {
+					continue
+				}
+				log.Status(aws.StringValue(ins.State.Name))
+				if aws.StringValue(ins.State.Name) == ec2.InstanceStateNameRunning {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return resp, log.Done(wrapErr("CreateServer", err))
+	}
 
-	time.Sleep(60 * time.Second)
+	var addr *ec2.Address
+	if adopted != nil {
+		addr, err = p.instanceAddress(ctx, "CreateServer", instanceID)
+		if err != nil {
+			return resp, log.Done(err)
+		}
+	}
 
-	allocRes, _, _ := p.CreateIPAddress(ctx, instanceID)
-	p.alloc = *allocRes.AllocationId
-	instanceIP = *allocRes.PublicIp
+	if addr != nil {
+		instanceIP = *addr.PublicIp
+	} else {
+		allocRes, _, err := p.CreateIPAddress(ctx, instanceID)
+		if err != nil {
+			return resp, log.Done(wrapErr("CreateServer", err))
+		}
+		instanceIP = *allocRes.PublicIp
+	}
+	log.IPAssigned(instanceIP)
+	resp.ServerIP = instanceIP
+
+	// RunInstances only attaches new volumes, so existing ones are attached once it runs
+	for _, v := range s.Volumes {
+		volume := &common.CreateVolumeResponse{
+			Name:     v.ID,
+			VolumeID: v,
+			Region:   v.Region,
+		}
+		if err := p.AttachVolume(ctx, volume, resp); err != nil {
+			return resp, log.Done(err)
+		}
+	}
 
-	return &common.CreateServerResponse{
-		Name:     name,
-		ServerID: instanceID,
-		ServerIP: instanceIP,
-	}, nil
+	return resp, log.Done(nil)
 }
 
-// CreateIPAddress allocates and associates an Elastic IP to a server instance
+// CreateIPAddress allocates and associates an Elastic IP to a server instance. An address
+// that cannot be associated is released, since RemoveIPAddress only finds associated ones.
 func (p *Provider) CreateIPAddress(ctx context.Context, instanceID string) (*ec2.AllocateAddressOutput, *ec2.AssociateAddressOutput, error) {
 	svc := p.client
 
-	log.Println("Allocating IP address")
-	allocRes, err := svc.AllocateAddress(&ec2.AllocateAddressInput{
+	log := p.info.StartOp(ctx, "aws", common.Operation("CreateIPAddress"), instanceID)
+	log.Info("allocating IP address")
+	allocInput := &ec2.AllocateAddressInput{
 		Domain: aws.String("vpc"),
+	}
+	if log.DryRun("ec2.AllocateAddress", allocInput) {
+		allocRes := &ec2.AllocateAddressOutput{
+			AllocationId: aws.String(common.PlaceholderID(instanceID)),
+			PublicIp:     aws.String(common.PlaceholderIP),
+		}
+		log.DryRun("ec2.AssociateAddress", &ec2.AssociateAddressInput{
+			AllocationId: allocRes.AllocationId,
+			InstanceId:   aws.String(instanceID),
+		})
+		return allocRes, &ec2.AssociateAddressOutput{}, log.Done(nil)
+	}
+
+	var allocRes *ec2.AllocateAddressOutput
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		allocRes, err = svc.AllocateAddressWithContext(ctx, allocInput)
+		return err
 	})
 	if err != nil {
-		log.Println("Unable to allocate IP address,", err)
+		return nil, nil, log.Done(wrapErr("CreateIPAddress", err))
 	}
 
-	log.Println("Associating IP address to instance", instanceID)
-	assocRes, err := svc.AssociateAddress(&ec2.AssociateAddressInput{
-		AllocationId: allocRes.AllocationId,
-		InstanceId:   aws.String(instanceID),
+	log.Started(*allocRes.AllocationId)
+	log.IPAssigned(*allocRes.PublicIp)
+	log.Info("associating IP address")
+	var assocRes *ec2.AssociateAddressOutput
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		assocRes, err = svc.AssociateAddressWithContext(ctx, &ec2.AssociateAddressInput{
+			AllocationId: allocRes.AllocationId,
+			InstanceId:   aws.String(instanceID),
+		})
+		return err
 	})
 	if err != nil {
-		log.Println("Unable to associate IP address with", instanceID, err)
+		releaseErr := p.call(context.WithoutCancel(ctx), true, func(ctx context.Context) error {
+			_, err := svc.ReleaseAddressWithContext(ctx, &ec2.ReleaseAddressInput{
+				AllocationId: allocRes.AllocationId,
+			})
+			return err
+		})
+		if releaseErr != nil {
+			log.Warn("could not release the IP address that was not associated", "allocation", *allocRes.AllocationId, "error", releaseErr)
+		}
+		return nil, nil, log.Done(wrapErr("CreateIPAddress", err))
 	}
+	log.With("association", *assocRes.AssociationId)
 
-	log.Printf("Successfully allocated %s with instance %s.\n\tallocation id: %s, association id: %s\n", *allocRes.PublicIp, instanceID, *allocRes.AllocationId, *assocRes.AssociationId)
-	return allocRes, assocRes, nil
+	return allocRes, assocRes, log.Done(nil)
 }
 
 // CreateDNSRecord creates a DNS A Record on AWS
 func (p *Provider) CreateDNSRecord(ctx context.Context, subDomain string, IP string) (*common.CreateDNSRecordResponse, error) {
 	svc := p.router
 
-	log.Println("Creating DNS A record for", subDomain)
+	log := p.info.StartOp(ctx, "aws", common.OpCreateDNSRecord, subDomain)
+	log.Info("creating DNS record", "ip", IP)
 	request := &route53.ChangeResourceRecordSetsInput{
 		ChangeBatch: &route53.ChangeBatch{
 			Changes: []*route53.Change{
@@ -155,79 +410,190 @@ func (p *Provider) CreateDNSRecord(ctx context.Context, subDomain string, IP str
 		},
 		HostedZoneId: aws.String(p.zone),
 	}
-	resp, err := svc.ChangeResourceRecordSets(request)
+	if log.DryRun("route53.ChangeResourceRecordSets", request) {
+		return &common.CreateDNSRecordResponse{
+			SubDomain:   subDomain,
+			SubDomainID: p.ref(common.KindDNSRecord, common.PlaceholderID(subDomain)),
+			SubDomainIP: IP,
+		}, log.Done(nil)
+	}
+
+	if p.info.Adopt {
+		ok, err := p.adoptRecord(ctx, subDomain, request.ChangeBatch.Changes[0].ResourceRecordSet)
+		if err != nil {
+			return nil, log.Done(err)
+		}
+		if ok {
+			log.Info("adopting existing DNS record")
+			log.Started(p.recordName(subDomain))
+			return &common.CreateDNSRecordResponse{
+				SubDomain:   subDomain,
+				SubDomainID: p.ref(common.KindDNSRecord, p.recordName(subDomain)),
+				SubDomainIP: IP,
+			}, log.Done(nil)
+		}
+	}
+
+	var resp *route53.ChangeResourceRecordSetsOutput
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		resp, err = svc.ChangeResourceRecordSetsWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		log.Println("Unable to create DNS Record", err)
+		return nil, log.Done(wrapErr("CreateDNSRecord", err))
 	}
+	log.Started(*resp.ChangeInfo.Id)
 
 	return &common.CreateDNSRecordResponse{
 		SubDomain:   subDomain,
-		SubDomainID: *resp.ChangeInfo.Id,
+		SubDomainID: p.ref(common.KindDNSRecord, p.recordName(subDomain)),
 		SubDomainIP: IP,
-	}, nil
+	}, log.Done(nil)
+}
+
+// recordName returns the fully qualified name of a record as Route53 lists it, which is
+// its ID whether it is created, adopted or listed
+func (p *Provider) recordName(subDomain string) string {
+	return subDomain + "." + strings.TrimSuffix(p.domain, ".") + "."
 }
 
 // CreateHostedZone creates a Route53 HostedZone
 func (p *Provider) CreateHostedZone(ctx context.Context, server *common.CreateServerResponse) error {
-	log.Println("Creating hosted zone", p.domain)
+	log := p.info.StartOp(ctx, "aws", common.Operation("CreateHostedZone"), p.domain)
+	log.Info("creating hosted zone")
 
 	params := &route53.CreateHostedZoneInput{
-		CallerReference: aws.String(server.ServerID.(string)),
+		CallerReference: aws.String(server.ServerID.ID),
 		Name:            aws.String(p.domain),
 	}
-	resp, err := p.router.CreateHostedZone(params)
+	if log.DryRun("route53.CreateHostedZone", params) {
+		p.zone = common.PlaceholderID(p.domain)
+		return log.Done(nil)
+	}
+
+	var resp *route53.CreateHostedZoneOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		resp, err = p.router.CreateHostedZoneWithContext(ctx, params)
+		return err
+	})
 	if err != nil {
-		log.Println("Unable to created hosted zone,", err)
+		return log.Done(wrapErr("CreateHostedZone", err))
 	}
 	p.zone = *resp.HostedZone.Id
+	log.Started(p.zone)
 
-	return nil
+	return log.Done(nil)
 }
 
 // RemoveServer removes an EC2 instance on AWS
 func (p *Provider) RemoveServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return err
+	}
 	svc := p.client
 
-	log.Println("Removing server", server.ServerID)
+	log := p.info.StartOp(ctx, "aws", common.OpRemoveServer, server.Name)
+	log.Info("terminating instance")
 	input := &ec2.TerminateInstancesInput{
 		InstanceIds: []*string{
-			aws.String(server.ServerID.(string)),
+			aws.String(server.ServerID.ID),
 		},
 	}
-	_, err := svc.TerminateInstances(input)
-	if err != nil {
-		log.Println("Unable to remove server", err)
+	if log.DryRun("ec2.TerminateInstances", input) {
+		return log.Done(p.RemoveIPAddress(ctx, server.ServerID.ID))
 	}
 
-	err = p.RemoveIPAddress(ctx)
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := svc.TerminateInstancesWithContext(ctx, input)
+		return err
+	})
 	if err != nil {
-		log.Println("Unable to release IP address", err)
+		return log.Done(wrapErr("RemoveServer", err))
 	}
+	log.Started(server.ServerID.ID)
 
-	log.Println("Done")
+	err = p.RemoveIPAddress(ctx, server.ServerID.ID)
+	if err != nil {
+		return log.Done(wrapErr("RemoveServer", err))
+	}
 
-	return nil
+	if !server.FirewallID.IsZero() {
+		// the security group can only be deleted once no instance uses it
+		if _, err := p.waitInstance(ctx, log, server.ServerID.ID, ec2.InstanceStateNameTerminated); err != nil {
+			return log.Done(wrapErr("RemoveServer", err))
+		}
+		err := p.RemoveFirewall(ctx, &common.CreateFirewallResponse{
+			Name:       common.FirewallName(server.Name),
+			FirewallID: server.FirewallID,
+		})
+		if err != nil {
+			return log.Done(err)
+		}
+	}
+	if !server.SSHKeyID.IsZero() {
+		return log.Done(p.RemoveSSHKey(ctx, &common.ImportSSHKeyResponse{
+			Name:  common.SSHKeyName(server.Name),
+			KeyID: server.SSHKeyID,
+		}))
+	}
+	return log.Done(nil)
 }
 
-// RemoveIPAddress dissociates and releases an Elastic IP
-func (p *Provider) RemoveIPAddress(ctx context.Context) error {
+// RemoveIPAddress dissociates and releases the Elastic IP associated with an instance,
+// if any. The address is looked up so that servers loaded from a state file or listed
+// from AWS release their own address.
+func (p *Provider) RemoveIPAddress(ctx context.Context, instanceID string) error {
 	svc := p.client
 
-	_, err := svc.ReleaseAddress(&ec2.ReleaseAddressInput{
-		AllocationId: aws.String(p.alloc),
+	log := p.info.StartOp(ctx, "aws", common.Operation("RemoveIPAddress"), instanceID)
+	log.Info("releasing IP address")
+	if log.DryRun("ec2.ReleaseAddress", map[string]string{"instance-id": instanceID}) {
+		return log.Done(nil)
+	}
+
+	addr, err := p.instanceAddress(ctx, "RemoveIPAddress", instanceID)
+	if err != nil {
+		return log.Done(err)
+	}
+	if addr == nil {
+		log.Info("no IP address to release")
+		return log.Done(nil)
+	}
+
+	if addr.AssociationId != nil {
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			_, err := svc.DisassociateAddressWithContext(ctx, &ec2.DisassociateAddressInput{
+				AssociationId: addr.AssociationId,
+			})
+			return err
+		})
+		if err != nil && classify(err) != common.ErrNotFound {
+			return log.Done(wrapErr("RemoveIPAddress", err))
+		}
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := svc.ReleaseAddressWithContext(ctx, &ec2.ReleaseAddressInput{
+			AllocationId: addr.AllocationId,
+		})
+		return err
 	})
 	if err != nil {
-		log.Println("Unable to release IP address,", err)
+		return log.Done(wrapErr("RemoveIPAddress", err))
 	}
+	log.Started(aws.StringValue(addr.AllocationId))
 
-	return nil
+	return log.Done(nil)
 }
 
 // RemoveDNSRecord removes a DNS A Record from AWS
 func (p *Provider) RemoveDNSRecord(ctx context.Context, subDomain *common.CreateDNSRecordResponse) error {
 	svc := p.router
 
-	log.Println("Removing DNS A record for", subDomain.SubDomain)
+	log := p.info.StartOp(ctx, "aws", common.OpRemoveDNSRecord, subDomain.SubDomain)
+	log.Info("deleting DNS record", "ip", subDomain.SubDomainIP)
 	request := &route53.ChangeResourceRecordSetsInput{
 		ChangeBatch: &route53.ChangeBatch{
 			Changes: []*route53.Change{
@@ -248,57 +614,73 @@ func (p *Provider) RemoveDNSRecord(ctx context.Context, subDomain *common.Create
 		},
 		HostedZoneId: aws.String(p.zone),
 	}
-	_, err := svc.ChangeResourceRecordSets(request)
+	if log.DryRun("route53.ChangeResourceRecordSets", request) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		_, err := svc.ChangeResourceRecordSetsWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		log.Println("Unable to delete DNS Record", err)
+		return log.Done(wrapErr("RemoveDNSRecord", err))
 	}
+	log.Started(subDomain.SubDomainID.ID)
 
-	return nil
+	return log.Done(nil)
 }
 
 // RemoveHostedZone removes an empty Route53 HostedZone
 func (p *Provider) RemoveHostedZone(ctx context.Context) error {
 	svc := p.router
 
-	log.Println("Removing hosted zone", p.domain)
+	log := p.info.StartOp(ctx, "aws", common.Operation("RemoveHostedZone"), p.domain)
+	log.Info("deleting hosted zone")
 	params := &route53.DeleteHostedZoneInput{
 		Id: aws.String(p.zone),
 	}
-	_, err := svc.DeleteHostedZone(params)
+	if log.DryRun("route53.DeleteHostedZone", params) {
+		return log.Done(nil)
+	}
 
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := svc.DeleteHostedZoneWithContext(ctx, params)
+		return err
+	})
 	if err != nil {
-		panic(err)
+		return log.Done(wrapErr("RemoveHostedZone", err))
 	}
+	log.Started(p.zone)
 
-	return nil
+	return log.Done(nil)
 }
 
 // CreateServerGroup unimplemented for AWS
 func (p *Provider) CreateServerGroup(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerGroupResponse, error) {
-	return nil, errors.New("Unimplemented")
+	return nil, common.NotImplemented("aws", "CreateServerGroup")
 }
 
 // RemoveServerGroup unimplemented for AWS
 func (p *Provider) RemoveServerGroup(ctx context.Context, group *common.CreateServerGroupResponse) error {
-	return errors.New("Unimplemented")
+	return common.NotImplemented("aws", "RemoveServerGroup")
 }
 
 // CreateK8s unimplemented for AWS
 func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateK8sResponse, error) {
-	return nil, errors.New("Unimplemented")
+	return nil, common.NotImplemented("aws", "CreateK8s")
 }
 
 // RemoveK8s unimplemented for AWS
 func (p *Provider) RemoveK8s(ctx context.Context, k8s *common.CreateK8sResponse) error {
-	return errors.New("Unimplemented")
+	return common.NotImplemented("aws", "RemoveK8s")
 }
 
 // CreateStaticIP unimplemented for AWS
 func (p *Provider) CreateStaticIP(ctx context.Context, name string, req *common.StaticIPRequest) (*common.CreateStaticIPResponse, error) {
-	return nil, errors.New("Unimplemented")
+	return nil, common.NotImplemented("aws", "CreateStaticIP")
 }
 
 // RemoveStaticIP unimplemented for AWS
 func (p *Provider) RemoveStaticIP(ctx context.Context, staticIP *common.CreateStaticIPResponse) error {
-	return errors.New("Unimplemented")
+	return common.NotImplemented("aws", "RemoveStaticIP")
 }
@@ -0,0 +1,55 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// transientCodes are AWS error codes for throttling and temporary failures
+var transientCodes = map[string]bool{
+	"Throttling":                   true,
+	"ThrottlingException":          true,
+	"RequestLimitExceeded":         true,
+	"RequestThrottled":             true,
+	"PriorRequestNotComplete":      true,
+	"InsufficientInstanceCapacity": true,
+	"InternalError":                true,
+	"InternalFailure":              true,
+	"ServiceUnavailable":           true,
+	"Unavailable":                  true,
+}
+
+// classify maps an AWS API error to one of the common error classes
+func classify(err error) error {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return nil
+	}
+
+	code := awsErr.Code()
+	switch {
+	case transientCodes[code]:
+		return common.ErrTransient
+	case strings.HasSuffix(code, "NotFound"), strings.HasPrefix(code, "NoSuch"):
+		return common.ErrNotFound
+	case strings.HasSuffix(code, ".Duplicate"), strings.HasSuffix(code, "AlreadyExists"):
+		return common.ErrAlreadyExists
+	case strings.HasSuffix(code, "LimitExceeded"):
+		return common.ErrQuotaExceeded
+	case code == "UnauthorizedOperation", code == "AuthFailure", code == "AccessDenied", code == "InvalidClientTokenId":
+		return common.ErrPermissionDenied
+	}
+
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() >= 500 {
+		return common.ErrTransient
+	}
+
+	return nil
+}
+
+// wrapErr classifies err for the given operation, preserving it as the cause
+func wrapErr(op string, err error) error {
+	return common.NewError("aws", op, classify(err), err)
+}
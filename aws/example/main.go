@@ -40,7 +40,10 @@ func main() {
 		panic("$DOMAIN not set")
 	}
 
-	p := aws.NewProvider(domain)
+	p, err := aws.NewProvider(domain)
+	if err != nil {
+		panic(err)
+	}
 
 	ctx := context.TODO()
 
@@ -68,7 +71,7 @@ func main() {
 	}
 
 	// DNS record creation
-	subDomain := serverResp.Name + "-" + serverResp.ServerID.(string) + "." + "instances"
+	subDomain := serverResp.Name + "-" + serverResp.ServerID.ID + "." + "instances"
 	dnsResp, err3 := p.CreateDNSRecord(ctx, subDomain, serverResp.ServerIP)
 	if err3 != nil {
 		panic(err3)
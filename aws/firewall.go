@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// ipPermissions returns the ingress permissions of the rules
+func ipPermissions(rules []common.FirewallRule) []*ec2.IpPermission {
+	var perms []*ec2.IpPermission
+	for _, r := range rules {
+		var v4 []*ec2.IpRange
+		var v6 []*ec2.Ipv6Range
+		for _, source := range common.SourcesOf(r) {
+			if strings.Contains(source, ":") {
+				v6 = append(v6, &ec2.Ipv6Range{CidrIpv6: aws.String(source)})
+			} else {
+				v4 = append(v4, &ec2.IpRange{CidrIp: aws.String(source)})
+			}
+		}
+
+		perm := func(from int64, to int64) *ec2.IpPermission {
+			return &ec2.IpPermission{
+				IpProtocol: aws.String(r.Protocol),
+				FromPort:   aws.Int64(from),
+				ToPort:     aws.Int64(to),
+				IpRanges:   v4,
+				Ipv6Ranges: v6,
+			}
+		}
+		switch {
+		case r.Protocol == "icmp":
+			// -1 allows every ICMP type and code
+			perms = append(perms, perm(-1, -1))
+		case len(r.Ports) == 0:
+			perms = append(perms, perm(0, 65535))
+		}
+		for _, port := range r.Ports {
+			// Validate has checked the ports
+			from, to, _ := common.PortRange(port)
+			perms = append(perms, perm(from, to))
+		}
+	}
+	return perms
+}
+
+// CreateFirewall creates a security group on AWS with the ingress rules, in the VPC of the
+// network or the default VPC. Security groups are attached to instances with ServerFirewall; they cannot
+// target tags.
+func (p *Provider) CreateFirewall(ctx context.Context, name string, req *common.FirewallRequest) (*common.CreateFirewallResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if len(req.TargetTags) > 0 {
+		return nil, common.NotImplemented("aws", "CreateFirewall with target tags")
+	}
+
+	log := p.info.StartOp(ctx, "aws", common.OpCreateFirewall, name)
+	log.Info("creating security group")
+	input := &ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(name),
+		Description: aws.String("cpt firewall " + name),
+	}
+	if !req.Network.IsZero() {
+		if err := req.Network.Check("aws", common.KindNetwork); err != nil {
+			return nil, log.Done(err)
+		}
+		input.VpcId = aws.String(req.Network.ID)
+	}
+	ingress := &ec2.AuthorizeSecurityGroupIngressInput{
+		IpPermissions: ipPermissions(req.Rules),
+	}
+	if log.DryRun("ec2.CreateSecurityGroup", input) {
+		log.DryRun("ec2.AuthorizeSecurityGroupIngress", ingress)
+		return &common.CreateFirewallResponse{
+			Name:       name,
+			FirewallID: p.ref(common.KindFirewall, common.PlaceholderID(name)),
+		}, log.Done(nil)
+	}
+
+	var out *ec2.CreateSecurityGroupOutput
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		out, err = p.client.CreateSecurityGroupWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateFirewall", err))
+	}
+	groupID := aws.StringValue(out.GroupId)
+	log.Started(groupID)
+	resp := &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: p.ref(common.KindFirewall, groupID),
+	}
+
+	ingress.GroupId = out.GroupId
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.AuthorizeSecurityGroupIngressWithContext(ctx, ingress)
+		return err
+	})
+	if err != nil {
+		if rmErr := p.RemoveFirewall(ctx, resp); rmErr != nil {
+			log.Warn("could not remove the security group", "error", rmErr)
+		}
+		return nil, log.Done(wrapErr("CreateFirewall", err))
+	}
+
+	return resp, log.Done(nil)
+}
+
+// existingFirewall returns the security group with the name in the network, or an empty
+// response if there is none
+func (p *Provider) existingFirewall(ctx context.Context, name string, network common.Ref) (*common.CreateFirewallResponse, error) {
+	filters := filter("group-name", name)
+	if !network.IsZero() {
+		filters = append(filters, filter("vpc-id", network.ID)...)
+	}
+	var desc *ec2.DescribeSecurityGroupsOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		desc, err = p.client.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{
+			Filters: filters,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, wrapErr("CreateFirewall", err)
+	}
+	if len(desc.SecurityGroups) == 0 {
+		return &common.CreateFirewallResponse{}, nil
+	}
+
+	return &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: p.ref(common.KindFirewall, aws.StringValue(desc.SecurityGroups[0].GroupId)),
+	}, nil
+}
+
+// serverFirewall creates the security group of an instance created with ServerFirewall in
+// the network of the instance. An existing security group with the name is kept when
+// adopting.
+func (p *Provider) serverFirewall(ctx context.Context, server string, network common.Ref, rules []common.FirewallRule) (*common.CreateFirewallResponse, error) {
+	name := common.FirewallName(server)
+
+	if p.info.Adopt {
+		existing, err := p.existingFirewall(ctx, name, network)
+		if err != nil || !existing.FirewallID.IsZero() {
+			return existing, err
+		}
+	}
+
+	return p.CreateFirewall(ctx, name, &common.FirewallRequest{Rules: rules, Network: network})
+}
+
+// RemoveFirewall removes a security group on AWS. It must not be attached to any
+// instance, including terminating ones.
+func (p *Provider) RemoveFirewall(ctx context.Context, firewall *common.CreateFirewallResponse) error {
+	if err := firewall.FirewallID.Check("aws", common.KindFirewall); err != nil {
+		return err
+	}
+	groupID := firewall.FirewallID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpRemoveFirewall, firewall.Name)
+	log.Info("deleting security group")
+	input := &ec2.DeleteSecurityGroupInput{
+		GroupId: aws.String(groupID),
+	}
+	if log.DryRun("ec2.DeleteSecurityGroup", input) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.DeleteSecurityGroupWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveFirewall", err))
+	}
+	log.Started(groupID)
+
+	return log.Done(nil)
+}
@@ -0,0 +1,175 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// imageResponse returns the response describing an AMI
+func (p *Provider) imageResponse(img *ec2.Image, source string) *common.CreateImageResponse {
+	imageID := aws.StringValue(img.ImageId)
+	return &common.CreateImageResponse{
+		Name:    aws.StringValue(img.Name),
+		ImageID: p.ref(common.KindImage, imageID),
+		Image:   imageID,
+		Source:  source,
+	}
+}
+
+// describeImage returns the AMI with the ID
+func (p *Provider) describeImage(ctx context.Context, imageID string) (*ec2.Image, error) {
+	var desc *ec2.DescribeImagesOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		desc, err = p.client.DescribeImagesWithContext(ctx, &ec2.DescribeImagesInput{
+			ImageIds: []*string{aws.String(imageID)},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(desc.Images) == 0 {
+		return nil, common.NewError("aws", "DescribeImages", common.ErrNotFound, fmt.Errorf("image %s", imageID))
+	}
+	return desc.Images[0], nil
+}
+
+// CreateImageFromServer creates an AMI of an EC2 instance on AWS and waits until it is
+// available. The instance is rebooted so its file systems are consistent.
+func (p *Provider) CreateImageFromServer(ctx context.Context, server *common.CreateServerResponse, name string) (*common.CreateImageResponse, error) {
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return nil, err
+	}
+
+	log := p.info.StartOp(ctx, "aws", common.OpCreateImage, name).With("server", server.Name)
+	log.Info("creating image")
+	input := &ec2.CreateImageInput{
+		InstanceId: aws.String(server.ServerID.ID),
+		Name:       aws.String(name),
+	}
+	if log.DryRun("ec2.CreateImage", input) {
+		return p.imageResponse(&ec2.Image{
+			ImageId: aws.String(common.PlaceholderID(name)),
+			Name:    aws.String(name),
+		}, server.Name), log.Done(nil)
+	}
+
+	var out *ec2.CreateImageOutput
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		out, err = p.client.CreateImageWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateImageFromServer", err))
+	}
+	imageID := aws.StringValue(out.ImageId)
+	log.Started(imageID)
+
+	var img *ec2.Image
+	err = wait.Poll(ctx, wait.Image, func(ctx context.Context) (bool, error) {
+		desc, err := p.client.DescribeImagesWithContext(ctx, &ec2.DescribeImagesInput{
+			ImageIds: []*string{aws.String(imageID)},
+		})
+		if err != nil || len(desc.Images) == 0 {
+			// new AMIs are not always visible right away
+			return false, pollErr(err)
+		}
+
+		img = desc.Images[0]
+		state := aws.StringValue(img.State)
+		log.Status(state)
+		if state == ec2.ImageStateFailed {
+			return false, fmt.Errorf("image %s failed", imageID)
+		}
+		return state == ec2.ImageStateAvailable, nil
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateImageFromServer", err))
+	}
+
+	return p.imageResponse(img, server.Name), log.Done(nil)
+}
+
+// ListImages lists the AMIs owned by the account
+func (p *Provider) ListImages(ctx context.Context, opts ...common.ListOption) ([]*common.CreateImageResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var desc *ec2.DescribeImagesOutput
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		desc, err = p.client.DescribeImagesWithContext(ctx, &ec2.DescribeImagesInput{
+			Owners: []*string{aws.String("self")},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, wrapErr("ListImages", err)
+	}
+
+	var images []*common.CreateImageResponse
+	for _, img := range desc.Images {
+		if l.Match(aws.StringValue(img.Name), tagLabels(img.Tags)) {
+			images = append(images, p.imageResponse(img, ""))
+		}
+	}
+
+	return images, nil
+}
+
+// RemoveImage deregisters an AMI on AWS and deletes its EBS snapshots
+func (p *Provider) RemoveImage(ctx context.Context, image *common.CreateImageResponse) error {
+	if err := image.ImageID.Check("aws", common.KindImage); err != nil {
+		return err
+	}
+	imageID := image.ImageID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpRemoveImage, image.Name)
+	log.Info("deregistering image")
+	input := &ec2.DeregisterImageInput{
+		ImageId: aws.String(imageID),
+	}
+	if log.DryRun("ec2.DeregisterImage", input) {
+		return log.Done(nil)
+	}
+
+	img, err := p.describeImage(ctx, imageID)
+	if err != nil {
+		return log.Done(wrapErr("RemoveImage", err))
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.DeregisterImageWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveImage", err))
+	}
+	log.Started(imageID)
+
+	for _, mapping := range img.BlockDeviceMappings {
+		if mapping.Ebs == nil || mapping.Ebs.SnapshotId == nil {
+			continue
+		}
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			_, err := p.client.DeleteSnapshotWithContext(ctx, &ec2.DeleteSnapshotInput{
+				SnapshotId: mapping.Ebs.SnapshotId,
+			})
+			return err
+		})
+		if err != nil {
+			return log.Done(wrapErr("RemoveImage", err))
+		}
+	}
+
+	return log.Done(nil)
+}
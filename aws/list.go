@@ -0,0 +1,179 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// serverResponse returns the response describing an instance
+func (p *Provider) serverResponse(ins *ec2.Instance) *common.CreateServerResponse {
+	var zone string
+	if ins.Placement != nil {
+		zone = aws.StringValue(ins.Placement.AvailabilityZone)
+	}
+
+	return &common.CreateServerResponse{
+		Name:         tagValue(ins.Tags, "Name"),
+		ServerID:     p.ref(common.KindServer, aws.StringValue(ins.InstanceId)),
+		ServerRegion: zone,
+		ServerIP:     aws.StringValue(ins.PublicIpAddress),
+		Expires:      common.Expires(instanceLabels(ins)),
+	}
+}
+
+// instanceLabels returns the tags of an instance as "key=value" tags
+func instanceLabels(ins *ec2.Instance) []string {
+	return tagLabels(ins.Tags)
+}
+
+// tagLabels returns EC2 tags as "key=value" tags
+func tagLabels(tags []*ec2.Tag) []string {
+	labels := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		labels[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return common.LabelTags(labels)
+}
+
+// describeInstances returns the instances that are not terminated and match the filters
+func (p *Provider) describeInstances(ctx context.Context, filters ...*ec2.Filter) ([]*ec2.Instance, error) {
+	filters = append(filters, &ec2.Filter{
+		Name: aws.String("instance-state-name"),
+		Values: aws.StringSlice([]string{
+			ec2.InstanceStateNamePending,
+			ec2.InstanceStateNameRunning,
+			ec2.InstanceStateNameStopping,
+			ec2.InstanceStateNameStopped,
+		}),
+	})
+
+	var instances []*ec2.Instance
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		instances = nil
+		return p.client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+			Filters: filters,
+		}, func(page *ec2.DescribeInstancesOutput, last bool) bool {
+			for _, res := range page.Reservations {
+				instances = append(instances, res.Instances...)
+			}
+			return true
+		})
+	})
+	return instances, err
+}
+
+// ListServers lists the instances, in the availability zone or region set with
+// common.ListRegion if any
+func (p *Provider) ListServers(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := p.describeInstances(ctx)
+	if err != nil {
+		return nil, wrapErr("ListServers", err)
+	}
+
+	var servers []*common.CreateServerResponse
+	for _, ins := range instances {
+		server := p.serverResponse(ins)
+		if !strings.HasPrefix(server.ServerRegion, l.Region) {
+			continue
+		}
+		if l.Match(server.Name, instanceLabels(ins)) {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers, nil
+}
+
+// GetServer returns the instance tagged with the name
+func (p *Provider) GetServer(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateServerResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := p.describeInstances(ctx, &ec2.Filter{
+		Name:   aws.String("tag:Name"),
+		Values: []*string{aws.String(name)},
+	})
+	if err != nil {
+		return nil, wrapErr("GetServer", err)
+	}
+
+	for _, ins := range instances {
+		if server := p.serverResponse(ins); strings.HasPrefix(server.ServerRegion, l.Region) {
+			return server, nil
+		}
+	}
+
+	return nil, common.NewError("aws", "GetServer", common.ErrNotFound, fmt.Errorf("instance %s", name))
+}
+
+// ListDNSRecords lists the A records of the hosted zone. DNS records carry no tags.
+func (p *Provider) ListDNSRecords(ctx context.Context, opts ...common.ListOption) ([]*common.CreateDNSRecordResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := "." + strings.TrimSuffix(p.domain, ".") + "."
+	var records []*common.CreateDNSRecordResponse
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		records = nil
+		return p.router.ListResourceRecordSetsPagesWithContext(ctx, &route53.ListResourceRecordSetsInput{
+			HostedZoneId: aws.String(p.zone),
+		}, func(page *route53.ListResourceRecordSetsOutput, last bool) bool {
+			for _, set := range page.ResourceRecordSets {
+				name := aws.StringValue(set.Name)
+				if aws.StringValue(set.Type) != "A" || !strings.HasSuffix(name, suffix) || len(set.ResourceRecords) == 0 {
+					continue
+				}
+
+				subDomain := strings.TrimSuffix(name, suffix)
+				if l.Match(subDomain, nil) {
+					records = append(records, &common.CreateDNSRecordResponse{
+						SubDomain:   subDomain,
+						SubDomainID: p.ref(common.KindDNSRecord, p.recordName(subDomain)),
+						SubDomainIP: aws.StringValue(set.ResourceRecords[0].Value),
+					})
+				}
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return nil, wrapErr("ListDNSRecords", err)
+	}
+
+	return records, nil
+}
+
+// ListServerGroups unimplemented for AWS
+func (p *Provider) ListServerGroups(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerGroupResponse, error) {
+	return nil, common.NotImplemented("aws", "ListServerGroups")
+}
+
+// ListK8s unimplemented for AWS
+func (p *Provider) ListK8s(ctx context.Context, opts ...common.ListOption) ([]*common.CreateK8sResponse, error) {
+	return nil, common.NotImplemented("aws", "ListK8s")
+}
+
+// GetK8s unimplemented for AWS
+func (p *Provider) GetK8s(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateK8sResponse, error) {
+	return nil, common.NotImplemented("aws", "GetK8s")
+}
+
+// ListStaticIPs unimplemented for AWS
+func (p *Provider) ListStaticIPs(ctx context.Context, opts ...common.ListOption) ([]*common.CreateStaticIPResponse, error) {
+	return nil, common.NotImplemented("aws", "ListStaticIPs")
+}
@@ -0,0 +1,293 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// filter returns a filter matching resources whose field has the value
+func filter(name string, value string) []*ec2.Filter {
+	return []*ec2.Filter{{
+		Name:   aws.String(name),
+		Values: []*string{aws.String(value)},
+	}}
+}
+
+// networkSubnet returns the subnet of a network created with CreateNetwork, or "" for the
+// default VPC if network is zero. In dry run mode the subnet is a placeholder, since the
+// network may be planned as well.
+func (p *Provider) networkSubnet(ctx context.Context, network common.Ref) (string, error) {
+	if network.IsZero() {
+		return "", nil
+	}
+	if err := network.Check("aws", common.KindNetwork); err != nil {
+		return "", err
+	}
+	if p.info.DryRun != nil {
+		return common.PlaceholderID(network.ID), nil
+	}
+
+	var desc *ec2.DescribeSubnetsOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		desc, err = p.client.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{
+			Filters: filter("vpc-id", network.ID),
+		})
+		return err
+	})
+	if err != nil {
+		return "", wrapErr("CreateServer", err)
+	}
+	if len(desc.Subnets) == 0 {
+		return "", common.NewError("aws", "CreateServer", common.ErrNotFound, fmt.Errorf("no subnet in network %s", network.ID))
+	}
+	return aws.StringValue(desc.Subnets[0].SubnetId), nil
+}
+
+// CreateNetwork creates a VPC on AWS with a subnet covering the CIDR, in the availability
+// zone if the region is set, and an internet gateway so that its instances can be given
+// Elastic IPs. Security groups are created in the VPC with FirewallRequest.Network.
+func (p *Provider) CreateNetwork(ctx context.Context, name string, req *common.NetworkRequest) (*common.CreateNetworkResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	log := p.info.StartOp(ctx, "aws", common.OpCreateNetwork, name)
+	log.Info("creating VPC", "cidr", req.CIDR, "zone", req.Region)
+	input := &ec2.CreateVpcInput{
+		CidrBlock: aws.String(req.CIDR),
+	}
+	subnetInput := &ec2.CreateSubnetInput{
+		CidrBlock: aws.String(req.CIDR),
+	}
+	if len(req.Region) > 0 {
+		subnetInput.AvailabilityZone = aws.String(req.Region)
+	}
+	if log.DryRun("ec2.CreateVpc", input) {
+		log.DryRun("ec2.CreateSubnet", subnetInput)
+		log.DryRun("ec2.CreateInternetGateway", &ec2.CreateInternetGatewayInput{})
+		return &common.CreateNetworkResponse{
+			Name:      name,
+			NetworkID: p.ref(common.KindNetwork, common.PlaceholderID(name)),
+			CIDR:      req.CIDR,
+			Region:    req.Region,
+		}, log.Done(nil)
+	}
+
+	var vpc *ec2.CreateVpcOutput
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		vpc, err = p.client.CreateVpcWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateNetwork", err))
+	}
+	vpcID := aws.StringValue(vpc.Vpc.VpcId)
+	log.Started(vpcID)
+	resp := &common.CreateNetworkResponse{
+		Name:      name,
+		NetworkID: p.ref(common.KindNetwork, vpcID),
+		CIDR:      req.CIDR,
+		Region:    req.Region,
+	}
+
+	if err := p.setupNetwork(ctx, log, name, vpcID, subnetInput); err != nil {
+		if rmErr := p.RemoveNetwork(context.WithoutCancel(ctx), resp); rmErr != nil {
+			log.Warn("could not remove the VPC", "error", rmErr)
+		}
+		return nil, log.Done(wrapErr("CreateNetwork", err))
+	}
+
+	return resp, log.Done(nil)
+}
+
+// setupNetwork tags a new VPC and creates its subnet, internet gateway and default route
+func (p *Provider) setupNetwork(ctx context.Context, log *common.OpLog, name string, vpcID string, subnetInput *ec2.CreateSubnetInput) error {
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+			Resources: []*string{aws.String(vpcID)},
+			Tags:      p.resourceTags(ec2.ResourceTypeVpc, name, time.Time{})[0].Tags,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = wait.Poll(ctx, wait.Operation, func(ctx context.Context) (bool, error) {
+		desc, err := p.client.DescribeVpcsWithContext(ctx, &ec2.DescribeVpcsInput{
+			VpcIds: []*string{aws.String(vpcID)},
+		})
+		if err != nil {
+			return false, pollErr(err)
+		}
+		if len(desc.Vpcs) == 0 {
+			return false, nil
+		}
+		log.Status(aws.StringValue(desc.Vpcs[0].State))
+		return aws.StringValue(desc.Vpcs[0].State) == ec2.VpcStateAvailable, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	subnetInput.VpcId = aws.String(vpcID)
+	err = p.call(ctx, false, func(ctx context.Context) error {
+		_, err := p.client.CreateSubnetWithContext(ctx, subnetInput)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var igw *ec2.CreateInternetGatewayOutput
+	err = p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		igw, err = p.client.CreateInternetGatewayWithContext(ctx, &ec2.CreateInternetGatewayInput{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	igwID := igw.InternetGateway.InternetGatewayId
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.AttachInternetGatewayWithContext(ctx, &ec2.AttachInternetGatewayInput{
+			InternetGatewayId: igwID,
+			VpcId:             aws.String(vpcID),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// the main route table of the VPC routes its subnet
+	var tables *ec2.DescribeRouteTablesOutput
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		tables, err = p.client.DescribeRouteTablesWithContext(ctx, &ec2.DescribeRouteTablesInput{
+			Filters: filter("vpc-id", vpcID),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(tables.RouteTables) == 0 {
+		return fmt.Errorf("VPC %s has no route table", vpcID)
+	}
+	return p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.CreateRouteWithContext(ctx, &ec2.CreateRouteInput{
+			RouteTableId:         tables.RouteTables[0].RouteTableId,
+			DestinationCidrBlock: aws.String(common.AnySource),
+			GatewayId:            igwID,
+		})
+		return err
+	})
+}
+
+// deleteInUse calls del until the resource it deletes is no longer used by terminating
+// instances or their network interfaces
+func (p *Provider) deleteInUse(ctx context.Context, del func(ctx context.Context) error) error {
+	return wait.Poll(ctx, wait.Operation, func(ctx context.Context) (bool, error) {
+		err := p.call(ctx, true, del)
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "DependencyViolation" {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+// RemoveNetwork removes a VPC on AWS with its subnets and internet gateways. Its
+// instances and security groups must be removed first; subnets are deleted once the
+// terminating instances release them.
+func (p *Provider) RemoveNetwork(ctx context.Context, network *common.CreateNetworkResponse) error {
+	if err := network.NetworkID.Check("aws", common.KindNetwork); err != nil {
+		return err
+	}
+	vpcID := network.NetworkID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpRemoveNetwork, network.Name)
+	log.Info("deleting VPC")
+	input := &ec2.DeleteVpcInput{
+		VpcId: aws.String(vpcID),
+	}
+	if log.DryRun("ec2.DeleteVpc", input) {
+		return log.Done(nil)
+	}
+
+	var igws *ec2.DescribeInternetGatewaysOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		igws, err = p.client.DescribeInternetGatewaysWithContext(ctx, &ec2.DescribeInternetGatewaysInput{
+			Filters: filter("attachment.vpc-id", vpcID),
+		})
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveNetwork", err))
+	}
+	for _, igw := range igws.InternetGateways {
+		err := p.deleteInUse(ctx, func(ctx context.Context) error {
+			_, err := p.client.DetachInternetGatewayWithContext(ctx, &ec2.DetachInternetGatewayInput{
+				InternetGatewayId: igw.InternetGatewayId,
+				VpcId:             aws.String(vpcID),
+			})
+			return err
+		})
+		if err != nil {
+			return log.Done(wrapErr("RemoveNetwork", err))
+		}
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			_, err := p.client.DeleteInternetGatewayWithContext(ctx, &ec2.DeleteInternetGatewayInput{
+				InternetGatewayId: igw.InternetGatewayId,
+			})
+			return err
+		})
+		if err != nil {
+			return log.Done(wrapErr("RemoveNetwork", err))
+		}
+	}
+
+	var subnets *ec2.DescribeSubnetsOutput
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		subnets, err = p.client.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{
+			Filters: filter("vpc-id", vpcID),
+		})
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveNetwork", err))
+	}
+	for _, subnet := range subnets.Subnets {
+		err := p.deleteInUse(ctx, func(ctx context.Context) error {
+			_, err := p.client.DeleteSubnetWithContext(ctx, &ec2.DeleteSubnetInput{
+				SubnetId: subnet.SubnetId,
+			})
+			return err
+		})
+		if err != nil {
+			return log.Done(wrapErr("RemoveNetwork", err))
+		}
+	}
+
+	err = p.deleteInUse(ctx, func(ctx context.Context) error {
+		_, err := p.client.DeleteVpcWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveNetwork", err))
+	}
+	log.Started(vpcID)
+
+	return log.Done(nil)
+}
@@ -0,0 +1,191 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// waitInstance polls the instance until it is in the state
+func (p *Provider) waitInstance(ctx context.Context, log *common.OpLog, instanceID string, state string) (*ec2.Instance, error) {
+	var instance *ec2.Instance
+	err := wait.Poll(ctx, wait.Server, func(ctx context.Context) (bool, error) {
+		desc, err := p.client.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []*string{aws.String(instanceID)},
+		})
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		for _, res := range desc.Reservations {
+			for _, ins := range res.Instances {
+				if ins.State == nil {
+					continue
+				}
+				log.Status(aws.StringValue(ins.State.Name))
+				if aws.StringValue(ins.State.Name) == state {
+					instance = ins
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	})
+	return instance, err
+}
+
+// StopServer stops an EC2 instance on AWS and waits until it is stopped
+func (p *Provider) StopServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return err
+	}
+	instanceID := server.ServerID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpStopServer, server.Name)
+	log.Info("stopping instance")
+	input := &ec2.StopInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	}
+	if log.DryRun("ec2.StopInstances", input) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.StopInstancesWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("StopServer", err))
+	}
+	log.Started(instanceID)
+
+	if _, err := p.waitInstance(ctx, log, instanceID, ec2.InstanceStateNameStopped); err != nil {
+		return log.Done(wrapErr("StopServer", err))
+	}
+	return log.Done(nil)
+}
+
+// StartServer starts a stopped EC2 instance on AWS and waits until it is running. The
+// Elastic IP stays associated while the instance is stopped.
+func (p *Provider) StartServer(ctx context.Context, server *common.CreateServerResponse) (*common.CreateServerResponse, error) {
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return nil, err
+	}
+	instanceID := server.ServerID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpStartServer, server.Name)
+	log.Info("starting instance")
+	input := &ec2.StartInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	}
+	if log.DryRun("ec2.StartInstances", input) {
+		return server, log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.StartInstancesWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("StartServer", err))
+	}
+	log.Started(instanceID)
+
+	ins, err := p.waitInstance(ctx, log, instanceID, ec2.InstanceStateNameRunning)
+	if err != nil {
+		return nil, log.Done(wrapErr("StartServer", err))
+	}
+	resp := p.serverResponse(ins)
+	log.IPAssigned(resp.ServerIP)
+
+	return resp, log.Done(nil)
+}
+
+// RebootServer reboots an EC2 instance on AWS and waits until it is running
+func (p *Provider) RebootServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return err
+	}
+	instanceID := server.ServerID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpRebootServer, server.Name)
+	log.Info("rebooting instance")
+	input := &ec2.RebootInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	}
+	if log.DryRun("ec2.RebootInstances", input) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.RebootInstancesWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RebootServer", err))
+	}
+	log.Started(instanceID)
+
+	if _, err := p.waitInstance(ctx, log, instanceID, ec2.InstanceStateNameRunning); err != nil {
+		return log.Done(wrapErr("RebootServer", err))
+	}
+	return log.Done(nil)
+}
+
+// ResizeServer changes the instance type of an EC2 instance on AWS. A running instance
+// is stopped for the change and started again.
+func (p *Provider) ResizeServer(ctx context.Context, server *common.CreateServerResponse, size string) (*common.CreateServerResponse, error) {
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return nil, err
+	}
+	instanceID := server.ServerID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpResizeServer, server.Name).With("size", size)
+	log.Info("changing instance type")
+	input := &ec2.ModifyInstanceAttributeInput{
+		InstanceId: aws.String(instanceID),
+		InstanceType: &ec2.AttributeValue{
+			Value: aws.String(size),
+		},
+	}
+	if log.DryRun("ec2.ModifyInstanceAttribute", input) {
+		return server, log.Done(nil)
+	}
+
+	instances, err := p.describeInstances(ctx, &ec2.Filter{
+		Name:   aws.String("instance-id"),
+		Values: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("ResizeServer", err))
+	}
+	if len(instances) == 0 {
+		return nil, log.Done(common.NewError("aws", "ResizeServer", common.ErrNotFound, fmt.Errorf("instance %s", instanceID)))
+	}
+
+	running := aws.StringValue(instances[0].State.Name) != ec2.InstanceStateNameStopped
+	if running {
+		if err := p.StopServer(ctx, server); err != nil {
+			return nil, log.Done(err)
+		}
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.ModifyInstanceAttributeWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("ResizeServer", err))
+	}
+	log.Started(instanceID)
+
+	if running {
+		resp, err := p.StartServer(ctx, server)
+		return resp, log.Done(err)
+	}
+	return p.serverResponse(instances[0]), log.Done(nil)
+}
@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// throttlingCodes are AWS error codes for requests rejected before being processed
+var throttlingCodes = map[string]bool{
+	"Throttling":           true,
+	"ThrottlingException":  true,
+	"RequestLimitExceeded": true,
+	"RequestThrottled":     true,
+}
+
+// retryable reports whether a failed idempotent call should be retried
+func retryable(err error) (bool, time.Duration) {
+	return classify(err) == common.ErrTransient, 0
+}
+
+// throttled reports whether a failed call was rejected by rate limiting before being
+// processed, so that even non-idempotent calls can be retried
+func throttled(err error) (bool, time.Duration) {
+	awsErr, ok := err.(awserr.Error)
+	return ok && throttlingCodes[awsErr.Code()], 0
+}
+
+// call runs fn with the retry policy of the provider. Idempotent calls are retried on
+// any transient error, other calls only when rate limited.
+func (p *Provider) call(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	classifier := throttled
+	if idempotent {
+		classifier = retryable
+	}
+	return p.info.Retry.Do(ctx, func(err error) (bool, time.Duration) {
+		ok, after := classifier(err)
+		if ok {
+			p.info.Log.Warn("retrying cloud API call", "provider", "aws", "error", err, "after", after)
+		}
+		return ok, after
+	}, fn)
+}
+
+// clientToken returns a random token for making RunInstances idempotent
+func clientToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// pollErr returns nil for transient errors so that polling continues through them
+func pollErr(err error) error {
+	if ok, _ := retryable(err); ok {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// describeKeyPair returns the key pair with the name, or nil if there is none
+func (p *Provider) describeKeyPair(ctx context.Context, name string) (*ec2.KeyPairInfo, error) {
+	var desc *ec2.DescribeKeyPairsOutput
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		desc, err = p.client.DescribeKeyPairsWithContext(ctx, &ec2.DescribeKeyPairsInput{
+			KeyNames: []*string{aws.String(name)},
+		})
+		return err
+	})
+	if classify(err) == common.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapErr("ImportSSHKey", err)
+	}
+	if len(desc.KeyPairs) == 0 {
+		return nil, nil
+	}
+	return desc.KeyPairs[0], nil
+}
+
+// ImportSSHKey imports a public key as an EC2 key pair in the region of the client. The
+// key pair name is its ID. An existing key pair with the name is kept when adopting.
+func (p *Provider) ImportSSHKey(ctx context.Context, name string, publicKey string) (*common.ImportSSHKeyResponse, error) {
+	fingerprint, err := common.SSHFingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	resp := &common.ImportSSHKeyResponse{
+		Name:        name,
+		KeyID:       p.ref(common.KindSSHKey, name),
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+	}
+
+	log := p.info.StartOp(ctx, "aws", common.OpImportSSHKey, name)
+	log.Info("importing key pair", "fingerprint", fingerprint)
+	input := &ec2.ImportKeyPairInput{
+		KeyName:           aws.String(name),
+		PublicKeyMaterial: []byte(publicKey),
+	}
+	if log.DryRun("ec2.ImportKeyPair", input) {
+		return resp, log.Done(nil)
+	}
+
+	if p.info.Adopt {
+		existing, err := p.describeKeyPair(ctx, name)
+		if err != nil {
+			return nil, log.Done(err)
+		}
+		if existing != nil {
+			log.Info("adopting existing key pair")
+			resp.Fingerprint = aws.StringValue(existing.KeyFingerprint)
+			return resp, log.Done(nil)
+		}
+	}
+
+	var out *ec2.ImportKeyPairOutput
+	err = p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		out, err = p.client.ImportKeyPairWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("ImportSSHKey", err))
+	}
+	log.Started(name)
+	resp.Fingerprint = aws.StringValue(out.KeyFingerprint)
+
+	return resp, log.Done(nil)
+}
+
+// RemoveSSHKey deletes an EC2 key pair. Instances launched with it keep accepting it.
+func (p *Provider) RemoveSSHKey(ctx context.Context, key *common.ImportSSHKeyResponse) error {
+	if err := key.KeyID.Check("aws", common.KindSSHKey); err != nil {
+		return err
+	}
+	name := key.KeyID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpRemoveSSHKey, name)
+	log.Info("deleting key pair")
+	input := &ec2.DeleteKeyPairInput{
+		KeyName: aws.String(name),
+	}
+	if log.DryRun("ec2.DeleteKeyPair", input) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.DeleteKeyPairWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveSSHKey", err))
+	}
+	log.Started(name)
+
+	return log.Done(nil)
+}
+
+// instanceKeyName returns the imported key pair of an instance, or "" if it has none or
+// its key is generated with ServerEphemeralSSHKey. An instance has at most one key pair.
+func instanceKeyName(s *common.ServerInfo) (string, error) {
+	count := len(s.SSHKeys)
+	if s.GenerateSSHKey {
+		count++
+	}
+	if count > 1 {
+		return "", fmt.Errorf("aws: an instance has a single key pair, got %d", count)
+	}
+
+	if len(s.SSHKeys) == 0 {
+		return "", nil
+	}
+	if err := s.SSHKeys[0].Check("aws", common.KindSSHKey); err != nil {
+		return "", err
+	}
+	return s.SSHKeys[0].ID, nil
+}
+
+// ephemeralSSHKey imports the key generated with ServerEphemeralSSHKey, if any, and
+// returns it along with its private key. It is only called for instances being created,
+// so that adopted instances leave no key pair behind.
+func (p *Provider) ephemeralSSHKey(ctx context.Context, s *common.ServerInfo) (*common.ImportSSHKeyResponse, string, error) {
+	if !s.GenerateSSHKey {
+		return &common.ImportSSHKeyResponse{}, "", nil
+	}
+
+	publicKey, privateKey, err := common.GenerateSSHKey(s.Name)
+	if err != nil {
+		return nil, "", err
+	}
+	generated, err := p.ImportSSHKey(ctx, common.SSHKeyName(s.Name), publicKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return generated, privateKey, nil
+}
+
+// removeServerKey removes the key pair generated for a server that could not be created, if any
+func (p *Provider) removeServerKey(ctx context.Context, log *common.OpLog, generated *common.ImportSSHKeyResponse) {
+	if generated.KeyID.IsZero() {
+		return
+	}
+	if err := p.RemoveSSHKey(context.WithoutCancel(ctx), generated); err != nil {
+		log.Warn("could not remove the key pair of the failed server", "error", err)
+	}
+}
@@ -0,0 +1,256 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// volumeDevices are the device names AttachVolume picks from, as recommended for EBS
+// volumes on HVM instances
+var volumeDevices = []string{
+	"/dev/sdf", "/dev/sdg", "/dev/sdh", "/dev/sdi", "/dev/sdj", "/dev/sdk",
+	"/dev/sdl", "/dev/sdm", "/dev/sdn", "/dev/sdo", "/dev/sdp",
+}
+
+// volumeRef returns the reference to an EBS volume. Its region is the availability zone,
+// which must match the instances it is attached to.
+func (p *Provider) volumeRef(id string, zone string) common.Ref {
+	ref := p.ref(common.KindVolume, id)
+	ref.Region = zone
+	return ref
+}
+
+// volumeZone returns the availability zone shared by the volumes of a server, or "" if
+// there are none
+func volumeZone(volumes []common.Ref) (string, error) {
+	zone := ""
+	for _, v := range volumes {
+		if err := v.Check("aws", common.KindVolume); err != nil {
+			return "", err
+		}
+		if len(zone) > 0 && len(v.Region) > 0 && v.Region != zone {
+			return "", fmt.Errorf("aws: volumes are in zones %s and %s", zone, v.Region)
+		}
+		if len(v.Region) > 0 {
+			zone = v.Region
+		}
+	}
+	return zone, nil
+}
+
+// waitVolume polls the volume until done reports true for it
+func (p *Provider) waitVolume(ctx context.Context, log *common.OpLog, volumeID string, done func(v *ec2.Volume) (bool, error)) error {
+	return wait.Poll(ctx, wait.Volume, func(ctx context.Context) (bool, error) {
+		desc, err := p.client.DescribeVolumesWithContext(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: []*string{aws.String(volumeID)},
+		})
+		if err != nil || len(desc.Volumes) == 0 {
+			return false, pollErr(err)
+		}
+
+		v := desc.Volumes[0]
+		log.Status(aws.StringValue(v.State))
+		if aws.StringValue(v.State) == ec2.VolumeStateError {
+			return false, fmt.Errorf("volume %s failed", volumeID)
+		}
+		return done(v)
+	})
+}
+
+// attachmentState returns the state of the attachment of the volume to the instance, or ""
+func attachmentState(v *ec2.Volume, instanceID string) string {
+	for _, a := range v.Attachments {
+		if aws.StringValue(a.InstanceId) == instanceID {
+			return aws.StringValue(a.State)
+		}
+	}
+	return ""
+}
+
+// freeDevice returns the first device name in volumeDevices not used by the instance
+func (p *Provider) freeDevice(ctx context.Context, instanceID string) (string, error) {
+	instances, err := p.describeInstances(ctx, &ec2.Filter{
+		Name:   aws.String("instance-id"),
+		Values: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(instances) == 0 {
+		return "", common.NewError("aws", "AttachVolume", common.ErrNotFound, fmt.Errorf("instance %s", instanceID))
+	}
+
+	used := make(map[string]bool)
+	for _, mapping := range instances[0].BlockDeviceMappings {
+		used[aws.StringValue(mapping.DeviceName)] = true
+	}
+	for _, device := range volumeDevices {
+		if !used[device] {
+			return device, nil
+		}
+	}
+	return "", fmt.Errorf("aws: instance %s has no free device for a volume", instanceID)
+}
+
+// CreateVolume creates an EBS volume on AWS and waits until it is available. The region
+// is the availability zone of the volume and the type an EBS volume type such as gp2.
+func (p *Provider) CreateVolume(ctx context.Context, name string, req *common.VolumeRequest) (*common.CreateVolumeResponse, error) {
+	input := &ec2.CreateVolumeInput{
+		AvailabilityZone:  aws.String(req.Region),
+		Size:              aws.Int64(req.SizeGB),
+		TagSpecifications: p.resourceTags(ec2.ResourceTypeVolume, name, time.Time{}),
+	}
+	if len(req.Type) > 0 {
+		input.VolumeType = aws.String(req.Type)
+	}
+
+	log := p.info.StartOp(ctx, "aws", common.OpCreateVolume, name).With("zone", req.Region)
+	log.Info("creating volume", "size", req.SizeGB)
+	if log.DryRun("ec2.CreateVolume", input) {
+		return &common.CreateVolumeResponse{
+			Name:     name,
+			VolumeID: p.volumeRef(common.PlaceholderID(name), req.Region),
+			Region:   req.Region,
+			SizeGB:   req.SizeGB,
+		}, log.Done(nil)
+	}
+
+	var volume *ec2.Volume
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		volume, err = p.client.CreateVolumeWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateVolume", err))
+	}
+	volumeID := aws.StringValue(volume.VolumeId)
+	log.Started(volumeID)
+
+	err = p.waitVolume(ctx, log, volumeID, func(v *ec2.Volume) (bool, error) {
+		return aws.StringValue(v.State) == ec2.VolumeStateAvailable, nil
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateVolume", err))
+	}
+
+	return &common.CreateVolumeResponse{
+		Name:     name,
+		VolumeID: p.volumeRef(volumeID, req.Region),
+		Region:   req.Region,
+		SizeGB:   aws.Int64Value(volume.Size),
+	}, log.Done(nil)
+}
+
+// AttachVolume attaches an EBS volume to an instance in the same availability zone on
+// AWS, as the first free device from /dev/sdf to /dev/sdp
+func (p *Provider) AttachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := volume.VolumeID.Check("aws", common.KindVolume); err != nil {
+		return err
+	}
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return err
+	}
+	volumeID := volume.VolumeID.ID
+	instanceID := server.ServerID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpAttachVolume, volume.Name).With("server", server.Name)
+	log.Info("attaching volume")
+	input := &ec2.AttachVolumeInput{
+		InstanceId: aws.String(instanceID),
+		VolumeId:   aws.String(volumeID),
+	}
+	if log.DryRun("ec2.AttachVolume", input) {
+		return log.Done(nil)
+	}
+
+	device, err := p.freeDevice(ctx, instanceID)
+	if err != nil {
+		return log.Done(wrapErr("AttachVolume", err))
+	}
+	input.Device = aws.String(device)
+
+	err = p.call(ctx, false, func(ctx context.Context) error {
+		_, err := p.client.AttachVolumeWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("AttachVolume", err))
+	}
+	log.Started(device)
+
+	err = p.waitVolume(ctx, log, volumeID, func(v *ec2.Volume) (bool, error) {
+		return attachmentState(v, instanceID) == ec2.VolumeAttachmentStateAttached, nil
+	})
+	return log.Done(wrapErr("AttachVolume", err))
+}
+
+// DetachVolume detaches an EBS volume from an instance on AWS and waits until it is
+// available
+func (p *Provider) DetachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := volume.VolumeID.Check("aws", common.KindVolume); err != nil {
+		return err
+	}
+	if err := server.ServerID.Check("aws", common.KindServer); err != nil {
+		return err
+	}
+	volumeID := volume.VolumeID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpDetachVolume, volume.Name).With("server", server.Name)
+	log.Info("detaching volume")
+	input := &ec2.DetachVolumeInput{
+		InstanceId: aws.String(server.ServerID.ID),
+		VolumeId:   aws.String(volumeID),
+	}
+	if log.DryRun("ec2.DetachVolume", input) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.DetachVolumeWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("DetachVolume", err))
+	}
+	log.Started(volumeID)
+
+	err = p.waitVolume(ctx, log, volumeID, func(v *ec2.Volume) (bool, error) {
+		return aws.StringValue(v.State) == ec2.VolumeStateAvailable, nil
+	})
+	return log.Done(wrapErr("DetachVolume", err))
+}
+
+// RemoveVolume removes an EBS volume on AWS. The volume must not be attached.
+func (p *Provider) RemoveVolume(ctx context.Context, volume *common.CreateVolumeResponse) error {
+	if err := volume.VolumeID.Check("aws", common.KindVolume); err != nil {
+		return err
+	}
+	volumeID := volume.VolumeID.ID
+
+	log := p.info.StartOp(ctx, "aws", common.OpRemoveVolume, volume.Name)
+	log.Info("deleting volume")
+	input := &ec2.DeleteVolumeInput{
+		VolumeId: aws.String(volumeID),
+	}
+	if log.DryRun("ec2.DeleteVolume", input) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.DeleteVolumeWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveVolume", err))
+	}
+	log.Started(volumeID)
+
+	return log.Done(nil)
+}
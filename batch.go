@@ -0,0 +1,235 @@
+package cpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// DefaultBatchWorkers is the number of concurrent calls of a Batch unless configured otherwise
+const DefaultBatchWorkers = 10
+
+// Batch creates many identical servers concurrently
+type Batch struct {
+	p CloudProvider
+
+	// Workers bounds the number of concurrent calls. Zero means DefaultBatchWorkers.
+	Workers int
+	// Interval is the minimum delay between starting calls. Zero means the CreateInterval
+	// of the provider capabilities.
+	Interval time.Duration
+	// MinSuccess is the number of servers that must be created. If fewer are, the servers
+	// that were created are removed. Zero keeps whatever was created.
+	MinSuccess int
+	// RollbackTimeout bounds the removal of the created servers, which is not cancelled
+	// with the context passed to CreateServers. Zero means no timeout.
+	RollbackTimeout time.Duration
+}
+
+// NewBatch returns a Batch creating servers with p
+func NewBatch(p CloudProvider) *Batch {
+	return &Batch{p: p}
+}
+
+// BatchResult is the outcome of creating one server of a batch
+type BatchResult struct {
+	Name string
+	// Server is the created server, or nil if creation failed. A server that was created
+	// but not fully set up is returned along with Err.
+	Server *common.CreateServerResponse
+	// Err is the error creating the server, if any
+	Err error
+	// RolledBack reports whether the server was removed because too few servers were created
+	RolledBack bool
+}
+
+// BatchError reports the servers of a batch that could not be created and, if fewer than
+// the minimum were created, any failures removing the others. It unwraps to the errors
+// creating the servers.
+type BatchError struct {
+	Created    int
+	Failed     int
+	MinSuccess int
+	// Errs are the errors creating the servers that failed
+	Errs []error
+	// RolledBack reports whether the created servers were removed
+	RolledBack bool
+	Rollbacks  []*RollbackError
+}
+
+func (e *BatchError) Error() string {
+	msg := fmt.Sprintf("%d of %d servers failed", e.Failed, e.Created+e.Failed)
+	if len(e.Errs) > 0 {
+		msg += ": " + e.Errs[0].Error()
+	}
+	if !e.RolledBack {
+		return msg
+	}
+
+	msg += fmt.Sprintf("; fewer than %d created", e.MinSuccess)
+	if len(e.Rollbacks) == 0 {
+		return msg + "; rolled back"
+	}
+	failures := make([]string, len(e.Rollbacks))
+	for i, r := range e.Rollbacks {
+		failures[i] = fmt.Sprintf("removing %s: %v", r.Step, r.Err)
+	}
+	return msg + "; rollback failed: " + strings.Join(failures, "; ")
+}
+
+// Unwrap returns the errors creating the servers that failed
+func (e *BatchError) Unwrap() []error {
+	return e.Errs
+}
+
+// CreateServers creates count servers with p using the default batch settings
+func CreateServers(ctx context.Context, p CloudProvider, namePrefix string, count int, opts ...common.ServerOption) ([]*BatchResult, error) {
+	return NewBatch(p).CreateServers(ctx, namePrefix, count, opts...)
+}
+
+// CreateServers creates count servers named namePrefix-1 to namePrefix-count concurrently
+// and returns one result per server in name order. If any server fails, it returns a
+// *BatchError with the results. If fewer than MinSuccess servers are created, the created
+// servers, including those returned with an error, are removed before returning.
+func (b *Batch) CreateServers(ctx context.Context, namePrefix string, count int, opts ...common.ServerOption) ([]*BatchResult, error) {
+	caps := b.p.Capabilities()
+	if err := caps.Check(common.OpCreateServer, opts...); err != nil {
+		return nil, err
+	}
+
+	interval := b.Interval
+	if interval == 0 {
+		interval = caps.CreateInterval
+	}
+	l := &limiter{interval: interval}
+
+	results := make([]*BatchResult, count)
+	for i := range results {
+		results[i] = &BatchResult{Name: fmt.Sprintf("%s-%d", namePrefix, i+1)}
+	}
+
+	b.each(results, func(r *BatchResult) {
+		if r.Err = l.wait(ctx); r.Err == nil {
+			r.Server, r.Err = b.p.CreateServer(ctx, r.Name, opts...)
+		}
+	})
+
+	batchErr := &BatchError{MinSuccess: b.MinSuccess}
+	// live servers returned with an error are removed on rollback as well
+	var live []*BatchResult
+	for _, r := range results {
+		if r.Err != nil {
+			batchErr.Failed++
+			batchErr.Errs = append(batchErr.Errs, fmt.Errorf("%s: %w", r.Name, r.Err))
+		} else {
+			batchErr.Created++
+		}
+		if r.Server != nil {
+			live = append(live, r)
+		}
+	}
+
+	if batchErr.Created < b.MinSuccess {
+		batchErr.RolledBack = true
+		batchErr.Rollbacks = b.rollback(ctx, l, live)
+		return results, batchErr
+	}
+	if batchErr.Failed > 0 {
+		return results, batchErr
+	}
+	return results, nil
+}
+
+// rollback removes the live servers, which are not cancelled with ctx
+func (b *Batch) rollback(ctx context.Context, l *limiter, live []*BatchResult) []*RollbackError {
+	ctx = context.WithoutCancel(ctx)
+	if b.RollbackTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.RollbackTimeout)
+		defer cancel()
+	}
+
+	var mu sync.Mutex
+	var failures []*RollbackError
+	b.each(live, func(r *BatchResult) {
+		err := l.wait(ctx)
+		if err == nil {
+			err = b.p.RemoveServer(ctx, r.Server)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failures = append(failures, &RollbackError{"server " + r.Name, err})
+			return
+		}
+		r.RolledBack = true
+	})
+	return failures
+}
+
+// each calls fn for every result on at most Workers goroutines
+func (b *Batch) each(results []*BatchResult, fn func(*BatchResult)) {
+	workers := b.Workers
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+
+	work := make(chan *BatchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(results); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range work {
+				fn(r)
+			}
+		}()
+	}
+
+	for _, r := range results {
+		work <- r
+	}
+	close(work)
+	wg.Wait()
+}
+
+// limiter spaces out calls by a minimum interval
+type limiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the next call may start or ctx is done
+func (l *limiter) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.interval <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	t := time.NewTimer(time.Until(start))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,154 @@
+package cpt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/fake"
+)
+
+var (
+	errSetup  = errors.New("server did not become ready")
+	transient = common.NewError("fake", "CreateServer", common.ErrTransient, errors.New("rate limited"))
+)
+
+func TestCreateServers(t *testing.T) {
+	tests := []struct {
+		name string
+		// partials are the errors returned along with created servers
+		partials       []error
+		createFailures []error
+		removeFailures []error
+		minSuccess     int
+
+		wantErr        bool
+		wantCreated    int
+		wantFailed     int
+		wantRolledBack bool
+		wantRollbacks  int
+		// wantLive is the number of servers left live
+		wantLive int
+	}{
+		{
+			name:        "created",
+			wantCreated: 3,
+			wantLive:    3,
+		},
+		{
+			name:           "failure kept",
+			createFailures: []error{transient},
+			wantErr:        true,
+			wantCreated:    2,
+			wantFailed:     1,
+			wantLive:       2,
+		},
+		{
+			name:           "failure rolled back",
+			createFailures: []error{transient},
+			minSuccess:     3,
+			wantErr:        true,
+			wantCreated:    2,
+			wantFailed:     1,
+			wantRolledBack: true,
+		},
+		{
+			name:        "partial kept",
+			partials:    []error{errSetup},
+			wantErr:     true,
+			wantCreated: 2,
+			wantFailed:  1,
+			wantLive:    3,
+		},
+		{
+			name:           "partial rolled back",
+			partials:       []error{errSetup},
+			minSuccess:     3,
+			wantErr:        true,
+			wantCreated:    2,
+			wantFailed:     1,
+			wantRolledBack: true,
+		},
+		{
+			name:           "rollback fails",
+			partials:       []error{errSetup},
+			removeFailures: []error{transient},
+			minSuccess:     3,
+			wantErr:        true,
+			wantCreated:    2,
+			wantFailed:     1,
+			wantRolledBack: true,
+			wantRollbacks:  1,
+			wantLive:       1,
+		},
+		{
+			name:           "minimum met",
+			createFailures: []error{transient},
+			minSuccess:     2,
+			wantErr:        true,
+			wantCreated:    2,
+			wantFailed:     1,
+			wantLive:       2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := fake.NewProvider()
+			fp.FailNext(fake.CreateServer, tt.createFailures...)
+			fp.FailNext(fake.RemoveServer, tt.removeFailures...)
+			fp.FailAfter(fake.CreateServer, tt.partials...)
+
+			b := cpt.NewBatch(fp)
+			b.MinSuccess = tt.minSuccess
+			results, err := b.CreateServers(context.Background(), "web", 3)
+			if len(results) != 3 {
+				t.Fatalf("CreateServers() returned %d results, want 3", len(results))
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateServers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if live := len(fp.Servers()); live != tt.wantLive {
+				t.Fatalf("%d servers live, want %d", live, tt.wantLive)
+			}
+			if err == nil {
+				return
+			}
+
+			var batchErr *cpt.BatchError
+			if !errors.As(err, &batchErr) {
+				t.Fatalf("CreateServers() error = %T, want *cpt.BatchError", err)
+			}
+			if batchErr.Created != tt.wantCreated || batchErr.Failed != tt.wantFailed {
+				t.Fatalf("created %d and failed %d, want %d and %d", batchErr.Created, batchErr.Failed, tt.wantCreated, tt.wantFailed)
+			}
+			if batchErr.RolledBack != tt.wantRolledBack || len(batchErr.Rollbacks) != tt.wantRollbacks {
+				t.Fatalf("rolled back %v with %d failures, want %v with %d", batchErr.RolledBack, len(batchErr.Rollbacks), tt.wantRolledBack, tt.wantRollbacks)
+			}
+			// the error unwraps to the errors creating the servers
+			wrapped := errSetup
+			if len(tt.createFailures) > 0 {
+				wrapped = tt.createFailures[0]
+			}
+			if !errors.Is(err, wrapped) {
+				t.Fatalf("CreateServers() error = %v, want it to wrap %v", err, wrapped)
+			}
+
+			// every live server is reported and was not rolled back
+			var reported int
+			for _, r := range results {
+				if r.Server != nil && !r.RolledBack {
+					reported++
+				}
+				if r.RolledBack && !tt.wantRolledBack {
+					t.Fatalf("server %s rolled back, want kept", r.Name)
+				}
+			}
+			if reported != tt.wantLive {
+				t.Fatalf("%d live servers reported, want %d", reported, tt.wantLive)
+			}
+		})
+	}
+}
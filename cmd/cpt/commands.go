@@ -0,0 +1,1089 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/reaper"
+	"github.com/sas-fe/cloud-provider-tools/state"
+)
+
+// serverFlags registers the common.ServerOption flags shared by server and k8s creation
+type serverFlags struct {
+	region   *string
+	size     *string
+	image    *string
+	userData *string
+	tags     *string
+	ttl      *time.Duration
+	allow    *string
+	sources  *string
+	sshKeys  *string
+	sshGen   *bool
+	network  *string
+}
+
+func newServerFlags(fs *flag.FlagSet) *serverFlags {
+	return &serverFlags{
+		region:   fs.String("region", "", "region or zone"),
+		size:     fs.String("size", "", "server size or machine type"),
+		image:    fs.String("image", "", "image ID or URL"),
+		userData: fs.String("user-data", "", "file containing cloud-init user data"),
+		tags:     fs.String("tags", "", "comma separated tags"),
+		ttl:      fs.Duration("ttl", 0, "label the resource to expire after ttl, for cpt reap"),
+		allow:    fs.String("allow", "", "create a firewall allowing comma separated protocol[:ports] rules, such as tcp:443,udp:1935,icmp"),
+		sources:  fs.String("source", "", "comma separated source CIDRs of the -allow rules; empty allows any IPv4 source"),
+		sshKeys:  fs.String("ssh-keys", "", "comma separated IDs or references of imported SSH keys"),
+		sshGen:   fs.Bool("ephemeral-ssh-key", false, "generate an SSH key pair and write its private key to <name>.key"),
+		network:  fs.String("network", "", "ID or reference of a private network; empty for the default network"),
+	}
+}
+
+func (f *serverFlags) options() ([]common.ServerOption, error) {
+	var opts []common.ServerOption
+
+	if len(*f.region) > 0 {
+		opts = append(opts, common.ServerRegion(*f.region))
+	}
+	if len(*f.size) > 0 {
+		opts = append(opts, common.ServerSize(*f.size))
+	}
+	if len(*f.image) > 0 {
+		opts = append(opts, common.ServerImage(*f.image))
+	}
+	if len(*f.userData) > 0 {
+		data, err := ioutil.ReadFile(*f.userData)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, common.ServerUserData(string(data)))
+	}
+	if len(*f.tags) > 0 {
+		opts = append(opts, common.ServerTags(strings.Split(*f.tags, ",")))
+	}
+	if *f.ttl > 0 {
+		opts = append(opts, common.ServerTTL(*f.ttl))
+	}
+	if len(*f.allow) > 0 {
+		opts = append(opts, common.ServerFirewall(parseRules(*f.allow, *f.sources)...))
+	}
+	if len(*f.sshKeys) > 0 {
+		var keys []common.Ref
+		for _, k := range strings.Split(*f.sshKeys, ",") {
+			ref, err := common.ParseRef(k)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, ref)
+		}
+		opts = append(opts, common.ServerSSHKeys(keys...))
+	}
+	if *f.sshGen {
+		opts = append(opts, common.ServerEphemeralSSHKey())
+	}
+	if len(*f.network) > 0 {
+		ref, err := common.ParseRef(*f.network)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, common.ServerNetwork(ref))
+	}
+
+	return opts, nil
+}
+
+// parseRules returns the firewall rules of comma separated protocol[:ports] entries,
+// merging the ports of each protocol, all allowed from the comma separated sources
+func parseRules(allow string, sources string) []common.FirewallRule {
+	var rules []common.FirewallRule
+	index := make(map[string]int)
+	for _, entry := range strings.Split(allow, ",") {
+		protocol, port := entry, ""
+		if i := strings.Index(entry, ":"); i >= 0 {
+			protocol, port = entry[:i], entry[i+1:]
+		}
+
+		i, ok := index[protocol]
+		if !ok {
+			i = len(rules)
+			index[protocol] = i
+			rules = append(rules, common.FirewallRule{Protocol: protocol})
+			if len(sources) > 0 {
+				rules[i].Sources = strings.Split(sources, ",")
+			}
+		}
+		if len(port) > 0 {
+			rules[i].Ports = append(rules[i].Ports, port)
+		}
+	}
+	return rules
+}
+
+// parseArgs parses the command flags and returns the single resource name argument
+func parseArgs(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", fmt.Errorf("%s: expected exactly one name, got %d", fs.Name(), fs.NArg())
+	}
+	return fs.Arg(0), nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cpt %s [flags] <name>\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func serverCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("server create")
+	sf := newServerFlags(fs)
+	count := fs.Int("count", 1, "number of servers to create, named <name>-1 to <name>-count")
+	workers := fs.Int("workers", cpt.DefaultBatchWorkers, "maximum number of concurrent creates with -count")
+	minSuccess := fs.Int("min-success", 0, "remove the created servers if fewer than this many are created with -count")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	opts, err := sf.options()
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := p.Capabilities().Check(common.OpCreateServer, opts...); err != nil {
+		return err
+	}
+
+	if *count > 1 {
+		b := cpt.NewBatch(p)
+		b.Workers = *workers
+		b.MinSuccess = *minSuccess
+		results, err := b.CreateServers(ctx, name, *count, opts...)
+		var servers []*common.CreateServerResponse
+		for _, r := range results {
+			if r.Server != nil && !r.RolledBack {
+				if keyErr := writePrivateKey(r.Server); keyErr != nil && err == nil {
+					err = keyErr
+				}
+				servers = append(servers, r.Server)
+			}
+		}
+		if plan == nil {
+			printList(servers)
+		}
+		return err
+	}
+
+	resp, err := p.CreateServer(ctx, name, opts...)
+	if resp != nil {
+		if keyErr := writePrivateKey(resp); keyErr != nil && err == nil {
+			err = keyErr
+		}
+		printResponse(resp)
+	}
+	return err
+}
+
+// writePrivateKey writes the private key generated for a server to <name>.key, so that
+// it is not printed with the response
+func writePrivateKey(server *common.CreateServerResponse) error {
+	if len(server.SSHPrivateKey) == 0 || plan != nil {
+		return nil
+	}
+
+	path := server.Name + ".key"
+	if err := ioutil.WriteFile(path, []byte(server.SSHPrivateKey), 0600); err != nil {
+		return err
+	}
+	server.SSHPrivateKey = ""
+	fmt.Fprintf(os.Stderr, "cpt: wrote the private key of %s to %s\n", server.Name, path)
+	return nil
+}
+
+// serverRefFlags registers the flags locating a server that is not recorded in the state file
+type serverRefFlags struct {
+	id     *string
+	region *string
+}
+
+func newServerRefFlags(fs *flag.FlagSet) *serverRefFlags {
+	return &serverRefFlags{
+		id:     fs.String("id", "", "server ID or reference, if not recorded in the state file"),
+		region: fs.String("region", "", "server region or zone, if not recorded in the state file"),
+	}
+}
+
+// server returns the named server recorded in the state file, or the server located by the flags
+func (sf *serverRefFlags) server(f *state.File, name string) (*common.CreateServerResponse, error) {
+	ref, err := common.ParseRef(*sf.id)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &common.CreateServerResponse{
+		Name:         name,
+		ServerID:     ref,
+		ServerRegion: *sf.region,
+	}
+	if r := lookup(f, state.SERVER, name); r != nil && len(*sf.id) == 0 {
+		server = r.Server()
+	} else if len(*sf.id) == 0 {
+		server.ServerID = common.Ref{ID: name}
+	}
+
+	return server, nil
+}
+
+func serverRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("server rm")
+	sf := newServerRefFlags(fs)
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	server, err := sf.server(f, name)
+	if err != nil {
+		return err
+	}
+
+	return p.RemoveServer(ctx, server)
+}
+
+// serverPower runs a power operation on an existing server
+func serverPower(action string, op common.Operation) command {
+	return func(ctx context.Context, args []string) error {
+		fs := newFlagSet("server " + action)
+		sf := newServerRefFlags(fs)
+		var size *string
+		if op == common.OpResizeServer {
+			size = fs.String("size", "", "new server size or machine type")
+		}
+		name, err := parseArgs(fs, args)
+		if err != nil {
+			return err
+		}
+		if size != nil && len(*size) == 0 {
+			return fmt.Errorf("server resize: -size is required")
+		}
+
+		p, f, err := newProvider()
+		if err != nil {
+			return err
+		}
+
+		if err := p.Capabilities().Check(op); err != nil {
+			return err
+		}
+
+		server, err := sf.server(f, name)
+		if err != nil {
+			return err
+		}
+
+		var resp *common.CreateServerResponse
+		switch op {
+		case common.OpStopServer:
+			return p.StopServer(ctx, server)
+		case common.OpRebootServer:
+			return p.RebootServer(ctx, server)
+		case common.OpStartServer:
+			resp, err = p.StartServer(ctx, server)
+		case common.OpResizeServer:
+			resp, err = p.ResizeServer(ctx, server, *size)
+		}
+		if resp != nil {
+			printResponse(resp)
+		}
+		return err
+	}
+}
+
+func k8sCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("k8s create")
+	sf := newServerFlags(fs)
+	version := fs.String("k8s-version", "", "kubernetes version")
+	autoscale := fs.Bool("autoscale", false, "enable node autoscaling")
+	minNodes := fs.Int64("min-nodes", 3, "minimum (and initial) number of nodes when autoscaling")
+	maxNodes := fs.Int64("max-nodes", 10, "maximum number of nodes when autoscaling")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	opts, err := sf.options()
+	if err != nil {
+		return err
+	}
+	if len(*version) > 0 {
+		opts = append(opts, common.K8sVersion(*version))
+	}
+	if *autoscale {
+		opts = append(opts, common.AutoScale(&common.AutoScaleOpt{
+			Enabled:  true,
+			MinNodes: *minNodes,
+			MaxNodes: *maxNodes,
+		}))
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := p.Capabilities().Check(common.OpCreateK8s, opts...); err != nil {
+		return err
+	}
+
+	resp, err := p.CreateK8s(ctx, name, opts...)
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+func k8sRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("k8s rm")
+	region := fs.String("region", "", "cluster zone, if not recorded in the state file")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	k8s := &common.CreateK8sResponse{
+		Name:          name,
+		ClusterID:     common.Ref{ID: name},
+		ClusterRegion: *region,
+	}
+	if r := lookup(f, state.K8S, name); r != nil && len(*region) == 0 {
+		k8s = r.K8s()
+	}
+
+	return p.RemoveK8s(ctx, k8s)
+}
+
+func parseIPType(t string) (common.StaticIPType, error) {
+	switch t {
+	case "global":
+		return common.GLOBAL, nil
+	case "regional":
+		return common.REGIONAL, nil
+	default:
+		return 0, fmt.Errorf("unknown static IP type %q (global or regional)", t)
+	}
+}
+
+func ipCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("ip create")
+	ipType := fs.String("type", "global", "static IP type: global or regional")
+	region := fs.String("region", "", "region of a regional static IP")
+	ttl := fs.Duration("ttl", 0, "label the static IP to expire after ttl, for cpt reap")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	t, err := parseIPType(*ipType)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if !p.Capabilities().SupportsStaticIPType(t) {
+		return common.NotImplemented(p.Capabilities().Provider, *ipType+" "+string(common.OpCreateStaticIP))
+	}
+
+	req := &common.StaticIPRequest{IPType: t, Region: *region}
+	if *ttl > 0 {
+		req.Expires = time.Now().Add(*ttl)
+	}
+
+	resp, err := p.CreateStaticIP(ctx, name, req)
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+func ipRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("ip rm")
+	ipType := fs.String("type", "", "static IP type, if not recorded in the state file: global or regional")
+	region := fs.String("region", "", "region of a regional static IP, if not recorded in the state file")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	staticIP := &common.CreateStaticIPResponse{
+		Name:   name,
+		Region: *region,
+	}
+	if r := lookup(f, state.STATICIP, name); r != nil && len(*ipType) == 0 {
+		staticIP = r.StaticIP()
+	} else if len(*ipType) > 0 {
+		if staticIP.Type, err = parseIPType(*ipType); err != nil {
+			return err
+		}
+	}
+
+	return p.RemoveStaticIP(ctx, staticIP)
+}
+
+func imageCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("image create")
+	serverName := fs.String("server", "", "name of the server to image")
+	sf := newServerRefFlags(fs)
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(*serverName) == 0 {
+		return fmt.Errorf("image create: -server is required")
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := p.Capabilities().Check(common.OpCreateImage); err != nil {
+		return err
+	}
+
+	server, err := sf.server(f, *serverName)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.CreateImageFromServer(ctx, server, name)
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+func imageRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("image rm")
+	id := fs.String("id", "", "image ID or reference, if not recorded in the state file")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	ref, err := common.ParseRef(*id)
+	if err != nil {
+		return err
+	}
+
+	image := &common.CreateImageResponse{
+		Name:    name,
+		ImageID: ref,
+	}
+	if r := lookup(f, state.IMAGE, name); r != nil && len(*id) == 0 {
+		image = r.ServerImage()
+	} else if len(*id) == 0 {
+		image.ImageID = common.Ref{ID: name}
+	}
+
+	return p.RemoveImage(ctx, image)
+}
+
+func volumeCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("volume create")
+	region := fs.String("region", "", "region or zone of the volume")
+	size := fs.Int64("size-gb", 10, "volume size in GiB")
+	volumeType := fs.String("type", "", "provider volume or disk type; empty for the default")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := p.Capabilities().Check(common.OpCreateVolume); err != nil {
+		return err
+	}
+
+	resp, err := p.CreateVolume(ctx, name, &common.VolumeRequest{
+		SizeGB: *size,
+		Region: *region,
+		Type:   *volumeType,
+	})
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+// volumeRefFlags registers the flags locating a volume that is not recorded in the state file
+type volumeRefFlags struct {
+	id     *string
+	region *string
+}
+
+func newVolumeRefFlags(fs *flag.FlagSet) *volumeRefFlags {
+	return &volumeRefFlags{
+		id:     fs.String("id", "", "volume ID or reference, if not recorded in the state file"),
+		region: fs.String("region", "", "volume region or zone, if not recorded in the state file"),
+	}
+}
+
+// volume returns the named volume recorded in the state file, or the volume located by the flags
+func (vf *volumeRefFlags) volume(f *state.File, name string) (*common.CreateVolumeResponse, error) {
+	ref, err := common.ParseRef(*vf.id)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := &common.CreateVolumeResponse{
+		Name:     name,
+		VolumeID: ref,
+		Region:   *vf.region,
+	}
+	if r := lookup(f, state.VOLUME, name); r != nil && len(*vf.id) == 0 {
+		volume = r.Volume()
+	} else if len(*vf.id) == 0 {
+		volume.VolumeID = common.Ref{ID: name, Region: *vf.region}
+	}
+
+	return volume, nil
+}
+
+func volumeRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("volume rm")
+	vf := newVolumeRefFlags(fs)
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	volume, err := vf.volume(f, name)
+	if err != nil {
+		return err
+	}
+
+	return p.RemoveVolume(ctx, volume)
+}
+
+// volumeAttach attaches or detaches a volume and an existing server
+func volumeAttach(action string, op common.Operation) command {
+	return func(ctx context.Context, args []string) error {
+		fs := newFlagSet("volume " + action)
+		vf := newVolumeRefFlags(fs)
+		serverName := fs.String("server", "", "name of the server")
+		serverID := fs.String("server-id", "", "server ID or reference, if not recorded in the state file")
+		name, err := parseArgs(fs, args)
+		if err != nil {
+			return err
+		}
+		if len(*serverName) == 0 {
+			return fmt.Errorf("volume %s: -server is required", action)
+		}
+
+		p, f, err := newProvider()
+		if err != nil {
+			return err
+		}
+
+		if err := p.Capabilities().Check(op); err != nil {
+			return err
+		}
+
+		volume, err := vf.volume(f, name)
+		if err != nil {
+			return err
+		}
+		sf := &serverRefFlags{id: serverID, region: vf.region}
+		server, err := sf.server(f, *serverName)
+		if err != nil {
+			return err
+		}
+
+		if op == common.OpDetachVolume {
+			return p.DetachVolume(ctx, volume, server)
+		}
+		return p.AttachVolume(ctx, volume, server)
+	}
+}
+
+func firewallCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("firewall create")
+	allow := fs.String("allow", "", "comma separated protocol[:ports] rules, such as tcp:443,udp:1935,icmp")
+	sources := fs.String("source", "", "comma separated source CIDRs; empty allows any IPv4 source")
+	targetTags := fs.String("target-tags", "", "comma separated tags of the servers the firewall applies to")
+	network := fs.String("network", "", "ID or reference of the private network of the firewall")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(*allow) == 0 {
+		return fmt.Errorf("firewall create: -allow is required")
+	}
+	networkRef, err := common.ParseRef(*network)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := p.Capabilities().Check(common.OpCreateFirewall); err != nil {
+		return err
+	}
+
+	req := &common.FirewallRequest{
+		Rules:   parseRules(*allow, *sources),
+		Network: networkRef,
+	}
+	if len(*targetTags) > 0 {
+		req.TargetTags = strings.Split(*targetTags, ",")
+	}
+
+	resp, err := p.CreateFirewall(ctx, name, req)
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+func firewallRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("firewall rm")
+	id := fs.String("id", "", "firewall ID or reference, if not recorded in the state file")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	ref, err := common.ParseRef(*id)
+	if err != nil {
+		return err
+	}
+
+	firewall := &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: ref,
+	}
+	if r := lookup(f, state.FIREWALL, name); r != nil && len(*id) == 0 {
+		firewall = r.Firewall()
+	} else if len(*id) == 0 {
+		firewall.FirewallID = common.Ref{ID: name}
+	}
+
+	return p.RemoveFirewall(ctx, firewall)
+}
+
+func sshKeyImport(ctx context.Context, args []string) error {
+	fs := newFlagSet("sshkey import")
+	publicKey := fs.String("public-key", "", "file containing the public key in authorized_keys format")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(*publicKey) == 0 {
+		return fmt.Errorf("sshkey import: -public-key is required")
+	}
+
+	data, err := ioutil.ReadFile(*publicKey)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := p.Capabilities().Check(common.OpImportSSHKey); err != nil {
+		return err
+	}
+
+	resp, err := p.ImportSSHKey(ctx, name, strings.TrimSpace(string(data)))
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+func sshKeyRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("sshkey rm")
+	id := fs.String("id", "", "SSH key ID or reference, if not recorded in the state file")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	ref, err := common.ParseRef(*id)
+	if err != nil {
+		return err
+	}
+
+	key := &common.ImportSSHKeyResponse{
+		Name:  name,
+		KeyID: ref,
+	}
+	if r := lookup(f, state.SSHKEY, name); r != nil && len(*id) == 0 {
+		key = r.SSHKey()
+	} else if len(*id) == 0 {
+		key.KeyID = common.Ref{ID: name}
+	}
+
+	return p.RemoveSSHKey(ctx, key)
+}
+
+func networkCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("network create")
+	cidr := fs.String("cidr", "", "private IPv4 address range of the network, such as 10.10.0.0/16")
+	region := fs.String("region", "", "region of the network, or availability zone of its subnet on AWS")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(*cidr) == 0 {
+		return fmt.Errorf("network create: -cidr is required")
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := p.Capabilities().Check(common.OpCreateNetwork); err != nil {
+		return err
+	}
+
+	resp, err := p.CreateNetwork(ctx, name, &common.NetworkRequest{
+		CIDR:   *cidr,
+		Region: *region,
+	})
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+func networkRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("network rm")
+	id := fs.String("id", "", "network ID or reference, if not recorded in the state file")
+	region := fs.String("region", "", "network region, if not recorded in the state file")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	ref, err := common.ParseRef(*id)
+	if err != nil {
+		return err
+	}
+
+	network := &common.CreateNetworkResponse{
+		Name:      name,
+		NetworkID: ref,
+		Region:    *region,
+	}
+	if r := lookup(f, state.NETWORK, name); r != nil && len(*id) == 0 {
+		network = r.Network()
+	} else if len(*id) == 0 {
+		network.NetworkID = common.Ref{ID: name, Region: *region}
+	}
+
+	return p.RemoveNetwork(ctx, network)
+}
+
+func dnsCreate(ctx context.Context, args []string) error {
+	fs := newFlagSet("dns create")
+	ip := fs.String("ip", "", "IP address the record points to")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(*ip) == 0 {
+		return fmt.Errorf("dns create: -ip is required")
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.CreateDNSRecord(ctx, name, *ip)
+	if resp != nil {
+		printResponse(resp)
+	}
+	return err
+}
+
+func dnsRemove(ctx context.Context, args []string) error {
+	fs := newFlagSet("dns rm")
+	id := fs.String("id", "", "record ID or reference, if not recorded in the state file")
+	ip := fs.String("ip", "", "IP address the record points to, if not recorded in the state file")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, f, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	ref, err := common.ParseRef(*id)
+	if err != nil {
+		return err
+	}
+
+	record := &common.CreateDNSRecordResponse{
+		SubDomain:   name,
+		SubDomainID: ref,
+		SubDomainIP: *ip,
+	}
+	if r := lookup(f, state.DNSRECORD, name); r != nil && len(*id) == 0 && len(*ip) == 0 {
+		record = r.DNSRecord()
+	}
+
+	return p.RemoveDNSRecord(ctx, record)
+}
+
+// listFlags registers the common.ListOption flags shared by the ls commands
+type listFlags struct {
+	prefix *string
+	tags   *string
+	region *string
+}
+
+// newListFlagSet returns the flag set of an ls command, which takes no arguments
+func newListFlagSet(name string) (*flag.FlagSet, *listFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cpt %s [flags]\n", name)
+		fs.PrintDefaults()
+	}
+
+	return fs, &listFlags{
+		prefix: fs.String("prefix", "", "only list resources whose name starts with prefix"),
+		tags:   fs.String("tags", "", "comma separated tags or key=value labels every listed resource carries"),
+		region: fs.String("region", "", "only list resources in the region or zone"),
+	}
+}
+
+// parse parses the command flags and returns the selected options
+func (f *listFlags) parse(fs *flag.FlagSet, args []string) ([]common.ListOption, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return nil, fmt.Errorf("%s: unexpected arguments %v", fs.Name(), fs.Args())
+	}
+
+	var opts []common.ListOption
+	if len(*f.prefix) > 0 {
+		opts = append(opts, common.ListPrefix(*f.prefix))
+	}
+	if len(*f.tags) > 0 {
+		opts = append(opts, common.ListTags(strings.Split(*f.tags, ",")...))
+	}
+	if len(*f.region) > 0 {
+		opts = append(opts, common.ListRegion(*f.region))
+	}
+
+	return opts, nil
+}
+
+func serverList(ctx context.Context, args []string) error {
+	fs, lf := newListFlagSet("server ls")
+	opts, err := lf.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	servers, err := p.ListServers(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return printList(servers)
+}
+
+func k8sList(ctx context.Context, args []string) error {
+	fs, lf := newListFlagSet("k8s ls")
+	opts, err := lf.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	clusters, err := p.ListK8s(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return printList(clusters)
+}
+
+func ipList(ctx context.Context, args []string) error {
+	fs, lf := newListFlagSet("ip ls")
+	opts, err := lf.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	staticIPs, err := p.ListStaticIPs(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return printList(staticIPs)
+}
+
+func imageList(ctx context.Context, args []string) error {
+	fs, lf := newListFlagSet("image ls")
+	opts, err := lf.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	images, err := p.ListImages(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return printList(images)
+}
+
+func dnsList(ctx context.Context, args []string) error {
+	fs, lf := newListFlagSet("dns ls")
+	opts, err := lf.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	records, err := p.ListDNSRecords(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return printList(records)
+}
+
+func reap(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("reap", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cpt reap [flags]\n")
+		fs.PrintDefaults()
+	}
+	grace := fs.Duration("grace", 0, "only remove resources that expired at least this long ago")
+	reportOnly := fs.Bool("n", false, "only report the expired resources, without removing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, _, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	r := reaper.New(*grace)
+	r.Add(p.Capabilities().Provider, p)
+
+	var report *reaper.Report
+	if *reportOnly {
+		report, err = r.Find(ctx)
+	} else {
+		report, err = r.Reap(ctx)
+	}
+	// dry runs print the plan instead
+	if report == nil || plan != nil {
+		return err
+	}
+
+	if werr := report.Write(os.Stdout); err == nil {
+		err = werr
+	}
+	return err
+}
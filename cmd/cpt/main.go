@@ -0,0 +1,208 @@
+// Command cpt creates, lists and removes on-demand cloud resources
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/state"
+)
+
+var providerName = flag.String("provider", os.Getenv("CPT_PROVIDER"), "cloud provider to use (default $CPT_PROVIDER)")
+var configFile = flag.String("config", "", "YAML or JSON provider config file; the environment is used if empty")
+var stateFile = flag.String("state", "cpt-state.json", "state file recording created resources; empty disables it")
+var output = flag.String("o", "table", "output format: table or json")
+var verbose = flag.Bool("v", false, "log provider operations to stderr")
+var dryRun = flag.Bool("dry-run", false, "print the cloud API requests as JSON instead of sending them")
+var adopt = flag.String("adopt", "", "adopt existing resources with the same name and this owner tag instead of creating them")
+
+// plan collects the requests of a dry run
+var plan *common.Plan
+
+// resolved is the name of the provider created by newProvider, from -provider or the
+// -config file, which resources are recorded under
+var resolved string
+
+// command runs a subcommand with its remaining arguments
+type command func(ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"server create":   serverCreate,
+	"server rm":       serverRemove,
+	"server ls":       serverList,
+	"server stop":     serverPower("stop", common.OpStopServer),
+	"server start":    serverPower("start", common.OpStartServer),
+	"server reboot":   serverPower("reboot", common.OpRebootServer),
+	"server resize":   serverPower("resize", common.OpResizeServer),
+	"k8s create":      k8sCreate,
+	"k8s rm":          k8sRemove,
+	"k8s ls":          k8sList,
+	"ip create":       ipCreate,
+	"ip rm":           ipRemove,
+	"ip ls":           ipList,
+	"image create":    imageCreate,
+	"image rm":        imageRemove,
+	"image ls":        imageList,
+	"volume create":   volumeCreate,
+	"volume rm":       volumeRemove,
+	"volume attach":   volumeAttach("attach", common.OpAttachVolume),
+	"volume detach":   volumeAttach("detach", common.OpDetachVolume),
+	"firewall create": firewallCreate,
+	"firewall rm":     firewallRemove,
+	"sshkey import":   sshKeyImport,
+	"sshkey rm":       sshKeyRemove,
+	"network create":  networkCreate,
+	"network rm":      networkRemove,
+	"dns create":      dnsCreate,
+	"dns rm":          dnsRemove,
+	"dns ls":          dnsList,
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: cpt [flags] <command> [command flags] <name>
+
+Commands:
+  server create|rm|ls   on-demand servers
+  server stop|start|reboot|resize
+                        power and size of existing servers
+  k8s create|rm|ls      kubernetes clusters
+  ip create|rm|ls       static IPs
+  image create|rm|ls    server images
+  volume create|rm|attach|detach
+                        block volumes
+  firewall create|rm    firewalls
+  sshkey import|rm      SSH public keys
+  network create|rm     private networks
+  dns create|rm|ls      DNS A records
+  ls                    list resources recorded in the state file
+  reap                  remove resources created with -ttl once they expire
+  providers             list the available providers
+
+Run "cpt <resource> <action> -h" for the flags of a command.
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch {
+	case len(args) == 1 && args[0] == "providers":
+		fmt.Println(strings.Join(cpt.Providers(), "\n"))
+	case len(args) == 1 && args[0] == "ls":
+		err = list()
+	case len(args) >= 1 && args[0] == "reap":
+		err = reap(ctx, args[1:])
+	case len(args) >= 2 && commands[args[0]+" "+args[1]] != nil:
+		err = commands[args[0]+" "+args[1]](ctx, args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err == nil && plan != nil {
+		err = plan.WriteJSON(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cpt:", err)
+		os.Exit(1)
+	}
+}
+
+// newProvider creates the configured provider, tracking resources in the state file if one is set
+func newProvider() (cpt.CloudProvider, *state.File, error) {
+	var cfg *cpt.Config
+	if len(*configFile) > 0 {
+		var err error
+		cfg, err = cpt.LoadConfig(*configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(*providerName) > 0 {
+			cfg.Provider = *providerName
+		}
+	} else {
+		cfg = cpt.ConfigFromEnv(*providerName)
+	}
+
+	if *verbose {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		cfg.Options = append(cfg.Options, common.ProviderLogger(logger))
+	}
+	if *dryRun {
+		plan = &common.Plan{}
+		cfg.Options = append(cfg.Options, common.ProviderDryRun(plan))
+	}
+	if len(*adopt) > 0 {
+		cfg.Options = append(cfg.Options, common.ProviderAdopt(*adopt))
+	}
+
+	p, err := cpt.NewCloudProviderFromConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved = cfg.Provider
+
+	if len(*stateFile) == 0 {
+		return p, nil, nil
+	}
+
+	f, err := state.Open(*stateFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// dry runs look up recorded resources but must not record placeholders
+	if plan != nil {
+		return p, f, nil
+	}
+
+	return state.Track(p, cfg.Provider, f), f, nil
+}
+
+// lookup returns the resource of the given kind and name recorded in the state file under
+// the provider created by newProvider, if any
+func lookup(f *state.File, kind state.Kind, name string) *state.Resource {
+	if f == nil {
+		return nil
+	}
+
+	for _, r := range f.Resources() {
+		if r.Type == kind && r.Name == name && r.Provider == resolved {
+			return r
+		}
+	}
+
+	return nil
+}
+
+func list() error {
+	if len(*stateFile) == 0 {
+		return fmt.Errorf("-state is required to list resources")
+	}
+
+	f, err := state.Open(*stateFile)
+	if err != nil {
+		return err
+	}
+
+	return printResources(f.Resources())
+}
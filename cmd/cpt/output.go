@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/state"
+)
+
+// printResponse prints a Create*Response in the selected output format
+func printResponse(resp interface{}) {
+	// dry runs print the plan instead of placeholder responses
+	if plan != nil {
+		return
+	}
+
+	if *output == "json" {
+		printJSON(resp)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	printFields(w, "", reflect.ValueOf(resp))
+	w.Flush()
+}
+
+// printFields writes one row per struct field, flattening nested structs
+func printFields(w *tabwriter.Writer, prefix string, v reflect.Value) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() == reflect.Ptr && !f.IsNil() && f.Elem().Kind() == reflect.Struct {
+			printFields(w, prefix+t.Field(i).Name+".", f)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s\t%v\n", prefix, t.Field(i).Name, f.Interface())
+	}
+}
+
+// printList prints a slice of Create*Response pointers in the selected output format,
+// one row per response. Nested structs such as credentials are only printed as JSON.
+func printList(list interface{}) error {
+	if *output == "json" {
+		printJSON(list)
+		return nil
+	}
+
+	v := reflect.ValueOf(list)
+	t := v.Type().Elem().Elem()
+
+	var columns []int
+	var header []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.Ptr {
+			columns = append(columns, i)
+			header = append(header, strings.ToUpper(t.Field(i).Name))
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for i := 0; i < v.Len(); i++ {
+		row := make([]string, len(columns))
+		for j, c := range columns {
+			row[j] = fmt.Sprint(v.Index(i).Elem().Field(c).Interface())
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// printResources lists recorded resources in the selected output format
+func printResources(resources []*state.Resource) error {
+	if *output == "json" {
+		printJSON(resources)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tTYPE\tNAME\tID\tREGION\tIP\tCREATED\tTAGS")
+	for _, r := range resources {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Provider, r.Type, r.Name, r.ID.ID, r.Region, r.IP,
+			r.Created.Local().Format(time.RFC3339), strings.Join(r.Tags, ","))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"fmt"
+)
+
+// OwnerLabel is the label key, or tag prefix on providers with plain tags, marking
+// the resources created with an owner tag
+const OwnerLabel = "cpt-owner"
+
+// AdoptProviderOption makes Create* operations idempotent by adopting existing resources
+type AdoptProviderOption struct {
+	// OwnerTag, if set, is recorded on created resources and required on adopted ones
+	OwnerTag string
+}
+
+// Set enables adoption
+func (o AdoptProviderOption) Set(p *ProviderInfo) error {
+	p.Adopt = true
+	p.OwnerTag = o.OwnerTag
+	return nil
+}
+
+// ProviderAdopt returns a ProviderOption that makes Create* operations idempotent. Each
+// Create* first looks up a resource with the same name and, if there is one, returns it
+// when it matches the requested spec, waiting for it if it is still provisioning. A resource
+// that conflicts with the spec, or lacks ownerTag when it is set, fails with ErrAlreadyExists.
+func ProviderAdopt(ownerTag string) ProviderOption {
+	return AdoptProviderOption{ownerTag}
+}
+
+// SpecConflict returns the error for an existing resource that cannot be adopted
+func SpecConflict(provider string, op Operation, name string, format string, args ...interface{}) error {
+	return NewError(provider, string(op), ErrAlreadyExists, fmt.Errorf("%s already exists but %s", name, fmt.Sprintf(format, args...)))
+}
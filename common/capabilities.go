@@ -0,0 +1,206 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// Operation names a CloudProvider operation
+type Operation string
+
+const (
+	// OpCreateServer creates a server
+	OpCreateServer Operation = "CreateServer"
+	// OpRemoveServer removes a server
+	OpRemoveServer Operation = "RemoveServer"
+	// OpCreateServerGroup creates a server group
+	OpCreateServerGroup Operation = "CreateServerGroup"
+	// OpRemoveServerGroup removes a server group
+	OpRemoveServerGroup Operation = "RemoveServerGroup"
+	// OpCreateK8s creates a k8s cluster
+	OpCreateK8s Operation = "CreateK8s"
+	// OpRemoveK8s removes a k8s cluster
+	OpRemoveK8s Operation = "RemoveK8s"
+	// OpCreateDNSRecord creates a DNS A record
+	OpCreateDNSRecord Operation = "CreateDNSRecord"
+	// OpRemoveDNSRecord removes a DNS A record
+	OpRemoveDNSRecord Operation = "RemoveDNSRecord"
+	// OpCreateStaticIP creates a static IP
+	OpCreateStaticIP Operation = "CreateStaticIP"
+	// OpRemoveStaticIP removes a static IP
+	OpRemoveStaticIP Operation = "RemoveStaticIP"
+	// OpListServers lists servers
+	OpListServers Operation = "ListServers"
+	// OpGetServer looks up a server by name
+	OpGetServer Operation = "GetServer"
+	// OpListServerGroups lists server groups
+	OpListServerGroups Operation = "ListServerGroups"
+	// OpListK8s lists k8s clusters
+	OpListK8s Operation = "ListK8s"
+	// OpGetK8s looks up a k8s cluster by name
+	OpGetK8s Operation = "GetK8s"
+	// OpListDNSRecords lists DNS A records
+	OpListDNSRecords Operation = "ListDNSRecords"
+	// OpListStaticIPs lists static IPs
+	OpListStaticIPs Operation = "ListStaticIPs"
+	// OpStopServer stops a server
+	OpStopServer Operation = "StopServer"
+	// OpStartServer starts a stopped server
+	OpStartServer Operation = "StartServer"
+	// OpRebootServer reboots a server
+	OpRebootServer Operation = "RebootServer"
+	// OpResizeServer changes the size of a server
+	OpResizeServer Operation = "ResizeServer"
+	// OpCreateImage creates an image of a server
+	OpCreateImage Operation = "CreateImageFromServer"
+	// OpListImages lists the images created in the account or project
+	OpListImages Operation = "ListImages"
+	// OpRemoveImage removes an image
+	OpRemoveImage Operation = "RemoveImage"
+	// OpCreateVolume creates a block volume
+	OpCreateVolume Operation = "CreateVolume"
+	// OpAttachVolume attaches a block volume to a server
+	OpAttachVolume Operation = "AttachVolume"
+	// OpDetachVolume detaches a block volume from a server
+	OpDetachVolume Operation = "DetachVolume"
+	// OpRemoveVolume removes a block volume
+	OpRemoveVolume Operation = "RemoveVolume"
+	// OpCreateFirewall creates a firewall
+	OpCreateFirewall Operation = "CreateFirewall"
+	// OpRemoveFirewall removes a firewall
+	OpRemoveFirewall Operation = "RemoveFirewall"
+	// OpImportSSHKey registers an SSH public key
+	OpImportSSHKey Operation = "ImportSSHKey"
+	// OpRemoveSSHKey removes an SSH public key
+	OpRemoveSSHKey Operation = "RemoveSSHKey"
+	// OpCreateNetwork creates a private network
+	OpCreateNetwork Operation = "CreateNetwork"
+	// OpRemoveNetwork removes a private network
+	OpRemoveNetwork Operation = "RemoveNetwork"
+)
+
+// OptionName names a kind of ServerOption
+type OptionName string
+
+const (
+	// OptSize is set by ServerSize
+	OptSize OptionName = "Size"
+	// OptRegion is set by ServerRegion
+	OptRegion OptionName = "Region"
+	// OptImage is set by ServerImage
+	OptImage OptionName = "Image"
+	// OptUserData is set by ServerUserData
+	OptUserData OptionName = "UserData"
+	// OptTags is set by ServerTags
+	OptTags OptionName = "Tags"
+	// OptAutoScale is set by AutoScale
+	OptAutoScale OptionName = "AutoScale"
+	// OptK8sVersion is set by K8sVersion
+	OptK8sVersion OptionName = "K8sVersion"
+	// OptExpires is set by ServerExpires and ServerTTL
+	OptExpires OptionName = "Expires"
+	// OptVolumes is set by ServerVolumes
+	OptVolumes OptionName = "Volumes"
+	// OptFirewall is set by ServerFirewall
+	OptFirewall OptionName = "Firewall"
+	// OptSSHKeys is set by ServerSSHKeys and ServerEphemeralSSHKey
+	OptSSHKeys OptionName = "SSHKeys"
+	// OptNetwork is set by ServerNetwork
+	OptNetwork OptionName = "Network"
+)
+
+// NameOf returns the name of a ServerOption, or "" for options defined outside this package
+func NameOf(opt ServerOption) OptionName {
+	switch opt.(type) {
+	case SizeServerOption, *SizeServerOption:
+		return OptSize
+	case RegionServerOption, *RegionServerOption:
+		return OptRegion
+	case ImageServerOption, *ImageServerOption:
+		return OptImage
+	case UserDataServerOption, *UserDataServerOption:
+		return OptUserData
+	case TagsServerOption, *TagsServerOption:
+		return OptTags
+	case AutoScaleServerOption, *AutoScaleServerOption:
+		return OptAutoScale
+	case K8sVersionServerOption, *K8sVersionServerOption:
+		return OptK8sVersion
+	case ExpiresServerOption, *ExpiresServerOption:
+		return OptExpires
+	case VolumesServerOption, *VolumesServerOption:
+		return OptVolumes
+	case FirewallServerOption, *FirewallServerOption:
+		return OptFirewall
+	case SSHKeysServerOption, *SSHKeysServerOption:
+		return OptSSHKeys
+	case NetworkServerOption, *NetworkServerOption:
+		return OptNetwork
+	default:
+		return ""
+	}
+}
+
+// Capabilities describes the operations a provider supports
+type Capabilities struct {
+	// Provider is the name of the provider
+	Provider string
+	// Operations maps each supported operation to the ServerOptions it honors
+	Operations map[Operation][]OptionName
+	// StaticIPTypes lists the supported static IP types
+	StaticIPTypes []StaticIPType
+	// CreateInterval is the minimum delay between starting concurrent creates that keeps
+	// within the API rate limits of the provider; zero means no limit
+	CreateInterval time.Duration
+}
+
+// Supports reports whether the operation is supported
+func (c *Capabilities) Supports(op Operation) bool {
+	_, ok := c.Operations[op]
+	return ok
+}
+
+// SupportsOption reports whether the operation is supported and honors the option
+func (c *Capabilities) SupportsOption(op Operation, name OptionName) bool {
+	for _, n := range c.Operations[op] {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsStaticIPType reports whether static IPs of the given type can be created
+func (c *Capabilities) SupportsStaticIPType(t StaticIPType) bool {
+	if !c.Supports(OpCreateStaticIP) {
+		return false
+	}
+	for _, s := range c.StaticIPTypes {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an ErrNotImplemented error if the operation is not supported or would
+// ignore one of the options. Options defined outside this package are not checked.
+func (c *Capabilities) Check(op Operation, opts ...ServerOption) error {
+	if !c.Supports(op) {
+		return NotImplemented(c.Provider, string(op))
+	}
+
+	for _, opt := range opts {
+		name := NameOf(opt)
+		if len(name) > 0 && !c.SupportsOption(op, name) {
+			return &Error{
+				Provider: c.Provider,
+				Op:       string(op),
+				Kind:     ErrNotImplemented,
+				Err:      fmt.Errorf("option %s is not supported", name),
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,24 +1,37 @@
 package common
 
+import (
+	"time"
+)
+
 // CreateServerResponse contains the response from server creation
 type CreateServerResponse struct {
 	Name         string
-	ServerID     interface{}
+	ServerID     Ref
 	ServerRegion string
 	ServerIP     string
+	// Expires is when the server expires, or the zero time if it does not
+	Expires time.Time
+	// FirewallID is the firewall created with ServerFirewall, if any
+	FirewallID Ref
+	// SSHKeyID is the key generated with ServerEphemeralSSHKey, if the provider registers it
+	SSHKeyID Ref
+	// SSHPrivateKey is the OpenSSH private key generated with ServerEphemeralSSHKey. It is
+	// only returned by CreateServer.
+	SSHPrivateKey string `json:",omitempty"`
 }
 
 // CreateDNSRecordResponse contains the response from DNS record creation
 type CreateDNSRecordResponse struct {
 	SubDomain   string
-	SubDomainID interface{}
+	SubDomainID Ref
 	SubDomainIP string
 }
 
 // CreateServerGroupResponse contains the reponse from creating a server group
 type CreateServerGroupResponse struct {
 	Name              string
-	ServerGroupID     interface{}
+	ServerGroupID     Ref
 	ServerGroupRegion string
 	LoadBalancerID    string
 	LoadBalancerIP    string
@@ -34,11 +47,13 @@ type ClusterCredentials struct {
 // CreateK8sResponse contains the response from K8s deployment
 type CreateK8sResponse struct {
 	Name          string
-	ClusterID     interface{}
+	ClusterID     Ref
 	ClusterRegion string
 	EndpointIP    string
 	EndpointPort  string
 	Credentials   *ClusterCredentials
+	// Expires is when the cluster expires, or the zero time if it does not
+	Expires time.Time
 }
 
 // StaticIPType enums the type of static IP
@@ -51,10 +66,23 @@ const (
 	REGIONAL StaticIPType = 1
 )
 
+func (t StaticIPType) String() string {
+	switch t {
+	case GLOBAL:
+		return "global"
+	case REGIONAL:
+		return "regional"
+	default:
+		return "unknown"
+	}
+}
+
 // StaticIPRequest contains the requested static IP type and region (for regional IPs)
 type StaticIPRequest struct {
 	IPType StaticIPType
 	Region string
+	// Expires is when the static IP expires, or the zero time if it does not
+	Expires time.Time
 }
 
 // CreateStaticIPResponse contains the response from creating a static IP
@@ -63,6 +91,37 @@ type CreateStaticIPResponse struct {
 	StaticIP string
 	Type     StaticIPType
 	Region   string
+	// Expires is when the static IP expires, or the zero time if it does not
+	Expires time.Time
+}
+
+// CreateImageResponse contains the response from creating an image of a server
+type CreateImageResponse struct {
+	Name    string
+	ImageID Ref
+	// Image identifies the image to ServerImage
+	Image string
+	// Source is the name of the server the image was created from, if known
+	Source string
+}
+
+// VolumeRequest contains the requested size, zone and type of a block volume
+type VolumeRequest struct {
+	// SizeGB is the size of the volume in GiB
+	SizeGB int64
+	// Region is the region or zone of the volume, which must match the servers it is
+	// attached to
+	Region string
+	// Type is the volume or disk type of the provider, or "" for its default
+	Type string
+}
+
+// CreateVolumeResponse contains the response from creating a block volume
+type CreateVolumeResponse struct {
+	Name     string
+	VolumeID Ref
+	Region   string
+	SizeGB   int64
 }
 
 // AutoScaleOpt contains fields for k8s autoscaling
@@ -82,6 +141,14 @@ type ServerInfo struct {
 	K8sVersion string
 	UserData   string
 	Tags       []string
+	Expires    time.Time
+	Volumes    []Ref
+	Firewall   []FirewallRule
+	SSHKeys    []Ref
+	// GenerateSSHKey is set by ServerEphemeralSSHKey
+	GenerateSSHKey bool
+	// Network is the private network of the server, or the default network if zero
+	Network Ref
 }
 
 // ServerOption configures a server for creation
@@ -200,3 +267,19 @@ func (o K8sVersionServerOption) Set(s *ServerInfo) error {
 func K8sVersion(version string) ServerOption {
 	return K8sVersionServerOption{version}
 }
+
+// VolumesServerOption configures the volumes attached to the server
+type VolumesServerOption struct {
+	Volumes []Ref
+}
+
+// Set sets the server volumes
+func (o VolumesServerOption) Set(s *ServerInfo) error {
+	s.Volumes = o.Volumes
+	return nil
+}
+
+// ServerVolumes returns a ServerOption that attaches existing volumes to the server
+func ServerVolumes(volumes ...Ref) ServerOption {
+	return VolumesServerOption{volumes}
+}
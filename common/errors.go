@@ -0,0 +1,78 @@
+package common
+
+import (
+	"errors"
+)
+
+// Error classes returned by providers. Use errors.Is to test for them:
+//
+//	if errors.Is(err, common.ErrTransient) {
+//		// retry
+//	}
+var (
+	// ErrNotImplemented is returned for operations a provider does not support
+	ErrNotImplemented = errors.New("not implemented")
+	// ErrNotFound is returned when the resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists is returned when a resource with the same name or ID already exists
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrQuotaExceeded is returned when a project or account quota or limit is reached
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrPermissionDenied is returned when the credentials are invalid or lack permission
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrTransient is returned for rate limiting and temporary server side failures
+	// that may succeed when retried
+	ErrTransient = errors.New("transient error")
+)
+
+// Error is a classified provider error. It matches its Kind with errors.Is
+// and unwraps to the underlying SDK error.
+type Error struct {
+	Provider string
+	Op       string
+	// Kind is one of the Err* classes, or nil if the error could not be classified
+	Kind error
+	// Err is the underlying error returned by the provider SDK, if any
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Provider + " " + e.Op
+	if e.Kind != nil {
+		msg += ": " + e.Kind.Error()
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is reports whether target is the class of the error
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError returns err classified as kind for the given provider operation.
+// It returns nil if err is nil, and err unchanged if it is already an *Error.
+func NewError(provider string, op string, kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return &Error{provider, op, kind, err}
+}
+
+// NotImplemented returns an ErrNotImplemented error for the given provider operation
+func NotImplemented(provider string, op string) error {
+	return &Error{Provider: provider, Op: op, Kind: ErrNotImplemented}
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+// EventType classifies the progress of a provider operation
+type EventType string
+
+// Progress events, in the order they are reported. StatusChanged and IPAssigned
+// are only reported by operations that wait for a resource.
+const (
+	// EventRequested is reported when the operation is called
+	EventRequested EventType = "requested"
+	// EventStarted is reported when the cloud API has accepted the request
+	EventStarted EventType = "started"
+	// EventStatusChanged is reported when the resource status reported by the cloud changes
+	EventStatusChanged EventType = "status-changed"
+	// EventIPAssigned is reported when the resource gets its IP address
+	EventIPAssigned EventType = "ip-assigned"
+	// EventReady is reported when the operation has completed successfully
+	EventReady EventType = "ready"
+	// EventFailed is reported when the operation has failed
+	EventFailed EventType = "failed"
+)
+
+// Event reports the progress of a Create* or Remove* call
+type Event struct {
+	Type     EventType
+	Time     time.Time
+	Provider string
+	Op       Operation
+	// Name is the resource name
+	Name string
+	// ID is the resource ID, once known
+	ID interface{}
+	// Status is the new status for EventStatusChanged, e.g. "PROVISIONING" or "RUNNING"
+	Status string
+	// IP is the assigned address for EventIPAssigned
+	IP string
+	// Err is the cause of EventFailed
+	Err error
+}
+
+// EventSink receives progress events. It is called synchronously from the
+// operation and must not block for long.
+type EventSink func(Event)
+
+// EventChannel returns an EventSink sending events to ch. Provisioning blocks
+// while ch is full, so it must be drained until the operation returns.
+func EventChannel(ch chan<- Event) EventSink {
+	return func(e Event) {
+		ch <- e
+	}
+}
+
+type eventSinkKey struct{}
+
+// WithEventSink returns a context reporting the progress of the provider
+// operations called with it to sink, in addition to any provider sink
+func WithEventSink(ctx context.Context, sink EventSink) context.Context {
+	return context.WithValue(ctx, eventSinkKey{}, sink)
+}
+
+// EventSinkFrom returns the sink attached to ctx with WithEventSink, or nil
+func EventSinkFrom(ctx context.Context) EventSink {
+	sink, _ := ctx.Value(eventSinkKey{}).(EventSink)
+	return sink
+}
+
+// EventsProviderOption configures a sink receiving the progress of every operation of a provider
+type EventsProviderOption struct {
+	Sink EventSink
+}
+
+// Set sets the event sink
+func (o EventsProviderOption) Set(p *ProviderInfo) error {
+	p.Events = o.Sink
+	return nil
+}
+
+// ProviderEvents returns a ProviderOption that sets the event sink
+func ProviderEvents(sink EventSink) ProviderOption {
+	return EventsProviderOption{sink}
+}
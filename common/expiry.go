@@ -0,0 +1,60 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpiresLabel is the label or tag recording when a resource expires, in Unix seconds
+const ExpiresLabel = "cpt-expires"
+
+// ExpiresValue returns the value of the ExpiresLabel label for t
+func ExpiresValue(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// ExpiresTag returns the "cpt-expires=<unix seconds>" tag for t
+func ExpiresTag(t time.Time) string {
+	return ExpiresLabel + "=" + ExpiresValue(t)
+}
+
+// Expires returns the expiry recorded in a "cpt-expires=<unix seconds>" or
+// "cpt-expires:<unix seconds>" tag, or the zero time if there is none
+func Expires(tags []string) time.Time {
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, ExpiresLabel) || len(tag) == len(ExpiresLabel) {
+			continue
+		}
+		if sep := tag[len(ExpiresLabel)]; sep != '=' && sep != ':' {
+			continue
+		}
+
+		sec, err := strconv.ParseInt(tag[len(ExpiresLabel)+1:], 10, 64)
+		if err == nil {
+			return time.Unix(sec, 0)
+		}
+	}
+	return time.Time{}
+}
+
+// ExpiresServerOption configures when the server or cluster expires
+type ExpiresServerOption struct {
+	Expires time.Time
+}
+
+// Set sets the expiry
+func (o ExpiresServerOption) Set(s *ServerInfo) error {
+	s.Expires = o.Expires
+	return nil
+}
+
+// ServerExpires returns a ServerOption that labels the server or cluster to expire at t
+func ServerExpires(t time.Time) ServerOption {
+	return ExpiresServerOption{t}
+}
+
+// ServerTTL returns a ServerOption that labels the server or cluster to expire ttl from now
+func ServerTTL(ttl time.Duration) ServerOption {
+	return ExpiresServerOption{time.Now().Add(ttl)}
+}
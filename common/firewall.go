@@ -0,0 +1,119 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AnySource is the source CIDR of rules that do not set any
+const AnySource = "0.0.0.0/0"
+
+// FirewallRule is an ingress rule allowing traffic to the servers of a firewall
+type FirewallRule struct {
+	// Protocol is tcp, udp or icmp
+	Protocol string
+	// Ports lists the allowed ports or port ranges, such as "443" or "8000-8080"; empty
+	// allows every port. ICMP rules have no ports.
+	Ports []string
+	// Sources lists the allowed source CIDRs; empty allows any IPv4 source
+	Sources []string
+}
+
+// FirewallRequest contains the ingress rules of a firewall and the servers it applies to
+type FirewallRequest struct {
+	Rules []FirewallRule
+	// TargetTags applies the firewall to servers with any of the tags
+	TargetTags []string
+	// Network is the network of the firewall, or the default network if zero. It is
+	// ignored where firewalls do not belong to a network.
+	Network Ref
+}
+
+// CreateFirewallResponse contains the response from creating a firewall
+type CreateFirewallResponse struct {
+	Name       string
+	FirewallID Ref
+	TargetTags []string
+}
+
+// Validate checks the protocols and ports of the rules
+func (req *FirewallRequest) Validate() error {
+	if len(req.Rules) == 0 {
+		return fmt.Errorf("firewall has no rules")
+	}
+
+	for _, r := range req.Rules {
+		switch r.Protocol {
+		case "tcp", "udp":
+		case "icmp":
+			if len(r.Ports) > 0 {
+				return fmt.Errorf("icmp firewall rules have no ports")
+			}
+		default:
+			return fmt.Errorf("unknown firewall protocol %q (tcp, udp or icmp)", r.Protocol)
+		}
+
+		for _, port := range r.Ports {
+			if _, _, err := PortRange(port); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SourcesOf returns the source CIDRs of the rule, or AnySource if it sets none
+func SourcesOf(r FirewallRule) []string {
+	if len(r.Sources) == 0 {
+		return []string{AnySource}
+	}
+	return r.Sources
+}
+
+// PortRange returns the first and last port of a port or port range such as "80" or
+// "8000-8080"
+func PortRange(port string) (int64, int64, error) {
+	from, to := port, port
+	if i := strings.Index(port, "-"); i >= 0 {
+		from, to = port[:i], port[i+1:]
+	}
+
+	first, err := strconv.ParseInt(from, 10, 64)
+	if err != nil || first < 1 || first > 65535 {
+		return 0, 0, fmt.Errorf("invalid port %q", port)
+	}
+	last, err := strconv.ParseInt(to, 10, 64)
+	if err != nil || last < first || last > 65535 {
+		return 0, 0, fmt.Errorf("invalid port range %q", port)
+	}
+
+	return first, last, nil
+}
+
+// FirewallName returns the name of the firewall ServerFirewall creates for a server
+func FirewallName(server string) string {
+	return server + "-fw"
+}
+
+// FirewallServerOption configures the rules of the firewall of the server
+type FirewallServerOption struct {
+	Rules []FirewallRule
+}
+
+// Set sets the server firewall rules
+func (o FirewallServerOption) Set(s *ServerInfo) error {
+	req := &FirewallRequest{Rules: o.Rules}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	s.Firewall = o.Rules
+	return nil
+}
+
+// ServerFirewall returns a ServerOption that creates a firewall named FirewallName(name)
+// with the ingress rules for the server. RemoveServer removes it with the server.
+func ServerFirewall(rules ...FirewallRule) ServerOption {
+	return FirewallServerOption{rules}
+}
@@ -0,0 +1,119 @@
+package common
+
+import (
+	"sort"
+	"strings"
+)
+
+// ListInfo contains the filters for listing resources
+type ListInfo struct {
+	Prefix string
+	Tags   []string
+	Region string
+}
+
+// ListOption filters listed resources
+type ListOption interface {
+	Set(*ListInfo) error
+}
+
+// NewListInfo returns the ListInfo configured by opts
+func NewListInfo(opts ...ListOption) (*ListInfo, error) {
+	l := &ListInfo{}
+
+	for _, opt := range opts {
+		if err := opt.Set(l); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// Match reports whether a resource with the name and tags passes the prefix and tag
+// filters. A tag filter "key" matches the tag "key" and any "key=value" tag, and
+// "key=value" only matches itself.
+func (l *ListInfo) Match(name string, tags []string) bool {
+	if !strings.HasPrefix(name, l.Prefix) {
+		return false
+	}
+
+	for _, want := range l.Tags {
+		found := false
+		for _, tag := range tags {
+			if tag == want || strings.HasPrefix(tag, want+"=") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LabelTags returns labels as sorted "key=value" tags, or "key" for empty values,
+// for matching with ListInfo.Match
+func LabelTags(labels map[string]string) []string {
+	tags := make([]string, 0, len(labels))
+	for k, v := range labels {
+		if len(v) == 0 {
+			tags = append(tags, k)
+		} else {
+			tags = append(tags, k+"="+v)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// PrefixListOption filters resources by name prefix
+type PrefixListOption struct {
+	Prefix string
+}
+
+// Set sets the name prefix
+func (o PrefixListOption) Set(l *ListInfo) error {
+	l.Prefix = o.Prefix
+	return nil
+}
+
+// ListPrefix returns a ListOption that keeps resources whose name starts with prefix
+func ListPrefix(prefix string) ListOption {
+	return PrefixListOption{prefix}
+}
+
+// TagsListOption filters resources by tag or label
+type TagsListOption struct {
+	Tags []string
+}
+
+// Set adds the tags
+func (o TagsListOption) Set(l *ListInfo) error {
+	l.Tags = append(l.Tags, o.Tags...)
+	return nil
+}
+
+// ListTags returns a ListOption that keeps resources carrying every tag. Labels and
+// key/value tags are matched as "key" or "key=value".
+func ListTags(tags ...string) ListOption {
+	return TagsListOption{tags}
+}
+
+// RegionListOption filters resources by region or zone
+type RegionListOption struct {
+	Region string
+}
+
+// Set sets the region
+func (o RegionListOption) Set(l *ListInfo) error {
+	l.Region = o.Region
+	return nil
+}
+
+// ListRegion returns a ListOption that only lists resources in the region or zone
+func ListRegion(region string) ListOption {
+	return RegionListOption{region}
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+// Logger is a leveled, structured logger. Messages are followed by alternating
+// keys and values. It is satisfied by *slog.Logger from the standard library.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// NopLogger discards all messages. It is the default logger of every provider.
+type NopLogger struct{}
+
+// Debug discards the message
+func (NopLogger) Debug(msg string, args ...interface{}) {}
+
+// Info discards the message
+func (NopLogger) Info(msg string, args ...interface{}) {}
+
+// Warn discards the message
+func (NopLogger) Warn(msg string, args ...interface{}) {}
+
+// Error discards the message
+func (NopLogger) Error(msg string, args ...interface{}) {}
+
+// LoggerProviderOption configures the logger of a provider
+type LoggerProviderOption struct {
+	Logger Logger
+}
+
+// Set sets the logger, falling back to NopLogger if it is nil
+func (o LoggerProviderOption) Set(p *ProviderInfo) error {
+	p.Log = o.Logger
+	if p.Log == nil {
+		p.Log = NopLogger{}
+	}
+	return nil
+}
+
+// ProviderLogger returns a ProviderOption that sets the logger
+func ProviderLogger(l Logger) ProviderOption {
+	return LoggerProviderOption{l}
+}
+
+// OpLog logs the messages and reports the progress events of a single provider
+// operation, adding the provider, operation and resource name to every message
+type OpLog struct {
+	log    Logger
+	sinks  []EventSink
+	event  Event
+	attrs  []interface{}
+	start  time.Time
+	status string
+	plan   *Plan
+}
+
+// StartOp returns an OpLog for an operation on the named resource, reporting
+// events to the sinks of the provider and ctx, and reports EventRequested
+func (i *ProviderInfo) StartOp(ctx context.Context, provider string, op Operation, name string) *OpLog {
+	o := &OpLog{
+		log:   i.Log,
+		event: Event{Provider: provider, Op: op, Name: name},
+		attrs: []interface{}{"provider", provider, "op", string(op), "name", name},
+		start: time.Now(),
+		plan:  i.DryRun,
+	}
+	for _, sink := range []EventSink{i.Events, EventSinkFrom(ctx)} {
+		if sink != nil {
+			o.sinks = append(o.sinks, sink)
+		}
+	}
+
+	o.emit(Event{Type: EventRequested})
+	return o
+}
+
+func (o *OpLog) emit(e Event) {
+	if len(o.sinks) == 0 {
+		return
+	}
+
+	e.Time = time.Now()
+	e.Provider = o.event.Provider
+	e.Op = o.event.Op
+	e.Name = o.event.Name
+	e.ID = o.event.ID
+	for _, sink := range o.sinks {
+		sink(e)
+	}
+}
+
+// With adds fields, such as the zone, to all later messages
+func (o *OpLog) With(args ...interface{}) *OpLog {
+	o.attrs = append(o.attrs, args...)
+	return o
+}
+
+func (o *OpLog) args(args []interface{}) []interface{} {
+	all := make([]interface{}, 0, len(o.attrs)+len(args))
+	all = append(all, o.attrs...)
+	return append(all, args...)
+}
+
+// Debug logs a debug message
+func (o *OpLog) Debug(msg string, args ...interface{}) {
+	o.log.Debug(msg, o.args(args)...)
+}
+
+// Info logs an informational message
+func (o *OpLog) Info(msg string, args ...interface{}) {
+	o.log.Info(msg, o.args(args)...)
+}
+
+// Warn logs a warning
+func (o *OpLog) Warn(msg string, args ...interface{}) {
+	o.log.Warn(msg, o.args(args)...)
+}
+
+// Error logs an error
+func (o *OpLog) Error(msg string, args ...interface{}) {
+	o.log.Error(msg, o.args(args)...)
+}
+
+// Started records the resource ID, which may be nil if it is not known yet,
+// and reports EventStarted once the cloud API has accepted the request
+func (o *OpLog) Started(id interface{}) *OpLog {
+	if id != nil {
+		o.event.ID = id
+		o.With("id", id)
+	}
+	o.Debug("operation started")
+	o.emit(Event{Type: EventStarted})
+	return o
+}
+
+// Status reports EventStatusChanged if status differs from the previous one
+func (o *OpLog) Status(status string) {
+	if status == o.status {
+		return
+	}
+	o.status = status
+	o.Debug("status changed", "status", status)
+	o.emit(Event{Type: EventStatusChanged, Status: status})
+}
+
+// IPAssigned records the IP address of the resource and reports EventIPAssigned
+func (o *OpLog) IPAssigned(ip string) {
+	o.With("ip", ip)
+	o.Debug("IP assigned")
+	o.emit(Event{Type: EventIPAssigned, IP: ip})
+}
+
+// Done logs the outcome of the operation with its duration, reports EventReady or
+// EventFailed, and returns err
+func (o *OpLog) Done(err error) error {
+	d := time.Since(o.start)
+	if err != nil {
+		o.Error("operation failed", "duration", d, "error", err)
+		o.emit(Event{Type: EventFailed, Err: err})
+	} else {
+		o.Info("operation finished", "duration", d)
+		o.emit(Event{Type: EventReady})
+	}
+	return err
+}
+
+// DryRun reports whether the provider is in dry run mode, in which case it adds the
+// request for the cloud API method to the plan instead of sending it
+func (o *OpLog) DryRun(api string, req interface{}) bool {
+	if o.plan == nil {
+		return false
+	}
+
+	o.plan.Add(PlannedRequest{o.event.Provider, o.event.Op, o.event.Name, api, req})
+	o.Info("dry run", "api", api)
+	return true
+}
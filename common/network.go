@@ -0,0 +1,51 @@
+package common
+
+import (
+	"fmt"
+	"net"
+)
+
+// NetworkRequest contains the address range and location of a private network
+type NetworkRequest struct {
+	// CIDR is the private IPv4 range of the network, such as "10.10.0.0/20"
+	CIDR string
+	// Region is the region of the network, or its availability zone on AWS
+	Region string
+}
+
+// CreateNetworkResponse contains the response from creating a private network
+type CreateNetworkResponse struct {
+	Name      string
+	NetworkID Ref
+	CIDR      string
+	Region    string
+}
+
+// Validate checks that the CIDR is a private IPv4 range
+func (req *NetworkRequest) Validate() error {
+	ip, _, err := net.ParseCIDR(req.CIDR)
+	if err != nil {
+		return fmt.Errorf("invalid network CIDR %q", req.CIDR)
+	}
+	if ip.To4() == nil || !ip.IsPrivate() {
+		return fmt.Errorf("network CIDR %s is not a private IPv4 range", req.CIDR)
+	}
+	return nil
+}
+
+// NetworkServerOption configures the private network of the server
+type NetworkServerOption struct {
+	Network Ref
+}
+
+// Set sets the server network
+func (o NetworkServerOption) Set(s *ServerInfo) error {
+	s.Network = o.Network
+	return nil
+}
+
+// ServerNetwork returns a ServerOption that places the server or cluster in a network
+// created with CreateNetwork instead of the default network
+func ServerNetwork(network Ref) ServerOption {
+	return NetworkServerOption{network}
+}
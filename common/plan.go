@@ -0,0 +1,98 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Placeholder values returned by providers in dry run mode
+const (
+	// PlaceholderIP is a documentation address (RFC 5737) returned instead of real IPs
+	PlaceholderIP = "192.0.2.1"
+	// PlaceholderEndpointPort is the endpoint port of placeholder clusters
+	PlaceholderEndpointPort = "443"
+)
+
+// PlaceholderID returns the ID returned for the named resource in dry run mode,
+// by providers whose IDs are strings
+func PlaceholderID(name string) string {
+	return "dry-run-" + name
+}
+
+// PlannedRequest is a cloud API request that was not sent because the provider is in dry run mode
+type PlannedRequest struct {
+	Provider string    `json:"provider"`
+	Op       Operation `json:"op"`
+	Name     string    `json:"name"`
+	// API names the cloud API method, e.g. "compute.instances.insert"
+	API string `json:"api"`
+	// Request is the request the provider would have sent
+	Request interface{} `json:"request,omitempty"`
+}
+
+// Plan collects the requests of providers in dry run mode. It is safe for concurrent use.
+type Plan struct {
+	mu       sync.Mutex
+	requests []PlannedRequest
+}
+
+// Add appends a request to the plan
+func (p *Plan) Add(r PlannedRequest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, r)
+}
+
+// Requests returns the planned requests in the order they were made
+func (p *Plan) Requests() []PlannedRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PlannedRequest(nil), p.requests...)
+}
+
+// WriteJSON writes the planned requests to w as an indented JSON array
+func (p *Plan) WriteJSON(w io.Writer) error {
+	requests := p.Requests()
+	if requests == nil {
+		requests = []PlannedRequest{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(requests)
+}
+
+// DryRunProviderOption puts a provider in dry run mode
+type DryRunProviderOption struct {
+	Plan *Plan
+}
+
+// Set sets the plan collecting the requests
+func (o DryRunProviderOption) Set(p *ProviderInfo) error {
+	p.DryRun = o.Plan
+	return nil
+}
+
+// ProviderDryRun returns a ProviderOption that puts the provider in dry run mode. Create*
+// and Remove* operations then validate their options and add the requests they would send
+// to plan, without calling the cloud, and return placeholder responses.
+func ProviderDryRun(plan *Plan) ProviderOption {
+	return DryRunProviderOption{plan}
+}
+
+// NewServerInfo returns the ServerInfo for the named server with opts applied,
+// or the first error returned by an option
+func NewServerInfo(name string, opts ...ServerOption) (*ServerInfo, error) {
+	s := &ServerInfo{
+		Name: name,
+	}
+
+	for _, opt := range opts {
+		if err := opt.Set(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"github.com/sas-fe/cloud-provider-tools/common/retry"
+)
+
+// ProviderInfo contains configuration shared by all providers
+type ProviderInfo struct {
+	Retry  retry.Policy
+	Log    Logger
+	Events EventSink
+	DryRun *Plan
+
+	// Adopt makes Create* operations idempotent; see ProviderAdopt
+	Adopt    bool
+	OwnerTag string
+}
+
+// NewProviderInfo returns the default provider configuration with opts applied.
+// If an option fails, the returned info has the remaining options applied and
+// the first error is returned with it.
+func NewProviderInfo(opts ...ProviderOption) (*ProviderInfo, error) {
+	info := &ProviderInfo{
+		Retry: retry.Default,
+		Log:   NopLogger{},
+	}
+
+	var firstErr error
+	for _, opt := range opts {
+		if err := opt.Set(info); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return info, firstErr
+}
+
+// ProviderOption configures a provider for creation
+type ProviderOption interface {
+	Set(*ProviderInfo) error
+}
+
+// RetryProviderOption configures the retry policy for cloud API calls
+type RetryProviderOption struct {
+	Policy retry.Policy
+}
+
+// Set sets the retry policy
+func (o RetryProviderOption) Set(p *ProviderInfo) error {
+	p.Retry = o.Policy
+	return nil
+}
+
+// ProviderRetry returns a ProviderOption that sets the retry policy.
+// Use retry.Never to disable retries.
+func ProviderRetry(policy retry.Policy) ProviderOption {
+	return RetryProviderOption{policy}
+}
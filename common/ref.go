@@ -0,0 +1,137 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Kind enums the type of a cloud resource
+type Kind string
+
+const (
+	// KindServer is a server
+	KindServer Kind = "server"
+	// KindServerGroup is a server group
+	KindServerGroup Kind = "servergroup"
+	// KindK8s is a k8s cluster
+	KindK8s Kind = "k8s"
+	// KindDNSRecord is a DNS A record
+	KindDNSRecord Kind = "dns"
+	// KindStaticIP is a static IP
+	KindStaticIP Kind = "staticip"
+	// KindImage is a server image
+	KindImage Kind = "image"
+	// KindVolume is a block volume
+	KindVolume Kind = "volume"
+	// KindFirewall is a firewall or security group
+	KindFirewall Kind = "firewall"
+	// KindSSHKey is an SSH public key
+	KindSSHKey Kind = "sshkey"
+	// KindNetwork is a private network
+	KindNetwork Kind = "network"
+)
+
+// Ref is a typed reference to a cloud resource. IDs are always strings, so a Ref survives
+// a round trip through JSON or YAML unchanged and can be handed back to Remove* on any
+// provider.
+type Ref struct {
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Kind     Kind   `json:"kind,omitempty" yaml:"kind,omitempty"`
+	ID       string `json:"id" yaml:"id"`
+	// Region is the region or zone of the resource, if any
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	// Project is the project of the resource, for providers that have them
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+}
+
+// IsZero reports whether the reference has no ID
+func (r Ref) IsZero() bool {
+	return len(r.ID) == 0
+}
+
+// String returns the reference as provider/kind/project/region/id with each part path
+// escaped, or just the ID if the reference has no other parts. ParseRef parses it back.
+func (r Ref) String() string {
+	if r == (Ref{ID: r.ID}) {
+		return r.ID
+	}
+
+	parts := []string{r.Provider, string(r.Kind), r.Project, r.Region, r.ID}
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// ParseRef parses a reference returned by Ref.String. A string without a slash is a bare ID.
+func ParseRef(s string) (Ref, error) {
+	if !strings.Contains(s, "/") {
+		return Ref{ID: s}, nil
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) != 5 {
+		return Ref{}, fmt.Errorf("invalid resource reference %q: want provider/kind/project/region/id", s)
+	}
+	for i, part := range parts {
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			return Ref{}, fmt.Errorf("invalid resource reference %q: %v", s, err)
+		}
+		parts[i] = unescaped
+	}
+
+	return Ref{
+		Provider: parts[0],
+		Kind:     Kind(parts[1]),
+		Project:  parts[2],
+		Region:   parts[3],
+		ID:       parts[4],
+	}, nil
+}
+
+// UnmarshalJSON decodes a reference object. For compatibility with responses and state
+// files written before IDs were typed, it also accepts a bare string or numeric ID.
+func (r *Ref) UnmarshalJSON(data []byte) error {
+	switch {
+	case len(data) > 0 && data[0] == '{':
+		type ref Ref
+		return json.Unmarshal(data, (*ref)(r))
+	case len(data) > 0 && data[0] == '"':
+		*r = Ref{}
+		return json.Unmarshal(data, &r.ID)
+	case string(data) == "null":
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid resource reference %s", data)
+	}
+	*r = Ref{ID: n.String()}
+	return nil
+}
+
+// Check returns an error if the reference is to a resource of another provider or kind.
+// References that leave the provider or kind unset, such as bare IDs, are accepted.
+func (r Ref) Check(provider string, kind Kind) error {
+	if len(r.Provider) > 0 && r.Provider != provider {
+		return fmt.Errorf("%v is a %s resource, not %s", r, r.Provider, provider)
+	}
+	if len(r.Kind) > 0 && r.Kind != kind {
+		return fmt.Errorf("%v is a %s, not a %s", r, r.Kind, kind)
+	}
+	return nil
+}
+
+// IntID returns the ID of a reference to a resource with an integer ID
+func (r Ref) IntID() (int, error) {
+	id, err := strconv.Atoi(r.ID)
+	if err != nil {
+		return 0, fmt.Errorf("%v does not have an integer ID", r)
+	}
+	return id, nil
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRefString(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  Ref
+		want string
+	}{
+		{"bare ID", Ref{ID: "i-123"}, "i-123"},
+		{"full", Ref{"aws", KindServer, "i-123", "us-east-1", ""}, "aws/server//us-east-1/i-123"},
+		{"project", Ref{"gce", KindK8s, "demo", "us-central1-a", "my-project"}, "gce/k8s/my-project/us-central1-a/demo"},
+		{"escaped", Ref{"gce", KindDNSRecord, "a/b", "", "p q"}, "gce/dns/p%20q//a%2Fb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ref.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+
+			parsed, err := ParseRef(tt.want)
+			if err != nil {
+				t.Fatalf("ParseRef(%q): %v", tt.want, err)
+			}
+			if parsed != tt.ref {
+				t.Fatalf("ParseRef(%q) = %+v, want %+v", tt.want, parsed, tt.ref)
+			}
+		})
+	}
+}
+
+func TestParseRefInvalid(t *testing.T) {
+	tests := []string{
+		"aws/server/i-123",
+		"aws/server//us-east-1/i-123/extra",
+		"aws/server//us-east-1/%zz",
+	}
+
+	for _, s := range tests {
+		if ref, err := ParseRef(s); err == nil {
+			t.Errorf("ParseRef(%q) = %+v, want error", s, ref)
+		}
+	}
+}
+
+func TestRefUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Ref
+		wantErr bool
+	}{
+		{"object", `{"provider":"digitalocean","kind":"server","id":"42"}`, Ref{Provider: "digitalocean", Kind: KindServer, ID: "42"}, false},
+		{"string", `"i-123"`, Ref{ID: "i-123"}, false},
+		{"number", `42`, Ref{ID: "42"}, false},
+		{"null", `null`, Ref{}, false},
+		{"bool", `true`, Ref{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Ref
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Unmarshal(%s) = %+v, want %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     Ref
+		wantErr bool
+	}{
+		{"match", Ref{Provider: "aws", Kind: KindServer, ID: "i-123"}, false},
+		{"bare ID", Ref{ID: "i-123"}, false},
+		{"other provider", Ref{Provider: "gce", Kind: KindServer, ID: "demo"}, true},
+		{"other kind", Ref{Provider: "aws", Kind: KindK8s, ID: "demo"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.ref.Check("aws", KindServer); (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package retry retries cloud API calls that fail with transient errors
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Policy configures how failed calls are retried
+type Policy struct {
+	// MaxAttempts is the maximum number of calls, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// Interval is the delay before the first retry
+	Interval time.Duration
+	// Factor multiplies the delay after every retry; values below 1 keep it constant
+	Factor float64
+	// MaxInterval caps the delay; zero means no cap
+	MaxInterval time.Duration
+	// Jitter randomizes every delay by up to +/- this fraction of it
+	Jitter float64
+}
+
+// Default is the policy used by providers unless configured otherwise
+var Default = Policy{
+	MaxAttempts: 5,
+	Interval:    time.Second,
+	Factor:      2,
+	MaxInterval: 30 * time.Second,
+	Jitter:      0.2,
+}
+
+// Never disables retries
+var Never = Policy{MaxAttempts: 1}
+
+// Classifier reports whether a failed call should be retried, and how long the
+// server asked to wait before retrying (zero if it did not say)
+type Classifier func(err error) (retry bool, after time.Duration)
+
+var (
+	rngMu sync.Mutex
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func (p Policy) delay(attempt int) time.Duration {
+	d := p.Interval
+	for i := 1; i < attempt; i++ {
+		if p.Factor > 1 {
+			d = time.Duration(float64(d) * p.Factor)
+		}
+		if p.MaxInterval > 0 && d > p.MaxInterval {
+			d = p.MaxInterval
+			break
+		}
+	}
+
+	if p.Jitter > 0 && d > 0 {
+		rngMu.Lock()
+		r := rng.Float64()
+		rngMu.Unlock()
+		d += time.Duration((2*r - 1) * p.Jitter * float64(d))
+	}
+
+	return d
+}
+
+// Do calls fn until it succeeds, fails with an error classify rejects, the attempts of
+// the policy are exhausted or ctx is done. The delay between attempts follows the policy,
+// but is never shorter than the delay requested by the server. It returns the last error
+// from fn, or ctx.Err() if ctx is done while waiting.
+func (p Policy) Do(ctx context.Context, classify Classifier, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		retry, after := classify(err)
+		if !retry {
+			return err
+		}
+
+		d := p.delay(attempt)
+		if after > d {
+			d = after
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// After returns the delay requested by the Retry-After header, given either in
+// seconds or as an HTTP date, or zero if there is none
+func After(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if len(v) == 0 {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
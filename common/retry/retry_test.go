@@ -0,0 +1,138 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common/retry"
+)
+
+var (
+	errTransient = errors.New("rate limited")
+	errNotFound  = errors.New("not found")
+)
+
+// classify retries errTransient, asking for a delay of after
+func classify(after time.Duration) retry.Classifier {
+	return func(err error) (bool, time.Duration) {
+		return err == errTransient, after
+	}
+}
+
+// scripted returns a call failing with errs in order, then succeeding. Every
+// call blocks for latency or until its context is done.
+func scripted(errs []error, latency time.Duration, calls *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*calls++
+		if latency > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(latency):
+			}
+		}
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func TestDo(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3, Interval: time.Millisecond, Factor: 2}
+
+	tests := []struct {
+		name      string
+		policy    retry.Policy
+		errs      []error
+		after     time.Duration
+		wantErr   error
+		wantCalls int
+	}{
+		{"success", policy, nil, 0, nil, 1},
+		{"transient then success", policy, []error{errTransient, errTransient}, 0, nil, 3},
+		{"permanent", policy, []error{errNotFound}, 0, errNotFound, 1},
+		{"transient then permanent", policy, []error{errTransient, errNotFound}, 0, errNotFound, 2},
+		{"attempts exhausted", policy, []error{errTransient, errTransient, errTransient, errTransient}, 0, errTransient, 3},
+		{"never", retry.Never, []error{errTransient}, 0, errTransient, 1},
+		{"server delay", policy, []error{errTransient}, 20 * time.Millisecond, nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			start := time.Now()
+			err := tt.policy.Do(context.Background(), classify(tt.after), scripted(tt.errs, 0, &calls))
+
+			if err != tt.wantErr {
+				t.Fatalf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("called %d times, want %d", calls, tt.wantCalls)
+			}
+			if elapsed := time.Since(start); elapsed < tt.after {
+				t.Fatalf("Do() returned after %v, before the requested delay of %v", elapsed, tt.after)
+			}
+		})
+	}
+}
+
+func TestDoContextDone(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency time.Duration
+		policy  retry.Policy
+	}{
+		// the call itself outlives the context
+		{"during call", time.Minute, retry.Policy{MaxAttempts: 3, Interval: time.Millisecond}},
+		// the context is done while waiting to retry
+		{"during delay", 0, retry.Policy{MaxAttempts: 3, Interval: time.Minute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			var calls int
+			err := tt.policy.Do(ctx, classify(0), scripted([]error{errTransient, errTransient}, tt.latency, &calls))
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Do() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+			if calls != 1 {
+				t.Fatalf("called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"missing", "", 0, 0},
+		{"seconds", "3", 3 * time.Second, 3 * time.Second},
+		{"zero seconds", "0", 0, 0},
+		{"date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+		{"past date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+		{"invalid", "soon", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if len(tt.value) > 0 {
+				h.Set("Retry-After", tt.value)
+			}
+			if got := retry.After(h); got < tt.min || got > tt.max {
+				t.Fatalf("After(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// SSHUser is the login user of the keys GCE instances are created with
+const SSHUser = "cpt"
+
+// ImportSSHKeyResponse contains the response from importing an SSH public key
+type ImportSSHKeyResponse struct {
+	Name  string
+	KeyID Ref
+	// PublicKey is the key in authorized_keys format
+	PublicKey string
+	// Fingerprint is the MD5 fingerprint of the key
+	Fingerprint string
+}
+
+// SSHKeyName returns the name of the key ServerEphemeralSSHKey registers for a server
+func SSHKeyName(server string) string {
+	return server + "-key"
+}
+
+// SSHFingerprint returns the MD5 fingerprint of a public key in authorized_keys format,
+// as shown by DigitalOcean and EC2
+func SSHFingerprint(publicKey string) (string, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("invalid SSH public key %q", publicKey)
+	}
+	data, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid SSH public key %q: %v", publicKey, err)
+	}
+
+	sum := md5.Sum(data)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(hex, ":"), nil
+}
+
+// sshString appends an SSH wire format string
+func sshString(b []byte, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// GenerateSSHKey generates an ed25519 key pair and returns its public key in
+// authorized_keys format and its unencrypted private key in OpenSSH format
+func GenerateSSHKey(comment string) (string, string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	wirePub := sshString(sshString(nil, []byte("ssh-ed25519")), pub)
+
+	// the private section is checked by repeating a random number and padded to the
+	// block size of the "none" cipher
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return "", "", err
+	}
+	section := append(check[:], check[:]...)
+	section = sshString(section, []byte("ssh-ed25519"))
+	section = sshString(section, pub)
+	section = sshString(section, priv)
+	section = sshString(section, []byte(comment))
+	for i := byte(1); len(section)%8 != 0; i++ {
+		section = append(section, i)
+	}
+
+	key := []byte("openssh-key-v1\x00")
+	key = sshString(key, []byte("none"))
+	key = sshString(key, []byte("none"))
+	key = sshString(key, nil)
+	key = binary.BigEndian.AppendUint32(key, 1)
+	key = sshString(key, wirePub)
+	key = sshString(key, section)
+
+	publicKey := "ssh-ed25519 " + base64.StdEncoding.EncodeToString(wirePub)
+	if len(comment) > 0 {
+		publicKey += " " + comment
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: key})
+	return publicKey, string(privateKey), nil
+}
+
+// SSHKeysServerOption configures the SSH keys the server accepts
+type SSHKeysServerOption struct {
+	Keys     []Ref
+	Generate bool
+}
+
+// Set adds the server SSH keys
+func (o SSHKeysServerOption) Set(s *ServerInfo) error {
+	s.SSHKeys = append(s.SSHKeys, o.Keys...)
+	s.GenerateSSHKey = s.GenerateSSHKey || o.Generate
+	return nil
+}
+
+// ServerSSHKeys returns a ServerOption that authorizes keys registered with ImportSSHKey
+func ServerSSHKeys(keys ...Ref) ServerOption {
+	return SSHKeysServerOption{Keys: keys}
+}
+
+// ServerEphemeralSSHKey returns a ServerOption that generates an ed25519 key pair for
+// the server. The private key is returned in CreateServerResponse.SSHPrivateKey, and a key
+// registered with the provider is removed with the server.
+func ServerEphemeralSSHKey() ServerOption {
+	return SSHKeysServerOption{Generate: true}
+}
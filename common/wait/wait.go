@@ -0,0 +1,172 @@
+// Package wait implements context-aware polling for resources to become ready
+package wait
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned by Poll when the condition is not met within the timeout
+var ErrTimeout = errors.New("timed out waiting for condition")
+
+// Backoff configures the polling schedule
+type Backoff struct {
+	// InitialDelay is the time to wait before the first poll
+	InitialDelay time.Duration
+	// Interval is the time between the first and second polls
+	Interval time.Duration
+	// Factor multiplies the interval after every poll; values below 1 keep it constant
+	Factor float64
+	// MaxInterval caps the interval; zero means no cap
+	MaxInterval time.Duration
+	// Jitter randomizes every delay by up to +/- this fraction of it
+	Jitter float64
+	// Timeout bounds the overall wait; zero means wait until the context is done
+	Timeout time.Duration
+}
+
+// Default schedules for the resources created by providers
+var (
+	// Server waits for a server to be running
+	Server = Backoff{
+		InitialDelay: 5 * time.Second,
+		Interval:     5 * time.Second,
+		Factor:       1.5,
+		MaxInterval:  30 * time.Second,
+		Jitter:       0.1,
+		Timeout:      15 * time.Minute,
+	}
+	// Cluster waits for a k8s cluster to be running
+	Cluster = Backoff{
+		InitialDelay: 30 * time.Second,
+		Interval:     10 * time.Second,
+		Factor:       1.5,
+		MaxInterval:  60 * time.Second,
+		Jitter:       0.1,
+		Timeout:      45 * time.Minute,
+	}
+	// Address waits for a static IP to be allocated
+	Address = Backoff{
+		InitialDelay: time.Second,
+		Interval:     2 * time.Second,
+		Factor:       1.5,
+		MaxInterval:  15 * time.Second,
+		Jitter:       0.1,
+		Timeout:      5 * time.Minute,
+	}
+	// Volume waits for a block volume to be ready, attached or detached
+	Volume = Backoff{
+		InitialDelay: 2 * time.Second,
+		Interval:     2 * time.Second,
+		Factor:       1.5,
+		MaxInterval:  15 * time.Second,
+		Jitter:       0.1,
+		Timeout:      10 * time.Minute,
+	}
+	// Operation waits for a provider operation, such as a firewall change, to complete
+	Operation = Backoff{
+		InitialDelay: time.Second,
+		Interval:     2 * time.Second,
+		Factor:       1.5,
+		MaxInterval:  10 * time.Second,
+		Jitter:       0.1,
+		Timeout:      5 * time.Minute,
+	}
+	// Image waits for an image or snapshot of a server to be ready
+	Image = Backoff{
+		InitialDelay: 10 * time.Second,
+		Interval:     10 * time.Second,
+		Factor:       1.5,
+		MaxInterval:  60 * time.Second,
+		Jitter:       0.1,
+		Timeout:      60 * time.Minute,
+	}
+)
+
+// ConditionFunc reports whether the awaited condition is met.
+// Returning an error stops polling and Poll returns it.
+type ConditionFunc func(ctx context.Context) (done bool, err error)
+
+var (
+	rngMu sync.Mutex
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitter randomizes d by up to +/- fraction of it
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+
+	rngMu.Lock()
+	r := rng.Float64()
+	rngMu.Unlock()
+
+	return d + time.Duration((2*r-1)*fraction*float64(d))
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Poll calls cond on the schedule of b until it reports done, returns an error,
+// the timeout of b elapses or ctx is done. It returns ErrTimeout on timeout and
+// ctx.Err() if ctx is done first.
+func Poll(ctx context.Context, b Backoff, cond ConditionFunc) error {
+	pollCtx := ctx
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+
+	// timedOut distinguishes our own timeout from the caller's context being done
+	timedOut := func(err error) error {
+		if ctx.Err() == nil && pollCtx.Err() == context.DeadlineExceeded {
+			return ErrTimeout
+		}
+		return err
+	}
+
+	if err := sleep(pollCtx, jitter(b.InitialDelay, b.Jitter)); err != nil {
+		return timedOut(err)
+	}
+
+	interval := b.Interval
+	for {
+		done, err := cond(pollCtx)
+		if err != nil {
+			return timedOut(err)
+		}
+		if done {
+			return nil
+		}
+
+		if err := sleep(pollCtx, jitter(interval, b.Jitter)); err != nil {
+			return timedOut(err)
+		}
+
+		if b.Factor > 1 {
+			interval = time.Duration(float64(interval) * b.Factor)
+		}
+		if b.MaxInterval > 0 && interval > b.MaxInterval {
+			interval = b.MaxInterval
+		}
+	}
+}
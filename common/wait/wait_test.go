@@ -0,0 +1,82 @@
+package wait_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// condition returns a ConditionFunc that reports done on call n, fails with
+// err on any earlier call if err is set, and blocks for latency on every call
+func condition(n int, err error, latency time.Duration, calls *int) wait.ConditionFunc {
+	return func(ctx context.Context) (bool, error) {
+		*calls++
+		if latency > 0 {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(latency):
+			}
+		}
+		if err != nil {
+			return false, err
+		}
+		return *calls >= n, nil
+	}
+}
+
+func TestPoll(t *testing.T) {
+	errDenied := errors.New("permission denied")
+
+	backoff := wait.Backoff{
+		Interval:    time.Millisecond,
+		Factor:      2,
+		MaxInterval: 5 * time.Millisecond,
+		Timeout:     time.Second,
+	}
+
+	tests := []struct {
+		name      string
+		doneAfter int
+		err       error
+		latency   time.Duration
+		backoff   wait.Backoff
+		wantErr   error
+		wantCalls int
+	}{
+		{"ready", 1, nil, 0, backoff, nil, 1},
+		{"ready after polls", 4, nil, 0, backoff, nil, 4},
+		{"condition error", 4, errDenied, 0, backoff, errDenied, 1},
+		{"timeout", 1, nil, time.Minute, wait.Backoff{Interval: time.Millisecond, Timeout: 20 * time.Millisecond}, wait.ErrTimeout, 1},
+		{"timeout between polls", 2, nil, 0, wait.Backoff{Interval: time.Minute, Timeout: 20 * time.Millisecond}, wait.ErrTimeout, 1},
+		{"timeout before first poll", 1, nil, 0, wait.Backoff{InitialDelay: time.Minute, Timeout: 20 * time.Millisecond}, wait.ErrTimeout, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			err := wait.Poll(context.Background(), tt.backoff, condition(tt.doneAfter, tt.err, tt.latency, &calls))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Poll() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("condition called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPollContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// the caller's deadline is not reported as a timeout of the poll
+	var calls int
+	err := wait.Poll(ctx, wait.Backoff{Interval: time.Millisecond, Timeout: time.Minute}, condition(1, nil, time.Minute, &calls))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Poll() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
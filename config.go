@@ -0,0 +1,151 @@
+package cpt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config contains the settings used to create a CloudProvider
+type Config struct {
+	// Provider is the registered provider name, e.g. "gce" or "digitalocean"
+	Provider string `json:"provider" yaml:"provider"`
+	// Token is the API token for token based providers (DigitalOcean)
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+	// CredentialsFile is the path to a credentials file (GCE service account).
+	// If empty, GCE falls back to application default credentials.
+	CredentialsFile string `json:"credentialsFile,omitempty" yaml:"credentialsFile,omitempty"`
+	// Project is the cloud project to create resources in (GCE)
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+	// Domain is the base domain name for DNS records
+	Domain string `json:"domain" yaml:"domain"`
+	// DNSZone is the managed DNS zone containing Domain (GCE)
+	DNSZone string `json:"dnsZone,omitempty" yaml:"dnsZone,omitempty"`
+
+	// Region, Size and Image are applied to every server and cluster
+	// unless overridden by a common.ServerOption
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	Size   string `json:"size,omitempty" yaml:"size,omitempty"`
+	Image  string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// Options configure the provider itself, e.g. common.ProviderRetry.
+	// They can only be set in code.
+	Options []common.ProviderOption `json:"-" yaml:"-"`
+}
+
+// ConfigError aggregates every problem found while validating a Config
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid provider config: " + strings.Join(e.Problems, "; ")
+}
+
+// ConfigFromEnv returns a Config for the named provider populated from the
+// environment variables documented in the README
+func ConfigFromEnv(provider string) *Config {
+	cfg := &Config{
+		Provider: provider,
+		Domain:   os.Getenv("DOMAIN"),
+	}
+
+	switch provider {
+	case DIGITALOCEAN.String():
+		cfg.Token = os.Getenv("DO_TOKEN")
+		cfg.Image = os.Getenv("DO_IMAGE_ID")
+	case GCE.String():
+		cfg.CredentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+		cfg.Project = os.Getenv("GCP_PROJECT")
+		cfg.DNSZone = os.Getenv("GCP_DNS_ZONE")
+		cfg.Image = os.Getenv("GCP_SOURCE_IMAGE")
+	case AWS.String():
+		cfg.Image = os.Getenv("AWS_IMAGE_ID")
+	}
+
+	return cfg
+}
+
+// LoadConfig reads a Config from a JSON (.json) or YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, cfg)
+	default:
+		err = yaml.UnmarshalStrict(data, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that the settings required by the configured provider are present.
+// All problems are reported together in a *ConfigError.
+func (c *Config) Validate() error {
+	var problems []string
+	require := func(value, field, env string) {
+		if len(value) == 0 {
+			problems = append(problems, fmt.Sprintf("%s is required for provider %q (env $%s)", field, c.Provider, env))
+		}
+	}
+
+	switch c.Provider {
+	case "":
+		problems = append(problems, fmt.Sprintf("provider is required (one of %v)", Providers()))
+	case DIGITALOCEAN.String():
+		require(c.Token, "token", "DO_TOKEN")
+		require(c.Domain, "domain", "DOMAIN")
+	case GCE.String():
+		require(c.Project, "project", "GCP_PROJECT")
+		require(c.Domain, "domain", "DOMAIN")
+		require(c.DNSZone, "dnsZone", "GCP_DNS_ZONE")
+		if len(c.CredentialsFile) > 0 {
+			if _, err := os.Stat(c.CredentialsFile); err != nil {
+				problems = append(problems, fmt.Sprintf("credentialsFile: %v", err))
+			}
+		}
+	case AWS.String():
+		require(c.Domain, "domain", "DOMAIN")
+	default:
+		factoriesMu.RLock()
+		_, ok := factories[c.Provider]
+		factoriesMu.RUnlock()
+		if !ok {
+			problems = append(problems, fmt.Sprintf("provider %q is not registered (one of %v)", c.Provider, Providers()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{problems}
+	}
+	return nil
+}
+
+// serverOptions returns the default options from the config
+func (c *Config) serverOptions() []common.ServerOption {
+	var opts []common.ServerOption
+	if len(c.Region) > 0 {
+		opts = append(opts, common.ServerRegion(c.Region))
+	}
+	if len(c.Size) > 0 {
+		opts = append(opts, common.ServerSize(c.Size))
+	}
+	if len(c.Image) > 0 {
+		opts = append(opts, common.ServerImage(c.Image))
+	}
+	return opts
+}
@@ -4,8 +4,8 @@ package cpt
 import (
 	"context"
 	"fmt"
-	"os"
 
+	"github.com/sas-fe/cloud-provider-tools/aws"
 	"github.com/sas-fe/cloud-provider-tools/common"
 	"github.com/sas-fe/cloud-provider-tools/digitalocean"
 	"github.com/sas-fe/cloud-provider-tools/gce"
@@ -41,60 +41,101 @@ type CloudProvider interface {
 
 	CreateStaticIP(ctx context.Context, name string, ipType *common.StaticIPRequest) (*common.CreateStaticIPResponse, error)
 	RemoveStaticIP(ctx context.Context, staticIP *common.CreateStaticIPResponse) error
+
+	ListServers(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerResponse, error)
+	GetServer(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateServerResponse, error)
+	ListServerGroups(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerGroupResponse, error)
+	ListK8s(ctx context.Context, opts ...common.ListOption) ([]*common.CreateK8sResponse, error)
+	GetK8s(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateK8sResponse, error)
+	ListDNSRecords(ctx context.Context, opts ...common.ListOption) ([]*common.CreateDNSRecordResponse, error)
+	ListStaticIPs(ctx context.Context, opts ...common.ListOption) ([]*common.CreateStaticIPResponse, error)
+
+	StopServer(ctx context.Context, server *common.CreateServerResponse) error
+	StartServer(ctx context.Context, server *common.CreateServerResponse) (*common.CreateServerResponse, error)
+	RebootServer(ctx context.Context, server *common.CreateServerResponse) error
+	ResizeServer(ctx context.Context, server *common.CreateServerResponse, size string) (*common.CreateServerResponse, error)
+
+	CreateImageFromServer(ctx context.Context, server *common.CreateServerResponse, name string) (*common.CreateImageResponse, error)
+	ListImages(ctx context.Context, opts ...common.ListOption) ([]*common.CreateImageResponse, error)
+	RemoveImage(ctx context.Context, image *common.CreateImageResponse) error
+
+	CreateVolume(ctx context.Context, name string, req *common.VolumeRequest) (*common.CreateVolumeResponse, error)
+	AttachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error
+	DetachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error
+	RemoveVolume(ctx context.Context, volume *common.CreateVolumeResponse) error
+
+	CreateFirewall(ctx context.Context, name string, req *common.FirewallRequest) (*common.CreateFirewallResponse, error)
+	RemoveFirewall(ctx context.Context, firewall *common.CreateFirewallResponse) error
+
+	ImportSSHKey(ctx context.Context, name string, publicKey string) (*common.ImportSSHKeyResponse, error)
+	RemoveSSHKey(ctx context.Context, key *common.ImportSSHKeyResponse) error
+
+	CreateNetwork(ctx context.Context, name string, req *common.NetworkRequest) (*common.CreateNetworkResponse, error)
+	RemoveNetwork(ctx context.Context, network *common.CreateNetworkResponse) error
+
+	Capabilities() *common.Capabilities
 }
 
+var _ CloudProvider = (*aws.Provider)(nil)
 var _ CloudProvider = (*digitalocean.Provider)(nil)
 var _ CloudProvider = (*gce.Provider)(nil)
 
-// NewCloudProvider returns a CloudProvider instance
-func NewCloudProvider(pt ProviderType) (CloudProvider, error) {
+// String returns the registered name of the provider type
+func (pt ProviderType) String() string {
 	switch pt {
 	case DIGITALOCEAN:
-		fmt.Println("Using DigitalOcean")
+		return "digitalocean"
+	case AWS:
+		return "aws"
+	case GCE:
+		return "gce"
+	case AZURE:
+		return "azure"
+	default:
+		return fmt.Sprintf("ProviderType(%d)", int(pt))
+	}
+}
+
+func init() {
+	Register(DIGITALOCEAN.String(), newDigitalOceanProvider)
+	Register(GCE.String(), newGCEProvider)
+	Register(AWS.String(), newAWSProvider)
+}
 
-		doToken := os.Getenv("DO_TOKEN")
-		if len(doToken) == 0 {
-			panic("$DO_TOKEN not set")
-		}
+// NewCloudProvider returns a CloudProvider instance configured from the environment
+func NewCloudProvider(pt ProviderType) (CloudProvider, error) {
+	return NewCloudProviderByName(pt.String())
+}
 
-		domain := os.Getenv("DOMAIN")
-		if len(domain) == 0 {
-			panic("$DOMAIN not set")
-		}
+func newDigitalOceanProvider(cfg *Config) (CloudProvider, error) {
+	p, err := digitalocean.NewProvider(cfg.Token, cfg.Domain, cfg.Options...)
+	if err != nil {
+		return nil, err
+	}
 
-		p := digitalocean.NewProvider(doToken, domain)
-		return p, nil
-	case GCE:
-		fmt.Println("Using GCE")
-
-		adc := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
-		if len(adc) == 0 {
-			panic("$GOOGLE_APPLICATION_CREDENTIALS not set")
-		}
-
-		projectID := os.Getenv("GCP_PROJECT")
-		if len(projectID) == 0 {
-			panic("$GCP_PROJECT not set")
-		}
-
-		domain := os.Getenv("DOMAIN")
-		if len(domain) == 0 {
-			panic("$DOMAIN not set")
-		}
-
-		dnsZone := os.Getenv("GCP_DNS_ZONE")
-		if len(domain) == 0 {
-			panic("$GCP_DNS_ZONE not set")
-		}
-
-		p, err := gce.NewProvider(projectID, domain, dnsZone)
-		if err != nil {
-			return nil, err
-		}
-
-		return p, nil
-	default:
-		fmt.Println("Provider Not Implemented")
-		return nil, fmt.Errorf("Provider Not Implemented")
+	return p, nil
+}
+
+func newGCEProvider(cfg *Config) (CloudProvider, error) {
+	var p *gce.Provider
+	var err error
+	if len(cfg.CredentialsFile) > 0 {
+		p, err = gce.NewProviderFromCredentialsFile(cfg.CredentialsFile, cfg.Project, cfg.Domain, cfg.DNSZone, cfg.Options...)
+	} else {
+		p, err = gce.NewProvider(cfg.Project, cfg.Domain, cfg.DNSZone, cfg.Options...)
+	}
+	if err != nil {
+		return nil, err
 	}
+
+	return p, nil
+}
+
+func newAWSProvider(cfg *Config) (CloudProvider, error) {
+	p, err := aws.NewProvider(cfg.Domain, cfg.Options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
 }
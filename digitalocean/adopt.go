@@ -0,0 +1,127 @@
+package digitalocean
+
+import (
+	"context"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// ownerTag returns the droplet tag marking droplets created with the owner tag, or ""
+func (p *Provider) ownerTag() string {
+	if len(p.info.OwnerTag) == 0 {
+		return ""
+	}
+	return common.OwnerLabel + ":" + p.info.OwnerTag
+}
+
+// listPages calls list with the options for every page of results, until the last page
+func (p *Provider) listPages(ctx context.Context, list func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error)) error {
+	opt := &godo.ListOptions{Page: 1, PerPage: 200}
+	for {
+		var resp *godo.Response
+		err := p.call(ctx, true, func(ctx context.Context) error {
+			var err error
+			resp, err = list(ctx, opt)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if resp.Links == nil || resp.Links.IsLastPage() {
+			return nil
+		}
+
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return err
+		}
+		opt.Page = page + 1
+	}
+}
+
+// adoptDroplet looks up a droplet with the name of req, and returns it if it can be adopted,
+// or nil if there is none. Droplets that are still provisioning are waited for by the caller.
+func (p *Provider) adoptDroplet(ctx context.Context, req *godo.DropletCreateRequest) (*godo.Droplet, error) {
+	var found []godo.Droplet
+	err := p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+		droplets, resp, err := p.client.Droplets.List(ctx, opt)
+		for _, d := range droplets {
+			if d.Name == req.Name {
+				found = append(found, d)
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, wrapErr("CreateServer", err)
+	}
+
+	conflict := func(format string, args ...interface{}) (*godo.Droplet, error) {
+		return nil, common.SpecConflict("digitalocean", common.OpCreateServer, req.Name, format, args...)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return conflict("is the name of %d droplets", len(found))
+	}
+
+	d := &found[0]
+	if owner := p.ownerTag(); len(owner) > 0 && !contains(d.Tags, owner) {
+		return conflict("is not tagged %s", owner)
+	}
+	if len(req.Size) > 0 && d.SizeSlug != req.Size {
+		return conflict("has size %s, not %s", d.SizeSlug, req.Size)
+	}
+	if len(req.Region) > 0 && d.Region != nil && d.Region.Slug != req.Region {
+		return conflict("is in region %s, not %s", d.Region.Slug, req.Region)
+	}
+	for _, tag := range req.Tags {
+		// the expiry of a TTL changes with every run
+		if !contains(d.Tags, tag) && !strings.HasPrefix(tag, common.ExpiresLabel+":") {
+			return conflict("is not tagged %s", tag)
+		}
+	}
+	if d.Status != "new" && d.Status != "active" {
+		return conflict("is %s", d.Status)
+	}
+
+	return d, nil
+}
+
+// adoptRecord looks up an A record with the name of req, and returns it if it
+// points to the same address, or nil if there is none
+func (p *Provider) adoptRecord(ctx context.Context, req *godo.DomainRecordEditRequest) (*godo.DomainRecord, error) {
+	var found *godo.DomainRecord
+	err := p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+		records, resp, err := p.client.Domains.Records(ctx, p.domain, opt)
+		for i := range records {
+			if records[i].Type == req.Type && records[i].Name == req.Name {
+				found = &records[i]
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, wrapErr("CreateDNSRecord", err)
+	}
+
+	if found != nil && found.Data != req.Data {
+		return nil, common.SpecConflict("digitalocean", common.OpCreateDNSRecord, req.Name, "points to %s", found.Data)
+	}
+
+	return found, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
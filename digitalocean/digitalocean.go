@@ -3,7 +3,6 @@ package digitalocean
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -11,6 +10,7 @@ import (
 
 	"github.com/digitalocean/godo"
 	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
 	"golang.org/x/oauth2"
 )
 
@@ -34,25 +34,83 @@ func clientFromToken(DOToken string) *godo.Client {
 type Provider struct {
 	client *godo.Client
 	domain string
+	info   *common.ProviderInfo
 }
 
-// NewProvider returns a new Provider instance
-func NewProvider(DOToken string, domain string) *Provider {
-	return &Provider{clientFromToken(DOToken), domain}
+// NewProvider returns a new Provider instance, or an error if the options are invalid
+func NewProvider(DOToken string, domain string, opts ...common.ProviderOption) (*Provider, error) {
+	info, err := common.NewProviderInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{clientFromToken(DOToken), domain, info}, nil
+}
+
+// ref returns the reference to a DigitalOcean resource
+func ref(kind common.Kind, id int, region string) common.Ref {
+	return common.Ref{
+		Provider: "digitalocean",
+		Kind:     kind,
+		ID:       strconv.Itoa(id),
+		Region:   region,
+	}
+}
+
+// uuidRef returns the reference to a volume or firewall, whose IDs are UUIDs
+func uuidRef(kind common.Kind, id string, region string) common.Ref {
+	return common.Ref{
+		Provider: "digitalocean",
+		Kind:     kind,
+		ID:       id,
+		Region:   region,
+	}
+}
+
+// Capabilities returns the operations and options supported by DigitalOcean
+func (p *Provider) Capabilities() *common.Capabilities {
+	return &common.Capabilities{
+		Provider: "digitalocean",
+		Operations: map[common.Operation][]common.OptionName{
+			common.OpCreateServer:    {common.OptRegion, common.OptSize, common.OptImage, common.OptUserData, common.OptTags, common.OptExpires, common.OptVolumes, common.OptFirewall, common.OptSSHKeys, common.OptNetwork},
+			common.OpRemoveServer:    nil,
+			common.OpCreateDNSRecord: nil,
+			common.OpRemoveDNSRecord: nil,
+			common.OpListServers:     nil,
+			common.OpGetServer:       nil,
+			common.OpListDNSRecords:  nil,
+			common.OpStopServer:      nil,
+			common.OpStartServer:     nil,
+			common.OpRebootServer:    nil,
+			common.OpResizeServer:    nil,
+			common.OpCreateImage:     nil,
+			common.OpListImages:      nil,
+			common.OpRemoveImage:     nil,
+			common.OpCreateVolume:    nil,
+			common.OpAttachVolume:    nil,
+			common.OpDetachVolume:    nil,
+			common.OpRemoveVolume:    nil,
+			common.OpCreateFirewall:  nil,
+			common.OpRemoveFirewall:  nil,
+			common.OpImportSSHKey:    nil,
+			common.OpRemoveSSHKey:    nil,
+			common.OpCreateNetwork:   nil,
+			common.OpRemoveNetwork:   nil,
+		},
+		// the API allows 250 requests per minute, shared with the status polls of every create
+		CreateInterval: time.Second,
+	}
 }
 
-// CreateServer creates a droplet on DigitalOcean
-// TODO reimplement waiting for IP using a ticker
+// CreateServer creates a droplet on DigitalOcean. If it has no public IPv4 address or its
+// firewall cannot be created, the server is returned with the error so it can be removed.
 func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerResponse, error) {
 	var dropletID int
 	var dropletIP string
 
-	s := &common.ServerInfo{
-		Name: name,
-	}
-
-	for _, opt := range opts {
-		opt.Set(s)
+	s, err := common.NewServerInfo(name, opts...)
+	if err != nil {
+		return nil, err
 	}
 
 	var imageIDStr string
@@ -75,6 +133,29 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 		}
 	}
 
+	tags := append([]string{}, s.Tags...)
+	if owner := p.ownerTag(); len(owner) > 0 {
+		tags = append(tags, owner)
+	}
+	if !s.Expires.IsZero() {
+		tags = append(tags, common.ExpiresLabel+":"+common.ExpiresValue(s.Expires))
+	}
+
+	volumes, err := createVolumes(s.Region, s.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	vpc, err := vpcUUID(s.Network, s.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	sshKeys, err := dropletSSHKeys(s)
+	if err != nil {
+		return nil, err
+	}
+
 	dropletRequest := &godo.DropletCreateRequest{
 		Name:     s.Name,
 		Region:   s.Region,
@@ -82,44 +163,134 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 		Image:    image,
 		UserData: s.UserData,
 		IPv6:     false,
-		Tags:     s.Tags,
+		Tags:     tags,
+		Volumes:  volumes,
+		SSHKeys:  sshKeys,
+		VPCUUID:  vpc,
 	}
 
-	fmt.Println("Creating Droplet...")
-	droplet, _, err := p.client.Droplets.Create(ctx, dropletRequest)
-	if err != nil {
-		return nil, err
+	log := p.info.StartOp(ctx, "digitalocean", common.OpCreateServer, name)
+	log.Info("creating droplet", "region", s.Region, "size", s.Size)
+	if log.DryRun("droplets.create", dropletRequest) {
+		generated, privateKey, err := p.ephemeralSSHKey(ctx, s)
+		if err != nil {
+			return nil, log.Done(err)
+		}
+		resp := &common.CreateServerResponse{
+			Name:          name,
+			ServerID:      ref(common.KindServer, 0, s.Region),
+			ServerRegion:  s.Region,
+			ServerIP:      common.PlaceholderIP,
+			Expires:       s.Expires,
+			SSHKeyID:      generated.KeyID,
+			SSHPrivateKey: privateKey,
+		}
+		if len(s.Firewall) > 0 {
+			firewall, err := p.serverFirewall(ctx, log, name, s.Firewall)
+			if err != nil {
+				return nil, log.Done(err)
+			}
+			resp.FirewallID = firewall.FirewallID
+		}
+		return resp, log.Done(nil)
 	}
-	dropletID = droplet.ID
 
-	fmt.Println(dropletID)
+	var droplet *godo.Droplet
+	if p.info.Adopt {
+		if droplet, err = p.adoptDroplet(ctx, dropletRequest); err != nil {
+			return nil, log.Done(err)
+		}
+	}
 
-	time.Sleep(60 * time.Second)
+	adopted := droplet != nil
+	generated := &common.ImportSSHKeyResponse{}
+	var privateKey string
+	firewall := &common.CreateFirewallResponse{}
+	if adopted {
+		// an adopted droplet keeps the keys and firewall it was created with
+		log.Info("adopting existing droplet")
+		if len(s.Firewall) > 0 {
+			if firewall, err = p.existingFirewall(ctx, name, droplet.ID); err != nil {
+				return nil, log.Done(err)
+			}
+		}
+	} else {
+		if len(s.Firewall) > 0 {
+			if firewall, err = p.serverFirewall(ctx, log, name, s.Firewall); err != nil {
+				return nil, log.Done(err)
+			}
+		}
+		if generated, privateKey, err = p.ephemeralSSHKey(ctx, s); err != nil {
+			p.removeServerFirewall(ctx, log, firewall)
+			return nil, log.Done(err)
+		}
+		if !generated.KeyID.IsZero() {
+			id, err := generated.KeyID.IntID()
+			if err != nil {
+				p.removeServerKey(ctx, log, generated)
+				p.removeServerFirewall(ctx, log, firewall)
+				return nil, log.Done(err)
+			}
+			dropletRequest.SSHKeys = append(dropletRequest.SSHKeys, godo.DropletCreateSSHKey{ID: id})
+		}
 
-	ready := false
-	for !ready {
+		err = p.call(ctx, false, func(ctx context.Context) error {
+			var err error
+			droplet, _, err = p.client.Droplets.Create(ctx, dropletRequest)
+			return err
+		})
+		if err != nil {
+			p.removeServerKey(ctx, log, generated)
+			p.removeServerFirewall(ctx, log, firewall)
+			return nil, log.Done(wrapErr("CreateServer", err))
+		}
+	}
+	dropletID = droplet.ID
+	log.Started(dropletID)
+
+	// the droplet exists from here on, so it is returned along with any error
+	resp := &common.CreateServerResponse{
+		Name:          name,
+		ServerID:      ref(common.KindServer, dropletID, s.Region),
+		ServerRegion:  s.Region,
+		Expires:       s.Expires,
+		FirewallID:    firewall.FirewallID,
+		SSHKeyID:      generated.KeyID,
+		SSHPrivateKey: privateKey,
+	}
+
+	// cloud firewalls apply to droplet IDs, so the firewall is applied once the droplet exists
+	if !adopted && !firewall.FirewallID.IsZero() {
+		if err := p.addServerFirewall(ctx, firewall, dropletID); err != nil {
+			return resp, log.Done(err)
+		}
+	}
+
+	err = wait.Poll(ctx, wait.Server, func(ctx context.Context) (bool, error) {
 		droplet, _, err := p.client.Droplets.Get(ctx, dropletID)
 		if err != nil {
-			return nil, err
+			return false, pollErr(err)
 		}
 
-		if droplet.Status == "active" {
-			fmt.Println("Droplet Created")
-			ready = true
-			dropletIP = droplet.Networks.V4[0].IPAddress
-		} else {
-			time.Sleep(15 * time.Second)
+		log.Status(droplet.Status)
+		if droplet.Status != "active" {
+			return false, nil
 		}
+		// a droplet in a VPC also has a private address, which may be listed first
+		dropletIP, _ = droplet.PublicIPv4()
+		return true, nil
+	})
+	if err != nil {
+		return resp, log.Done(wrapErr("CreateServer", err))
 	}
 
-	fmt.Println(dropletIP)
+	resp.ServerIP = dropletIP
+	if len(dropletIP) == 0 {
+		return resp, log.Done(common.NewError("digitalocean", "CreateServer", nil, fmt.Errorf("droplet %d has no public IPv4 address", dropletID)))
+	}
+	log.IPAssigned(dropletIP)
 
-	return &common.CreateServerResponse{
-		Name:         name,
-		ServerID:     dropletID,
-		ServerRegion: s.Region,
-		ServerIP:     dropletIP,
-	}, nil
+	return resp, log.Done(nil)
 }
 
 // CreateDNSRecord creates a DNS A Record on DigitalOcean
@@ -129,77 +300,139 @@ func (p *Provider) CreateDNSRecord(ctx context.Context, subDomain string, IP str
 		Name: subDomain,
 		Data: IP,
 	}
-	domainRecord, _, err := p.client.Domains.CreateRecord(ctx, p.domain, domainRequest)
-	if err != nil {
-		return nil, err
+	log := p.info.StartOp(ctx, "digitalocean", common.OpCreateDNSRecord, subDomain)
+	log.Info("creating DNS record", "ip", IP)
+	if log.DryRun("domains.records.create", domainRequest) {
+		return &common.CreateDNSRecordResponse{
+			SubDomain:   subDomain,
+			SubDomainID: ref(common.KindDNSRecord, 0, ""),
+			SubDomainIP: IP,
+		}, log.Done(nil)
+	}
+
+	var domainRecord *godo.DomainRecord
+	var err error
+	if p.info.Adopt {
+		if domainRecord, err = p.adoptRecord(ctx, domainRequest); err != nil {
+			return nil, log.Done(err)
+		}
+	}
+
+	if domainRecord != nil {
+		log.Info("adopting existing DNS record")
+	} else {
+		err = p.call(ctx, false, func(ctx context.Context) error {
+			var err error
+			domainRecord, _, err = p.client.Domains.CreateRecord(ctx, p.domain, domainRequest)
+			return err
+		})
+		if err != nil {
+			return nil, log.Done(wrapErr("CreateDNSRecord", err))
+		}
 	}
+	log.Started(domainRecord.ID)
 
 	return &common.CreateDNSRecordResponse{
 		SubDomain:   subDomain,
-		SubDomainID: domainRecord.ID,
-	}, nil
+		SubDomainID: ref(common.KindDNSRecord, domainRecord.ID, ""),
+		SubDomainIP: domainRecord.Data,
+	}, log.Done(nil)
 }
 
 // RemoveServer removes a droplet on DigitalOcean
 func (p *Provider) RemoveServer(ctx context.Context, server *common.CreateServerResponse) error {
-	intServerID, ok := server.ServerID.(int)
-	if !ok {
-		return fmt.Errorf("%v is not an int", server.ServerID)
+	intServerID, err := dropletID(server)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Deleting droplet...")
-	_, err := p.client.Droplets.Delete(ctx, intServerID)
-	if err != nil {
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRemoveServer, server.Name)
+	log.Info("deleting droplet")
+	if log.DryRun("droplets.delete", map[string]int{"id": intServerID}) {
+		return log.Done(nil)
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.Droplets.Delete(ctx, intServerID)
 		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveServer", err))
 	}
-	fmt.Println("Done")
+	log.Started(intServerID)
 
-	return nil
+	if !server.FirewallID.IsZero() {
+		err := p.RemoveFirewall(ctx, &common.CreateFirewallResponse{
+			Name:       common.FirewallName(server.Name),
+			FirewallID: server.FirewallID,
+		})
+		if err != nil {
+			return log.Done(err)
+		}
+	}
+	if !server.SSHKeyID.IsZero() {
+		return log.Done(p.RemoveSSHKey(ctx, &common.ImportSSHKeyResponse{
+			Name:  common.SSHKeyName(server.Name),
+			KeyID: server.SSHKeyID,
+		}))
+	}
+	return log.Done(nil)
 }
 
 // RemoveDNSRecord removes a DNS A Record from DigitalOcean
 func (p *Provider) RemoveDNSRecord(ctx context.Context, subDomain *common.CreateDNSRecordResponse) error {
-	intSubDomainID, ok := subDomain.SubDomainID.(int)
-	if !ok {
-		return fmt.Errorf("%v is not an int", subDomain.SubDomainID)
+	if err := subDomain.SubDomainID.Check("digitalocean", common.KindDNSRecord); err != nil {
+		return err
 	}
-
-	fmt.Println("Deleting Domain Record...")
-	_, err := p.client.Domains.DeleteRecord(ctx, p.domain, intSubDomainID)
+	intSubDomainID, err := subDomain.SubDomainID.IntID()
 	if err != nil {
 		return err
 	}
-	fmt.Println("Done")
 
-	return nil
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRemoveDNSRecord, subDomain.SubDomain)
+	log.Info("deleting DNS record")
+	if log.DryRun("domains.records.delete", map[string]interface{}{"domain": p.domain, "id": intSubDomainID}) {
+		return log.Done(nil)
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.Domains.DeleteRecord(ctx, p.domain, intSubDomainID)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveDNSRecord", err))
+	}
+	log.Started(intSubDomainID)
+
+	return log.Done(nil)
 }
 
 // CreateServerGroup unimplemented for DigitalOcean
 func (p *Provider) CreateServerGroup(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerGroupResponse, error) {
-	return nil, errors.New("Unimplemented")
+	return nil, common.NotImplemented("digitalocean", "CreateServerGroup")
 }
 
 // RemoveServerGroup unimplemented for DigitalOcean
 func (p *Provider) RemoveServerGroup(ctx context.Context, group *common.CreateServerGroupResponse) error {
-	return errors.New("Unimplemented")
+	return common.NotImplemented("digitalocean", "RemoveServerGroup")
 }
 
 // CreateK8s unimplemented for DigitalOcean
 func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateK8sResponse, error) {
-	return nil, errors.New("Unimplemented")
+	return nil, common.NotImplemented("digitalocean", "CreateK8s")
 }
 
 // RemoveK8s unimplemented for DigitalOcean
 func (p *Provider) RemoveK8s(ctx context.Context, k8s *common.CreateK8sResponse) error {
-	return errors.New("Unimplemented")
+	return common.NotImplemented("digitalocean", "RemoveK8s")
 }
 
 // CreateStaticIP unimplemented for DigitalOcean
 func (p *Provider) CreateStaticIP(ctx context.Context, name string, req *common.StaticIPRequest) (*common.CreateStaticIPResponse, error) {
-	return nil, errors.New("Unimplemented")
+	return nil, common.NotImplemented("digitalocean", "CreateStaticIP")
 }
 
 // RemoveStaticIP unimplemented for DigitalOcean
 func (p *Provider) RemoveStaticIP(ctx context.Context, staticIP *common.CreateStaticIPResponse) error {
-	return errors.New("Unimplemented")
+	return common.NotImplemented("digitalocean", "RemoveStaticIP")
 }
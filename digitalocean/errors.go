@@ -0,0 +1,42 @@
+package digitalocean
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// classify maps a DigitalOcean API error to one of the common error classes
+func classify(err error) error {
+	var apiErr *godo.ErrorResponse
+	if !errors.As(err, &apiErr) || apiErr.Response == nil {
+		return nil
+	}
+
+	switch code := apiErr.Response.StatusCode; {
+	case code == http.StatusNotFound:
+		return common.ErrNotFound
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return common.ErrPermissionDenied
+	case code == http.StatusTooManyRequests, code >= 500:
+		return common.ErrTransient
+	case code == http.StatusUnprocessableEntity:
+		msg := strings.ToLower(apiErr.Message)
+		if strings.Contains(msg, "limit") {
+			return common.ErrQuotaExceeded
+		}
+		if strings.Contains(msg, "already") {
+			return common.ErrAlreadyExists
+		}
+	}
+
+	return nil
+}
+
+// wrapErr classifies err for the given operation, preserving it as the cause
+func wrapErr(op string, err error) error {
+	return common.NewError("digitalocean", op, classify(err), err)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strconv"
 	"time"
 
 	"github.com/sas-fe/cloud-provider-tools/common"
@@ -22,7 +21,10 @@ func main() {
 		panic("$DOMAIN not set")
 	}
 
-	p := digitalocean.NewProvider(doToken, domain)
+	p, err := digitalocean.NewProvider(doToken, domain)
+	if err != nil {
+		panic(err)
+	}
 
 	ctx := context.TODO()
 
@@ -43,7 +45,7 @@ func main() {
 
 	fmt.Println(serverResp)
 
-	subDomain := serverResp.Name + "-" + strconv.Itoa(serverResp.ServerID.(int)) + "." + "instances"
+	subDomain := serverResp.Name + "-" + serverResp.ServerID.ID + "." + "instances"
 
 	dnsResp, err := p.CreateDNSRecord(ctx, subDomain, serverResp.ServerIP)
 	if err != nil {
@@ -0,0 +1,192 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// allowAllOutbound lets droplets behind a cloud firewall reach any destination, which
+// cloud firewalls otherwise deny
+var allowAllOutbound = []godo.OutboundRule{
+	{Protocol: "tcp", PortRange: "all", Destinations: &godo.Destinations{Addresses: []string{"0.0.0.0/0", "::/0"}}},
+	{Protocol: "udp", PortRange: "all", Destinations: &godo.Destinations{Addresses: []string{"0.0.0.0/0", "::/0"}}},
+	{Protocol: "icmp", Destinations: &godo.Destinations{Addresses: []string{"0.0.0.0/0", "::/0"}}},
+}
+
+// firewallRequest returns the cloud firewall request of the rules, applied to the tags
+// and droplets
+func firewallRequest(name string, req *common.FirewallRequest, dropletIDs []int) (*godo.FirewallRequest, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var inbound []godo.InboundRule
+	for _, r := range req.Rules {
+		sources := &godo.Sources{Addresses: common.SourcesOf(r)}
+		switch {
+		case r.Protocol == "icmp":
+			inbound = append(inbound, godo.InboundRule{Protocol: r.Protocol, Sources: sources})
+		case len(r.Ports) == 0:
+			inbound = append(inbound, godo.InboundRule{Protocol: r.Protocol, PortRange: "all", Sources: sources})
+		}
+		for _, port := range r.Ports {
+			inbound = append(inbound, godo.InboundRule{Protocol: r.Protocol, PortRange: port, Sources: sources})
+		}
+	}
+
+	return &godo.FirewallRequest{
+		Name:          name,
+		InboundRules:  inbound,
+		OutboundRules: allowAllOutbound,
+		DropletIDs:    dropletIDs,
+		Tags:          req.TargetTags,
+	}, nil
+}
+
+// createFirewall creates a cloud firewall and waits until it is applied
+func (p *Provider) createFirewall(ctx context.Context, name string, req *common.FirewallRequest, dropletIDs []int) (*common.CreateFirewallResponse, error) {
+	firewallReq, err := firewallRequest(name, req, dropletIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpCreateFirewall, name)
+	log.Info("creating firewall", "tags", req.TargetTags, "droplets", dropletIDs)
+	if log.DryRun("firewalls.create", firewallReq) {
+		return &common.CreateFirewallResponse{
+			Name:       name,
+			FirewallID: uuidRef(common.KindFirewall, common.PlaceholderID(name), ""),
+			TargetTags: req.TargetTags,
+		}, log.Done(nil)
+	}
+
+	var firewall *godo.Firewall
+	err = p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		firewall, _, err = p.client.Firewalls.Create(ctx, firewallReq)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateFirewall", err))
+	}
+	log.Started(firewall.ID)
+
+	resp := &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: uuidRef(common.KindFirewall, firewall.ID, ""),
+		TargetTags: req.TargetTags,
+	}
+
+	err = wait.Poll(ctx, wait.Operation, func(ctx context.Context) (bool, error) {
+		current, _, err := p.client.Firewalls.Get(ctx, firewall.ID)
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		log.Status(current.Status)
+		if current.Status == "failed" {
+			return false, fmt.Errorf("firewall %s failed", name)
+		}
+		return current.Status == "succeeded", nil
+	})
+	if err != nil {
+		return resp, log.Done(wrapErr("CreateFirewall", err))
+	}
+
+	return resp, log.Done(nil)
+}
+
+// CreateFirewall creates a cloud firewall on DigitalOcean applied to the droplets with
+// the target tags. Outbound traffic is allowed to any destination.
+func (p *Provider) CreateFirewall(ctx context.Context, name string, req *common.FirewallRequest) (*common.CreateFirewallResponse, error) {
+	return p.createFirewall(ctx, name, req, nil)
+}
+
+// serverFirewall creates the firewall of a droplet created with ServerFirewall, before
+// the droplet is, applied to no droplets yet. A firewall that is created but not applied
+// is removed.
+func (p *Provider) serverFirewall(ctx context.Context, log *common.OpLog, server string, rules []common.FirewallRule) (*common.CreateFirewallResponse, error) {
+	firewall, err := p.createFirewall(ctx, common.FirewallName(server), &common.FirewallRequest{Rules: rules}, nil)
+	if err != nil && firewall != nil {
+		p.removeServerFirewall(ctx, log, firewall)
+		return nil, err
+	}
+	return firewall, err
+}
+
+// addServerFirewall applies the firewall created by serverFirewall to the droplet
+func (p *Provider) addServerFirewall(ctx context.Context, firewall *common.CreateFirewallResponse, id int) error {
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.Firewalls.AddDroplets(ctx, firewall.FirewallID.ID, id)
+		return err
+	})
+	return wrapErr("CreateServer", err)
+}
+
+// existingFirewall returns the firewall of the server applied to the droplet, or an
+// empty response if there is none, for adopted droplets that keep the firewall they
+// were created with
+func (p *Provider) existingFirewall(ctx context.Context, server string, id int) (*common.CreateFirewallResponse, error) {
+	name := common.FirewallName(server)
+
+	var existing *godo.Firewall
+	err := p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+		firewalls, resp, err := p.client.Firewalls.ListByDroplet(ctx, id, opt)
+		for i := range firewalls {
+			if firewalls[i].Name == name {
+				existing = &firewalls[i]
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, wrapErr("CreateServer", err)
+	}
+	if existing == nil {
+		return &common.CreateFirewallResponse{}, nil
+	}
+
+	return &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: uuidRef(common.KindFirewall, existing.ID, ""),
+	}, nil
+}
+
+// removeServerFirewall removes the firewall of a droplet that could not be created, if any
+func (p *Provider) removeServerFirewall(ctx context.Context, log *common.OpLog, firewall *common.CreateFirewallResponse) {
+	if firewall.FirewallID.IsZero() {
+		return
+	}
+	if err := p.RemoveFirewall(context.WithoutCancel(ctx), firewall); err != nil {
+		log.Warn("could not remove the firewall of the failed server", "firewall", firewall.Name, "error", err)
+	}
+}
+
+// RemoveFirewall removes a cloud firewall on DigitalOcean
+func (p *Provider) RemoveFirewall(ctx context.Context, firewall *common.CreateFirewallResponse) error {
+	if err := firewall.FirewallID.Check("digitalocean", common.KindFirewall); err != nil {
+		return err
+	}
+	firewallID := firewall.FirewallID.ID
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRemoveFirewall, firewall.Name)
+	log.Info("deleting firewall")
+	if log.DryRun("firewalls.delete", map[string]string{"id": firewallID}) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.Firewalls.Delete(ctx, firewallID)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveFirewall", err))
+	}
+	log.Started(firewallID)
+
+	return log.Done(nil)
+}
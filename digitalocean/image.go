@@ -0,0 +1,116 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// imageResponse returns the response describing a snapshot or custom image
+func imageResponse(img *godo.Image, source string) *common.CreateImageResponse {
+	return &common.CreateImageResponse{
+		Name:    img.Name,
+		ImageID: ref(common.KindImage, img.ID, ""),
+		Image:   strconv.Itoa(img.ID),
+		Source:  source,
+	}
+}
+
+// CreateImageFromServer creates a snapshot of a droplet on DigitalOcean and waits until
+// it is available. A running droplet is snapshotted live; power it off first for a
+// consistent snapshot.
+func (p *Provider) CreateImageFromServer(ctx context.Context, server *common.CreateServerResponse, name string) (*common.CreateImageResponse, error) {
+	id, err := dropletID(server)
+	if err != nil {
+		return nil, err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpCreateImage, name).With("server", server.Name)
+	log.Info("snapshotting droplet")
+	if log.DryRun("droplets.actions.snapshot", map[string]interface{}{"id": id, "name": name}) {
+		return imageResponse(&godo.Image{Name: name}, server.Name), log.Done(nil)
+	}
+
+	err = p.dropletAction(ctx, log, "CreateImageFromServer", id, wait.Image, func(ctx context.Context) (*godo.Action, *godo.Response, error) {
+		return p.client.DropletActions.Snapshot(ctx, id, name)
+	})
+	if err != nil {
+		return nil, log.Done(err)
+	}
+
+	// the action does not return the snapshot, so take the newest one with the name
+	var snapshot *godo.Image
+	err = p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+		images, resp, err := p.client.Droplets.Snapshots(ctx, id, opt)
+		for i := range images {
+			if images[i].Name == name && (snapshot == nil || images[i].ID > snapshot.ID) {
+				snapshot = &images[i]
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateImageFromServer", err))
+	}
+	if snapshot == nil {
+		return nil, log.Done(common.NewError("digitalocean", "CreateImageFromServer", common.ErrNotFound, fmt.Errorf("snapshot %s", name)))
+	}
+
+	return imageResponse(snapshot, server.Name), log.Done(nil)
+}
+
+// ListImages lists the snapshots and custom images of the account
+func (p *Provider) ListImages(ctx context.Context, opts ...common.ListOption) ([]*common.CreateImageResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*common.CreateImageResponse
+	err = p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+		userImages, resp, err := p.client.Images.ListUser(ctx, opt)
+		for i := range userImages {
+			if l.Match(userImages[i].Name, userImages[i].Tags) {
+				images = append(images, imageResponse(&userImages[i], ""))
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, wrapErr("ListImages", err)
+	}
+
+	return images, nil
+}
+
+// RemoveImage removes a snapshot or custom image on DigitalOcean
+func (p *Provider) RemoveImage(ctx context.Context, image *common.CreateImageResponse) error {
+	if err := image.ImageID.Check("digitalocean", common.KindImage); err != nil {
+		return err
+	}
+	id, err := image.ImageID.IntID()
+	if err != nil {
+		return err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRemoveImage, image.Name)
+	log.Info("deleting image")
+	if log.DryRun("images.delete", map[string]int{"id": id}) {
+		return log.Done(nil)
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.Images.Delete(ctx, id)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveImage", err))
+	}
+	log.Started(id)
+
+	return log.Done(nil)
+}
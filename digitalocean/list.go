@@ -0,0 +1,124 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// serverResponse returns the response describing a droplet
+func serverResponse(d *godo.Droplet) *common.CreateServerResponse {
+	var region, dropletIP string
+	if d.Region != nil {
+		region = d.Region.Slug
+	}
+	if d.Networks != nil {
+		for _, n := range d.Networks.V4 {
+			if n.Type == "public" {
+				dropletIP = n.IPAddress
+				break
+			}
+		}
+	}
+
+	return &common.CreateServerResponse{
+		Name:         d.Name,
+		ServerID:     ref(common.KindServer, d.ID, region),
+		ServerRegion: region,
+		ServerIP:     dropletIP,
+		Expires:      common.Expires(d.Tags),
+	}
+}
+
+// ListServers lists the droplets, in the region set with common.ListRegion if any
+func (p *Provider) ListServers(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []*common.CreateServerResponse
+	err = p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+		droplets, resp, err := p.client.Droplets.List(ctx, opt)
+		for i := range droplets {
+			server := serverResponse(&droplets[i])
+			if len(l.Region) > 0 && server.ServerRegion != l.Region {
+				continue
+			}
+			if l.Match(droplets[i].Name, droplets[i].Tags) {
+				servers = append(servers, server)
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, wrapErr("ListServers", err)
+	}
+
+	return servers, nil
+}
+
+// GetServer returns the droplet with the name
+func (p *Provider) GetServer(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateServerResponse, error) {
+	servers, err := p.ListServers(ctx, append(opts, common.ListPrefix(name))...)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		if server.Name == name {
+			return server, nil
+		}
+	}
+
+	return nil, common.NewError("digitalocean", "GetServer", common.ErrNotFound, fmt.Errorf("droplet %s", name))
+}
+
+// ListDNSRecords lists the A records of the domain. DNS records carry no tags.
+func (p *Provider) ListDNSRecords(ctx context.Context, opts ...common.ListOption) ([]*common.CreateDNSRecordResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*common.CreateDNSRecordResponse
+	err = p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+		domainRecords, resp, err := p.client.Domains.Records(ctx, p.domain, opt)
+		for _, r := range domainRecords {
+			if r.Type == "A" && l.Match(r.Name, nil) {
+				records = append(records, &common.CreateDNSRecordResponse{
+					SubDomain:   r.Name,
+					SubDomainID: ref(common.KindDNSRecord, r.ID, ""),
+					SubDomainIP: r.Data,
+				})
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, wrapErr("ListDNSRecords", err)
+	}
+
+	return records, nil
+}
+
+// ListServerGroups unimplemented for DigitalOcean
+func (p *Provider) ListServerGroups(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerGroupResponse, error) {
+	return nil, common.NotImplemented("digitalocean", "ListServerGroups")
+}
+
+// ListK8s unimplemented for DigitalOcean
+func (p *Provider) ListK8s(ctx context.Context, opts ...common.ListOption) ([]*common.CreateK8sResponse, error) {
+	return nil, common.NotImplemented("digitalocean", "ListK8s")
+}
+
+// GetK8s unimplemented for DigitalOcean
+func (p *Provider) GetK8s(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateK8sResponse, error) {
+	return nil, common.NotImplemented("digitalocean", "GetK8s")
+}
+
+// ListStaticIPs unimplemented for DigitalOcean
+func (p *Provider) ListStaticIPs(ctx context.Context, opts ...common.ListOption) ([]*common.CreateStaticIPResponse, error) {
+	return nil, common.NotImplemented("digitalocean", "ListStaticIPs")
+}
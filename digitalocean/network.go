@@ -0,0 +1,110 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// vpcUUID returns the VPC of a droplet in the region, or "" for the default VPC of the
+// region if network is zero
+func vpcUUID(network common.Ref, region string) (string, error) {
+	if network.IsZero() {
+		return "", nil
+	}
+	if err := network.Check("digitalocean", common.KindNetwork); err != nil {
+		return "", err
+	}
+	if len(network.Region) > 0 && network.Region != region {
+		return "", fmt.Errorf("digitalocean: network %s is in region %s, not %s", network.ID, network.Region, region)
+	}
+	return network.ID, nil
+}
+
+// CreateNetwork creates a VPC on DigitalOcean covering the CIDR in the region. Cloud
+// firewalls apply to droplets in any VPC, so FirewallRequest.Network is ignored.
+func (p *Provider) CreateNetwork(ctx context.Context, name string, req *common.NetworkRequest) (*common.CreateNetworkResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	vpcRequest := &godo.VPCCreateRequest{
+		Name:       name,
+		RegionSlug: req.Region,
+		IPRange:    req.CIDR,
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpCreateNetwork, name).With("region", req.Region)
+	log.Info("creating VPC", "cidr", req.CIDR)
+	if log.DryRun("vpcs.create", vpcRequest) {
+		return &common.CreateNetworkResponse{
+			Name:      name,
+			NetworkID: uuidRef(common.KindNetwork, common.PlaceholderID(name), req.Region),
+			CIDR:      req.CIDR,
+			Region:    req.Region,
+		}, log.Done(nil)
+	}
+
+	var vpc *godo.VPC
+	if p.info.Adopt {
+		err := p.listPages(ctx, func(ctx context.Context, opt *godo.ListOptions) (*godo.Response, error) {
+			vpcs, resp, err := p.client.VPCs.List(ctx, opt)
+			for _, v := range vpcs {
+				if v.Name == name && v.RegionSlug == req.Region {
+					vpc = v
+				}
+			}
+			return resp, err
+		})
+		if err != nil {
+			return nil, log.Done(wrapErr("CreateNetwork", err))
+		}
+	}
+
+	if vpc != nil {
+		log.Info("adopting existing VPC")
+	} else {
+		err := p.call(ctx, false, func(ctx context.Context) error {
+			var err error
+			vpc, _, err = p.client.VPCs.Create(ctx, vpcRequest)
+			return err
+		})
+		if err != nil {
+			return nil, log.Done(wrapErr("CreateNetwork", err))
+		}
+	}
+	log.Started(vpc.ID)
+
+	return &common.CreateNetworkResponse{
+		Name:      vpc.Name,
+		NetworkID: uuidRef(common.KindNetwork, vpc.ID, vpc.RegionSlug),
+		CIDR:      vpc.IPRange,
+		Region:    vpc.RegionSlug,
+	}, log.Done(nil)
+}
+
+// RemoveNetwork removes a VPC on DigitalOcean. Its droplets must be removed first.
+func (p *Provider) RemoveNetwork(ctx context.Context, network *common.CreateNetworkResponse) error {
+	if err := network.NetworkID.Check("digitalocean", common.KindNetwork); err != nil {
+		return err
+	}
+	vpcID := network.NetworkID.ID
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRemoveNetwork, network.Name)
+	log.Info("deleting VPC")
+	if log.DryRun("vpcs.delete", map[string]string{"id": vpcID}) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.VPCs.Delete(ctx, vpcID)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveNetwork", err))
+	}
+	log.Started(vpcID)
+
+	return log.Done(nil)
+}
@@ -0,0 +1,179 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// dropletID returns the ID of the droplet of a server response
+func dropletID(server *common.CreateServerResponse) (int, error) {
+	if err := server.ServerID.Check("digitalocean", common.KindServer); err != nil {
+		return 0, err
+	}
+	return server.ServerID.IntID()
+}
+
+// dropletAction runs a droplet action and polls it with the backoff until it completes
+func (p *Provider) dropletAction(ctx context.Context, log *common.OpLog, op string, id int, b wait.Backoff, action func(ctx context.Context) (*godo.Action, *godo.Response, error)) error {
+	return p.runAction(ctx, log, op, b, action, func(ctx context.Context, actionID int) (*godo.Action, *godo.Response, error) {
+		return p.client.DropletActions.Get(ctx, id, actionID)
+	})
+}
+
+// runAction runs an action and polls it with get and the backoff until it completes
+func (p *Provider) runAction(ctx context.Context, log *common.OpLog, op string, b wait.Backoff, action func(ctx context.Context) (*godo.Action, *godo.Response, error), get func(ctx context.Context, actionID int) (*godo.Action, *godo.Response, error)) error {
+	var a *godo.Action
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		a, _, err = action(ctx)
+		return err
+	})
+	if err != nil {
+		return wrapErr(op, err)
+	}
+	log.Started(a.ID)
+
+	err = wait.Poll(ctx, b, func(ctx context.Context) (bool, error) {
+		current, _, err := get(ctx, a.ID)
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		log.Status(current.Status)
+		switch current.Status {
+		case godo.ActionCompleted:
+			return true, nil
+		case "errored":
+			return false, fmt.Errorf("action %s errored", current.Type)
+		}
+		return false, nil
+	})
+	return wrapErr(op, err)
+}
+
+// getDroplet returns the droplet with the ID
+func (p *Provider) getDroplet(ctx context.Context, id int) (*godo.Droplet, error) {
+	var droplet *godo.Droplet
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		droplet, _, err = p.client.Droplets.Get(ctx, id)
+		return err
+	})
+	return droplet, err
+}
+
+// StopServer powers off a droplet on DigitalOcean and waits until it is off
+func (p *Provider) StopServer(ctx context.Context, server *common.CreateServerResponse) error {
+	id, err := dropletID(server)
+	if err != nil {
+		return err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpStopServer, server.Name)
+	log.Info("powering off droplet")
+	if log.DryRun("droplets.actions.power_off", map[string]int{"id": id}) {
+		return log.Done(nil)
+	}
+
+	return log.Done(p.dropletAction(ctx, log, "StopServer", id, wait.Server, func(ctx context.Context) (*godo.Action, *godo.Response, error) {
+		return p.client.DropletActions.PowerOff(ctx, id)
+	}))
+}
+
+// StartServer powers on a droplet on DigitalOcean and waits until it is active
+func (p *Provider) StartServer(ctx context.Context, server *common.CreateServerResponse) (*common.CreateServerResponse, error) {
+	id, err := dropletID(server)
+	if err != nil {
+		return nil, err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpStartServer, server.Name)
+	log.Info("powering on droplet")
+	if log.DryRun("droplets.actions.power_on", map[string]int{"id": id}) {
+		return server, log.Done(nil)
+	}
+
+	err = p.dropletAction(ctx, log, "StartServer", id, wait.Server, func(ctx context.Context) (*godo.Action, *godo.Response, error) {
+		return p.client.DropletActions.PowerOn(ctx, id)
+	})
+	if err != nil {
+		return nil, log.Done(err)
+	}
+
+	droplet, err := p.getDroplet(ctx, id)
+	if err != nil {
+		return nil, log.Done(wrapErr("StartServer", err))
+	}
+	resp := serverResponse(droplet)
+	log.IPAssigned(resp.ServerIP)
+
+	return resp, log.Done(nil)
+}
+
+// RebootServer reboots a droplet on DigitalOcean and waits until the reboot completes
+func (p *Provider) RebootServer(ctx context.Context, server *common.CreateServerResponse) error {
+	id, err := dropletID(server)
+	if err != nil {
+		return err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRebootServer, server.Name)
+	log.Info("rebooting droplet")
+	if log.DryRun("droplets.actions.reboot", map[string]int{"id": id}) {
+		return log.Done(nil)
+	}
+
+	return log.Done(p.dropletAction(ctx, log, "RebootServer", id, wait.Server, func(ctx context.Context) (*godo.Action, *godo.Response, error) {
+		return p.client.DropletActions.Reboot(ctx, id)
+	}))
+}
+
+// ResizeServer changes the size of a droplet on DigitalOcean without resizing its disk,
+// so the change can be reverted. An active droplet is powered off for the change and
+// powered on again.
+func (p *Provider) ResizeServer(ctx context.Context, server *common.CreateServerResponse, size string) (*common.CreateServerResponse, error) {
+	id, err := dropletID(server)
+	if err != nil {
+		return nil, err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpResizeServer, server.Name).With("size", size)
+	log.Info("resizing droplet")
+	if log.DryRun("droplets.actions.resize", map[string]interface{}{"id": id, "size": size, "disk": false}) {
+		return server, log.Done(nil)
+	}
+
+	droplet, err := p.getDroplet(ctx, id)
+	if err != nil {
+		return nil, log.Done(wrapErr("ResizeServer", err))
+	}
+
+	active := droplet.Status == "active"
+	if active {
+		if err := p.StopServer(ctx, server); err != nil {
+			return nil, log.Done(err)
+		}
+	}
+
+	err = p.dropletAction(ctx, log, "ResizeServer", id, wait.Server, func(ctx context.Context) (*godo.Action, *godo.Response, error) {
+		return p.client.DropletActions.Resize(ctx, id, size, false)
+	})
+	if err != nil {
+		return nil, log.Done(err)
+	}
+
+	if active {
+		resp, err := p.StartServer(ctx, server)
+		return resp, log.Done(err)
+	}
+
+	droplet, err = p.getDroplet(ctx, id)
+	if err != nil {
+		return nil, log.Done(wrapErr("ResizeServer", err))
+	}
+	return serverResponse(droplet), log.Done(nil)
+}
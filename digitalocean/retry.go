@@ -0,0 +1,72 @@
+package digitalocean
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/retry"
+)
+
+// retryAfter returns the delay requested by a rate limited response
+func retryAfter(resp *http.Response) time.Duration {
+	if d := retry.After(resp.Header); d > 0 {
+		return d
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		reset, err := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64)
+		if err == nil {
+			if d := time.Until(time.Unix(reset, 0)); d > 0 {
+				return d
+			}
+		}
+	}
+
+	return 0
+}
+
+// retryable reports whether a failed idempotent call should be retried
+func retryable(err error) (bool, time.Duration) {
+	if classify(err) != common.ErrTransient {
+		return false, 0
+	}
+	return true, retryAfter(err.(*godo.ErrorResponse).Response)
+}
+
+// throttled reports whether a failed call was rejected by rate limiting before being
+// processed, so that even non-idempotent calls can be retried
+func throttled(err error) (bool, time.Duration) {
+	apiErr, ok := err.(*godo.ErrorResponse)
+	if !ok || apiErr.Response == nil || apiErr.Response.StatusCode != http.StatusTooManyRequests {
+		return false, 0
+	}
+	return true, retryAfter(apiErr.Response)
+}
+
+// call runs fn with the retry policy of the provider. Idempotent calls are retried on
+// any transient error, other calls only when rate limited.
+func (p *Provider) call(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	classifier := throttled
+	if idempotent {
+		classifier = retryable
+	}
+	return p.info.Retry.Do(ctx, func(err error) (bool, time.Duration) {
+		ok, after := classifier(err)
+		if ok {
+			p.info.Log.Warn("retrying cloud API call", "provider", "digitalocean", "error", err, "after", after)
+		}
+		return ok, after
+	}, fn)
+}
+
+// pollErr returns nil for transient errors so that polling continues through them
+func pollErr(err error) error {
+	if ok, _ := retryable(err); ok {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package digitalocean
+
+import (
+	"context"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// ImportSSHKey adds a public key to the DigitalOcean account. When adopting, a key
+// already in the account with the same fingerprint is returned instead, since an
+// account cannot hold the same key twice.
+func (p *Provider) ImportSSHKey(ctx context.Context, name string, publicKey string) (*common.ImportSSHKeyResponse, error) {
+	fingerprint, err := common.SSHFingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	keyRequest := &godo.KeyCreateRequest{
+		Name:      name,
+		PublicKey: publicKey,
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpImportSSHKey, name)
+	log.Info("adding SSH key", "fingerprint", fingerprint)
+	if log.DryRun("account.keys.create", keyRequest) {
+		return &common.ImportSSHKeyResponse{
+			Name:        name,
+			KeyID:       ref(common.KindSSHKey, 0, ""),
+			PublicKey:   publicKey,
+			Fingerprint: fingerprint,
+		}, log.Done(nil)
+	}
+
+	var key *godo.Key
+	if p.info.Adopt {
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			var err error
+			key, _, err = p.client.Keys.GetByFingerprint(ctx, fingerprint)
+			return err
+		})
+		if err != nil && classify(err) != common.ErrNotFound {
+			return nil, log.Done(wrapErr("ImportSSHKey", err))
+		}
+	}
+
+	if key != nil {
+		log.Info("adopting existing SSH key")
+	} else {
+		err = p.call(ctx, false, func(ctx context.Context) error {
+			var err error
+			key, _, err = p.client.Keys.Create(ctx, keyRequest)
+			return err
+		})
+		if err != nil {
+			return nil, log.Done(wrapErr("ImportSSHKey", err))
+		}
+	}
+	log.Started(key.ID)
+
+	return &common.ImportSSHKeyResponse{
+		Name:        key.Name,
+		KeyID:       ref(common.KindSSHKey, key.ID, ""),
+		PublicKey:   key.PublicKey,
+		Fingerprint: key.Fingerprint,
+	}, log.Done(nil)
+}
+
+// RemoveSSHKey removes a public key from the DigitalOcean account. Droplets created with
+// it keep accepting it.
+func (p *Provider) RemoveSSHKey(ctx context.Context, key *common.ImportSSHKeyResponse) error {
+	if err := key.KeyID.Check("digitalocean", common.KindSSHKey); err != nil {
+		return err
+	}
+	keyID, err := key.KeyID.IntID()
+	if err != nil {
+		return err
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRemoveSSHKey, key.Name)
+	log.Info("deleting SSH key")
+	if log.DryRun("account.keys.delete", map[string]int{"id": keyID}) {
+		return log.Done(nil)
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.Keys.DeleteByID(ctx, keyID)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveSSHKey", err))
+	}
+	log.Started(keyID)
+
+	return log.Done(nil)
+}
+
+// dropletSSHKeys returns the imported keys of a droplet request
+func dropletSSHKeys(s *common.ServerInfo) ([]godo.DropletCreateSSHKey, error) {
+	var keys []godo.DropletCreateSSHKey
+	for _, k := range s.SSHKeys {
+		if err := k.Check("digitalocean", common.KindSSHKey); err != nil {
+			return nil, err
+		}
+		id, err := k.IntID()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, godo.DropletCreateSSHKey{ID: id})
+	}
+	return keys, nil
+}
+
+// ephemeralSSHKey registers the key generated with ServerEphemeralSSHKey, if any, and
+// returns it along with its private key. It is only called for droplets being created, so
+// that adopted droplets leave no key behind.
+func (p *Provider) ephemeralSSHKey(ctx context.Context, s *common.ServerInfo) (*common.ImportSSHKeyResponse, string, error) {
+	if !s.GenerateSSHKey {
+		return &common.ImportSSHKeyResponse{}, "", nil
+	}
+
+	publicKey, privateKey, err := common.GenerateSSHKey(s.Name)
+	if err != nil {
+		return nil, "", err
+	}
+	generated, err := p.ImportSSHKey(ctx, common.SSHKeyName(s.Name), publicKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return generated, privateKey, nil
+}
+
+// removeServerKey removes the key generated for a droplet that could not be created, if any
+func (p *Provider) removeServerKey(ctx context.Context, log *common.OpLog, generated *common.ImportSSHKeyResponse) {
+	if generated.KeyID.IsZero() {
+		return
+	}
+	if err := p.RemoveSSHKey(context.WithoutCancel(ctx), generated); err != nil {
+		log.Warn("could not remove the SSH key of the failed server", "error", err)
+	}
+}
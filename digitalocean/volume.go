@@ -0,0 +1,148 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+)
+
+// createVolumes returns the volumes of a droplet create request
+func createVolumes(region string, volumes []common.Ref) ([]godo.DropletCreateVolume, error) {
+	var out []godo.DropletCreateVolume
+	for _, v := range volumes {
+		if err := v.Check("digitalocean", common.KindVolume); err != nil {
+			return nil, err
+		}
+		if len(v.Region) > 0 && v.Region != region {
+			return nil, fmt.Errorf("digitalocean: volume %s is in region %s, not %s", v.ID, v.Region, region)
+		}
+		out = append(out, godo.DropletCreateVolume{ID: v.ID})
+	}
+	return out, nil
+}
+
+// volumeAction runs an action on the volume and polls it until it completes
+func (p *Provider) volumeAction(ctx context.Context, log *common.OpLog, op string, volumeID string, action func(ctx context.Context) (*godo.Action, *godo.Response, error)) error {
+	return p.runAction(ctx, log, op, wait.Volume, action, func(ctx context.Context, actionID int) (*godo.Action, *godo.Response, error) {
+		return p.client.StorageActions.Get(ctx, volumeID, actionID)
+	})
+}
+
+// CreateVolume creates a block storage volume on DigitalOcean. Volumes are ready as
+// soon as they are created. The type is the filesystem to format the volume with, such
+// as ext4 or xfs, and the volume is left unformatted if it is empty.
+func (p *Provider) CreateVolume(ctx context.Context, name string, req *common.VolumeRequest) (*common.CreateVolumeResponse, error) {
+	var tags []string
+	if owner := p.ownerTag(); len(owner) > 0 {
+		tags = append(tags, owner)
+	}
+
+	volumeRequest := &godo.VolumeCreateRequest{
+		Region:         req.Region,
+		Name:           name,
+		SizeGigaBytes:  req.SizeGB,
+		FilesystemType: req.Type,
+		Tags:           tags,
+	}
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpCreateVolume, name).With("region", req.Region)
+	log.Info("creating volume", "size", req.SizeGB)
+	if log.DryRun("volumes.create", volumeRequest) {
+		return &common.CreateVolumeResponse{
+			Name:     name,
+			VolumeID: uuidRef(common.KindVolume, common.PlaceholderID(name), req.Region),
+			Region:   req.Region,
+			SizeGB:   req.SizeGB,
+		}, log.Done(nil)
+	}
+
+	var volume *godo.Volume
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		volume, _, err = p.client.Storage.CreateVolume(ctx, volumeRequest)
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateVolume", err))
+	}
+	log.Started(volume.ID)
+
+	return &common.CreateVolumeResponse{
+		Name:     volume.Name,
+		VolumeID: uuidRef(common.KindVolume, volume.ID, req.Region),
+		Region:   req.Region,
+		SizeGB:   volume.SizeGigaBytes,
+	}, log.Done(nil)
+}
+
+// AttachVolume attaches a volume to a droplet in the same region on DigitalOcean
+func (p *Provider) AttachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := volume.VolumeID.Check("digitalocean", common.KindVolume); err != nil {
+		return err
+	}
+	id, err := dropletID(server)
+	if err != nil {
+		return err
+	}
+	volumeID := volume.VolumeID.ID
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpAttachVolume, volume.Name).With("server", server.Name)
+	log.Info("attaching volume")
+	if log.DryRun("volumes.actions.attach", map[string]interface{}{"volume": volumeID, "droplet": id}) {
+		return log.Done(nil)
+	}
+
+	return log.Done(p.volumeAction(ctx, log, "AttachVolume", volumeID, func(ctx context.Context) (*godo.Action, *godo.Response, error) {
+		return p.client.StorageActions.Attach(ctx, volumeID, id)
+	}))
+}
+
+// DetachVolume detaches a volume from a droplet on DigitalOcean
+func (p *Provider) DetachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := volume.VolumeID.Check("digitalocean", common.KindVolume); err != nil {
+		return err
+	}
+	id, err := dropletID(server)
+	if err != nil {
+		return err
+	}
+	volumeID := volume.VolumeID.ID
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpDetachVolume, volume.Name).With("server", server.Name)
+	log.Info("detaching volume")
+	if log.DryRun("volumes.actions.detach", map[string]interface{}{"volume": volumeID, "droplet": id}) {
+		return log.Done(nil)
+	}
+
+	return log.Done(p.volumeAction(ctx, log, "DetachVolume", volumeID, func(ctx context.Context) (*godo.Action, *godo.Response, error) {
+		return p.client.StorageActions.DetachByDropletID(ctx, volumeID, id)
+	}))
+}
+
+// RemoveVolume removes a volume on DigitalOcean. The volume must not be attached.
+func (p *Provider) RemoveVolume(ctx context.Context, volume *common.CreateVolumeResponse) error {
+	if err := volume.VolumeID.Check("digitalocean", common.KindVolume); err != nil {
+		return err
+	}
+	volumeID := volume.VolumeID.ID
+
+	log := p.info.StartOp(ctx, "digitalocean", common.OpRemoveVolume, volume.Name)
+	log.Info("deleting volume")
+	if log.DryRun("volumes.delete", map[string]string{"id": volumeID}) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.client.Storage.DeleteVolume(ctx, volumeID)
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveVolume", err))
+	}
+	log.Started(volumeID)
+
+	return log.Done(nil)
+}
@@ -165,36 +165,33 @@ func main() {
 
 	ctx := context.TODO()
 
-	fmt.Println("Acquiring global static IP")
-	ipResp, err := p.CreateStaticIP(ctx, clusterName, &common.StaticIPRequest{IPType: common.GLOBAL, Region: "us-east1"})
-	if err != nil {
+	var ipResp *common.CreateStaticIPResponse
+	var dnsResp *common.CreateDNSRecordResponse
+	var k8sResp *common.CreateK8sResponse
+
+	fmt.Println("Acquiring global static IP, creating DNS record and cluster")
+	tx := cpt.NewTransaction(
+		cpt.StaticIPStep(p, clusterName, &common.StaticIPRequest{IPType: common.GLOBAL, Region: "us-east1"}, &ipResp),
+		cpt.DNSRecordStep(p, subDomain, func() string { return ipResp.StaticIP }, &dnsResp),
+		cpt.K8sStep(
+			p,
+			clusterName,
+			&k8sResp,
+			common.ServerRegion("us-east1-c"),
+			common.ServerSize("n1-standard-4"),
+			common.AutoScale(&common.AutoScaleOpt{
+				Enabled:  true,
+				MinNodes: 3,
+				MaxNodes: 10,
+			}),
+			common.K8sVersion("1.10.6-gke.6"),
+		),
+	)
+	if err := tx.Run(ctx); err != nil {
 		panic(err)
 	}
 	fmt.Println(ipResp)
-
-	fmt.Println("Creating DNS record")
-	dnsResp, err := p.CreateDNSRecord(ctx, subDomain, ipResp.StaticIP)
-	if err != nil {
-		panic(err)
-	}
 	fmt.Println(dnsResp)
-
-	fmt.Println("Creating Cluster")
-	k8sResp, err := p.CreateK8s(
-		ctx,
-		clusterName,
-		common.ServerRegion("us-east1-c"),
-		common.ServerSize("n1-standard-4"),
-		common.AutoScale(&common.AutoScaleOpt{
-			Enabled:  true,
-			MinNodes: 3,
-			MaxNodes: 10,
-		}),
-		common.K8sVersion("1.10.6-gke.6"),
-	)
-	if err != nil {
-		panic(err)
-	}
 	fmt.Println(k8sResp)
 	fmt.Println(k8sResp.Credentials)
 
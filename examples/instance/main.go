@@ -156,7 +156,10 @@ func main() {
 		common.ServerRegion("us-east1-c"),
 		common.ServerSize("n1-highcpu-4"),
 		common.ServerUserData(configBuf.String()),
-		common.ServerTags([]string{"http-server", "https-server", "face-recognition"}),
+		common.ServerTags([]string{"face-recognition"}),
+		common.ServerFirewall(
+			common.FirewallRule{Protocol: "tcp", Ports: []string{"80", "443", "1935", "10001"}},
+		),
 	)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,635 @@
+// Package fake implements an in-memory cloud provider for testing code built on cpt
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+var _ cpt.CloudProvider = (*Provider)(nil)
+
+// Op names a provider operation for scripting failures and latencies
+type Op = common.Operation
+
+// Operations of the fake provider
+const (
+	CreateServer      = common.OpCreateServer
+	RemoveServer      = common.OpRemoveServer
+	CreateServerGroup = common.OpCreateServerGroup
+	RemoveServerGroup = common.OpRemoveServerGroup
+	CreateK8s         = common.OpCreateK8s
+	RemoveK8s         = common.OpRemoveK8s
+	CreateDNSRecord   = common.OpCreateDNSRecord
+	RemoveDNSRecord   = common.OpRemoveDNSRecord
+	CreateStaticIP    = common.OpCreateStaticIP
+	RemoveStaticIP    = common.OpRemoveStaticIP
+	ListServers       = common.OpListServers
+	GetServer         = common.OpGetServer
+	ListServerGroups  = common.OpListServerGroups
+	ListK8s           = common.OpListK8s
+	GetK8s            = common.OpGetK8s
+	ListDNSRecords    = common.OpListDNSRecords
+	ListStaticIPs     = common.OpListStaticIPs
+	StopServer        = common.OpStopServer
+	StartServer       = common.OpStartServer
+	RebootServer      = common.OpRebootServer
+	ResizeServer      = common.OpResizeServer
+	CreateImage       = common.OpCreateImage
+	ListImages        = common.OpListImages
+	RemoveImage       = common.OpRemoveImage
+	CreateVolume      = common.OpCreateVolume
+	AttachVolume      = common.OpAttachVolume
+	DetachVolume      = common.OpDetachVolume
+	RemoveVolume      = common.OpRemoveVolume
+	CreateFirewall    = common.OpCreateFirewall
+	RemoveFirewall    = common.OpRemoveFirewall
+	ImportSSHKey      = common.OpImportSSHKey
+	RemoveSSHKey      = common.OpRemoveSSHKey
+	CreateNetwork     = common.OpCreateNetwork
+	RemoveNetwork     = common.OpRemoveNetwork
+)
+
+// Provider implements cpt.CloudProvider entirely in memory
+type Provider struct {
+	mu sync.Mutex
+
+	nextID int
+	nextIP int
+
+	servers   map[string]*common.CreateServerResponse
+	groups    map[string]*common.CreateServerGroupResponse
+	clusters  map[string]*common.CreateK8sResponse
+	records   map[string]*common.CreateDNSRecordResponse
+	staticIPs map[string]*common.CreateStaticIPResponse
+	images    map[string]*common.CreateImageResponse
+	volumes   map[string]*common.CreateVolumeResponse
+	firewalls map[string]*common.CreateFirewallResponse
+	sshKeys   map[string]*common.ImportSSHKeyResponse
+	networks  map[string]*common.CreateNetworkResponse
+
+	// tags maps server, server group and cluster IDs to their tags
+	tags map[string][]string
+	// sizes maps server IDs to their size
+	sizes map[string]string
+	// stopped holds the IDs of stopped servers
+	stopped map[string]bool
+	// attached maps volume IDs to the ID of the server they are attached to
+	attached map[string]string
+
+	failures  map[Op][]error
+	partials  map[Op][]error
+	latencies map[Op]time.Duration
+	calls     map[Op]int
+
+	capabilities *common.Capabilities
+}
+
+// NewProvider returns a new Provider instance with no live resources
+func NewProvider() *Provider {
+	return &Provider{
+		servers:   make(map[string]*common.CreateServerResponse),
+		groups:    make(map[string]*common.CreateServerGroupResponse),
+		clusters:  make(map[string]*common.CreateK8sResponse),
+		records:   make(map[string]*common.CreateDNSRecordResponse),
+		staticIPs: make(map[string]*common.CreateStaticIPResponse),
+		images:    make(map[string]*common.CreateImageResponse),
+		volumes:   make(map[string]*common.CreateVolumeResponse),
+		firewalls: make(map[string]*common.CreateFirewallResponse),
+		sshKeys:   make(map[string]*common.ImportSSHKeyResponse),
+		networks:  make(map[string]*common.CreateNetworkResponse),
+		tags:      make(map[string][]string),
+		sizes:     make(map[string]string),
+		stopped:   make(map[string]bool),
+		attached:  make(map[string]string),
+		failures:  make(map[Op][]error),
+		partials:  make(map[Op][]error),
+		latencies: make(map[Op]time.Duration),
+		calls:     make(map[Op]int),
+	}
+}
+
+// allOptions lists every ServerOption defined in common
+var allOptions = []common.OptionName{
+	common.OptRegion, common.OptSize, common.OptImage, common.OptUserData,
+	common.OptTags, common.OptAutoScale, common.OptK8sVersion, common.OptExpires,
+	common.OptVolumes, common.OptFirewall, common.OptSSHKeys, common.OptNetwork,
+}
+
+// Capabilities returns the capabilities set with SetCapabilities, or by default
+// every operation with every option
+func (p *Provider) Capabilities() *common.Capabilities {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.capabilities != nil {
+		return p.capabilities
+	}
+
+	return &common.Capabilities{
+		Provider: "fake",
+		Operations: map[common.Operation][]common.OptionName{
+			CreateServer:      allOptions,
+			RemoveServer:      nil,
+			CreateServerGroup: allOptions,
+			RemoveServerGroup: nil,
+			CreateK8s:         allOptions,
+			RemoveK8s:         nil,
+			CreateDNSRecord:   nil,
+			RemoveDNSRecord:   nil,
+			CreateStaticIP:    nil,
+			RemoveStaticIP:    nil,
+			ListServers:       nil,
+			GetServer:         nil,
+			ListServerGroups:  nil,
+			ListK8s:           nil,
+			GetK8s:            nil,
+			ListDNSRecords:    nil,
+			ListStaticIPs:     nil,
+			StopServer:        nil,
+			StartServer:       nil,
+			RebootServer:      nil,
+			ResizeServer:      nil,
+			CreateImage:       nil,
+			ListImages:        nil,
+			RemoveImage:       nil,
+			CreateVolume:      nil,
+			AttachVolume:      nil,
+			DetachVolume:      nil,
+			RemoveVolume:      nil,
+			CreateFirewall:    nil,
+			RemoveFirewall:    nil,
+			ImportSSHKey:      nil,
+			RemoveSSHKey:      nil,
+			CreateNetwork:     nil,
+			RemoveNetwork:     nil,
+		},
+		StaticIPTypes: []common.StaticIPType{common.GLOBAL, common.REGIONAL},
+	}
+}
+
+// SetCapabilities overrides the capabilities reported by the provider, to mimic a
+// real provider in tests. It does not change which operations succeed.
+func (p *Provider) SetCapabilities(c *common.Capabilities) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.capabilities = c
+}
+
+// FailNext scripts the next len(errs) calls to op to fail with the given errors, in order.
+// A nil entry lets the corresponding call succeed.
+func (p *Provider) FailNext(op Op, errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures[op] = append(p.failures[op], errs...)
+}
+
+// FailAfter scripts the next len(errs) calls to op that create a resource to return it
+// along with the given errors, in order, like a provider whose resource was created but
+// failed to become ready. A nil entry lets the corresponding call succeed.
+func (p *Provider) FailAfter(op Op, errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.partials[op] = append(p.partials[op], errs...)
+}
+
+// SetLatency makes every call to op block for d, or until its context is done
+func (p *Provider) SetLatency(op Op, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latencies[op] = d
+}
+
+// Calls returns the number of times op has been called
+func (p *Provider) Calls(op Op) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[op]
+}
+
+// begin records a call to op, applies its scripted latency and returns its scripted failure.
+// On success it returns with p.mu held; the caller must unlock it.
+func (p *Provider) begin(ctx context.Context, op Op) error {
+	p.mu.Lock()
+	p.calls[op]++
+	latency := p.latencies[op]
+	var err error
+	if q := p.failures[op]; len(q) > 0 {
+		err = q[0]
+		p.failures[op] = q[1:]
+	}
+	p.mu.Unlock()
+
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	return nil
+}
+
+// partial returns the error scripted by FailAfter for a call to op that created a
+// resource; p.mu must be held
+func (p *Provider) partial(op Op) error {
+	q := p.partials[op]
+	if len(q) == 0 {
+		return nil
+	}
+	p.partials[op] = q[1:]
+	return q[0]
+}
+
+// id allocates a synthetic resource ID; p.mu must be held
+func (p *Provider) id(kind string) string {
+	p.nextID++
+	return fmt.Sprintf("fake-%s-%d", kind, p.nextID)
+}
+
+// ref returns the reference to an in-memory resource
+func ref(kind common.Kind, id string, region string) common.Ref {
+	return common.Ref{
+		Provider: "fake",
+		Kind:     kind,
+		ID:       id,
+		Region:   region,
+	}
+}
+
+// ip allocates a synthetic IPv4 address from 10.0.0.0/8; p.mu must be held
+func (p *Provider) ip() string {
+	p.nextIP++
+	n := p.nextIP
+	return fmt.Sprintf("10.%d.%d.%d", (n>>16)&0xff, (n>>8)&0xff, n&0xff)
+}
+
+func serverInfo(name string, opts []common.ServerOption) (*common.ServerInfo, error) {
+	s := &common.ServerInfo{
+		Name: name,
+	}
+
+	for _, opt := range opts {
+		if err := opt.Set(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// CreateServer creates an in-memory server
+func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerResponse, error) {
+	s, err := serverInfo(name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var publicKey, privateKey, fingerprint string
+	if s.GenerateSSHKey {
+		if publicKey, privateKey, err = common.GenerateSSHKey(name); err != nil {
+			return nil, err
+		}
+		fingerprint, _ = common.SSHFingerprint(publicKey)
+	}
+
+	if err := p.begin(ctx, CreateServer); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	for _, v := range s.Volumes {
+		if err := p.volume(CreateServer, v, s.Region); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.network(CreateServer, s.Network); err != nil {
+		return nil, err
+	}
+	for _, k := range s.SSHKeys {
+		if _, ok := p.sshKeys[k.ID]; !ok || k.Check("fake", common.KindSSHKey) != nil {
+			return nil, common.NewError("fake", string(CreateServer), common.ErrNotFound, fmt.Errorf("ssh key %v", k))
+		}
+	}
+
+	id := p.id("server")
+	resp := &common.CreateServerResponse{
+		Name:         name,
+		ServerID:     ref(common.KindServer, id, s.Region),
+		ServerRegion: s.Region,
+		ServerIP:     p.ip(),
+		Expires:      s.Expires,
+	}
+	p.servers[id] = resp
+	p.tags[id] = s.Tags
+	p.sizes[id] = s.Size
+	for _, v := range s.Volumes {
+		p.attached[v.ID] = id
+	}
+	if len(s.Firewall) > 0 {
+		resp.FirewallID = p.firewall(common.FirewallName(name), nil).FirewallID
+	}
+	if s.GenerateSSHKey {
+		resp.SSHKeyID = p.sshKey(common.SSHKeyName(name), publicKey, fingerprint).KeyID
+	}
+
+	copied := *resp
+	copied.SSHPrivateKey = privateKey
+	return &copied, p.partial(CreateServer)
+}
+
+// RemoveServer removes an in-memory server
+func (p *Provider) RemoveServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := p.begin(ctx, RemoveServer); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := server.ServerID.ID
+	if _, ok := p.servers[id]; !ok || server.ServerID.Check("fake", common.KindServer) != nil {
+		return common.NewError("fake", string(RemoveServer), common.ErrNotFound, fmt.Errorf("server %v", server.ServerID))
+	}
+	delete(p.firewalls, p.servers[id].FirewallID.ID)
+	delete(p.sshKeys, p.servers[id].SSHKeyID.ID)
+	delete(p.servers, id)
+	delete(p.tags, id)
+	delete(p.sizes, id)
+	delete(p.stopped, id)
+	for volumeID, serverID := range p.attached {
+		if serverID == id {
+			delete(p.attached, volumeID)
+		}
+	}
+
+	return nil
+}
+
+// CreateServerGroup creates an in-memory server group
+func (p *Provider) CreateServerGroup(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerGroupResponse, error) {
+	s, err := serverInfo(name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.begin(ctx, CreateServerGroup); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	id := p.id("group")
+	resp := &common.CreateServerGroupResponse{
+		Name:              name,
+		ServerGroupID:     ref(common.KindServerGroup, id, s.Region),
+		ServerGroupRegion: s.Region,
+		LoadBalancerID:    p.id("lb"),
+		LoadBalancerIP:    p.ip(),
+	}
+	p.groups[id] = resp
+	p.tags[id] = s.Tags
+
+	copied := *resp
+	return &copied, p.partial(CreateServerGroup)
+}
+
+// RemoveServerGroup removes an in-memory server group
+func (p *Provider) RemoveServerGroup(ctx context.Context, group *common.CreateServerGroupResponse) error {
+	if err := p.begin(ctx, RemoveServerGroup); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := group.ServerGroupID.ID
+	if _, ok := p.groups[id]; !ok || group.ServerGroupID.Check("fake", common.KindServerGroup) != nil {
+		return common.NewError("fake", string(RemoveServerGroup), common.ErrNotFound, fmt.Errorf("server group %v", group.ServerGroupID))
+	}
+	delete(p.groups, id)
+	delete(p.tags, id)
+
+	return nil
+}
+
+// CreateK8s creates an in-memory cluster
+func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateK8sResponse, error) {
+	s, err := serverInfo(name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.begin(ctx, CreateK8s); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	if err := p.network(CreateK8s, s.Network); err != nil {
+		return nil, err
+	}
+
+	id := p.id("k8s")
+	resp := &common.CreateK8sResponse{
+		Name:          name,
+		ClusterID:     ref(common.KindK8s, id, s.Region),
+		ClusterRegion: s.Region,
+		EndpointIP:    p.ip(),
+		EndpointPort:  "443",
+		Credentials: &common.ClusterCredentials{
+			Username: "admin",
+			Password: id,
+		},
+		Expires: s.Expires,
+	}
+	p.clusters[id] = resp
+	p.tags[id] = s.Tags
+
+	copied := *resp
+	creds := *resp.Credentials
+	copied.Credentials = &creds
+	return &copied, p.partial(CreateK8s)
+}
+
+// RemoveK8s removes an in-memory cluster
+func (p *Provider) RemoveK8s(ctx context.Context, k8s *common.CreateK8sResponse) error {
+	if err := p.begin(ctx, RemoveK8s); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := k8s.ClusterID.ID
+	if _, ok := p.clusters[id]; !ok || k8s.ClusterID.Check("fake", common.KindK8s) != nil {
+		return common.NewError("fake", string(RemoveK8s), common.ErrNotFound, fmt.Errorf("cluster %v", k8s.ClusterID))
+	}
+	delete(p.clusters, id)
+	delete(p.tags, id)
+
+	return nil
+}
+
+// CreateDNSRecord creates an in-memory DNS A Record
+func (p *Provider) CreateDNSRecord(ctx context.Context, subDomain string, IP string) (*common.CreateDNSRecordResponse, error) {
+	if err := p.begin(ctx, CreateDNSRecord); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	for _, r := range p.records {
+		if r.SubDomain == subDomain {
+			return nil, common.NewError("fake", string(CreateDNSRecord), common.ErrAlreadyExists, fmt.Errorf("DNS record %v", subDomain))
+		}
+	}
+
+	id := p.id("dns")
+	resp := &common.CreateDNSRecordResponse{
+		SubDomain:   subDomain,
+		SubDomainID: ref(common.KindDNSRecord, id, ""),
+		SubDomainIP: IP,
+	}
+	p.records[id] = resp
+
+	copied := *resp
+	return &copied, p.partial(CreateDNSRecord)
+}
+
+// RemoveDNSRecord removes an in-memory DNS A Record
+func (p *Provider) RemoveDNSRecord(ctx context.Context, subDomain *common.CreateDNSRecordResponse) error {
+	if err := p.begin(ctx, RemoveDNSRecord); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := subDomain.SubDomainID.ID
+	if _, ok := p.records[id]; !ok || subDomain.SubDomainID.Check("fake", common.KindDNSRecord) != nil {
+		return common.NewError("fake", string(RemoveDNSRecord), common.ErrNotFound, fmt.Errorf("DNS record %v", subDomain.SubDomainID))
+	}
+	delete(p.records, id)
+
+	return nil
+}
+
+// CreateStaticIP creates an in-memory static IP. Like GCE, static IPs are identified by name.
+func (p *Provider) CreateStaticIP(ctx context.Context, name string, req *common.StaticIPRequest) (*common.CreateStaticIPResponse, error) {
+	switch req.IPType {
+	case common.GLOBAL, common.REGIONAL:
+	default:
+		return nil, fmt.Errorf("Static IP Type: %v is not supported", req.IPType)
+	}
+
+	if err := p.begin(ctx, CreateStaticIP); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	if _, ok := p.staticIPs[name]; ok {
+		return nil, common.NewError("fake", string(CreateStaticIP), common.ErrAlreadyExists, fmt.Errorf("static IP %v", name))
+	}
+
+	resp := &common.CreateStaticIPResponse{
+		Name:     name,
+		StaticIP: p.ip(),
+		Type:     req.IPType,
+		Region:   req.Region,
+		Expires:  req.Expires,
+	}
+	p.staticIPs[name] = resp
+
+	copied := *resp
+	return &copied, p.partial(CreateStaticIP)
+}
+
+// RemoveStaticIP removes an in-memory static IP
+func (p *Provider) RemoveStaticIP(ctx context.Context, staticIP *common.CreateStaticIPResponse) error {
+	if err := p.begin(ctx, RemoveStaticIP); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	if _, ok := p.staticIPs[staticIP.Name]; !ok {
+		return common.NewError("fake", string(RemoveStaticIP), common.ErrNotFound, fmt.Errorf("static IP %v", staticIP.Name))
+	}
+	delete(p.staticIPs, staticIP.Name)
+
+	return nil
+}
+
+// Servers returns copies of the live servers ordered by name
+func (p *Provider) Servers() []*common.CreateServerResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]*common.CreateServerResponse, 0, len(p.servers))
+	for _, r := range p.servers {
+		copied := *r
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ServerGroups returns copies of the live server groups ordered by name
+func (p *Provider) ServerGroups() []*common.CreateServerGroupResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]*common.CreateServerGroupResponse, 0, len(p.groups))
+	for _, r := range p.groups {
+		copied := *r
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Clusters returns copies of the live clusters ordered by name
+func (p *Provider) Clusters() []*common.CreateK8sResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]*common.CreateK8sResponse, 0, len(p.clusters))
+	for _, r := range p.clusters {
+		copied := *r
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// DNSRecords returns copies of the live DNS records ordered by subdomain
+func (p *Provider) DNSRecords() []*common.CreateDNSRecordResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]*common.CreateDNSRecordResponse, 0, len(p.records))
+	for _, r := range p.records {
+		copied := *r
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SubDomain < out[j].SubDomain })
+	return out
+}
+
+// StaticIPs returns copies of the live static IPs ordered by name
+func (p *Provider) StaticIPs() []*common.CreateStaticIPResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]*common.CreateStaticIPResponse, 0, len(p.staticIPs))
+	for _, r := range p.staticIPs {
+		copied := *r
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
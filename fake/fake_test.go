@@ -0,0 +1,363 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/fake"
+)
+
+// resource creates and removes resources of one kind
+type resource struct {
+	kind   string
+	create fake.Op
+	// add creates the named resource and returns its IP and a func removing it
+	add  func(ctx context.Context, p *fake.Provider, name string) (ip string, remove func() error, err error)
+	live func(p *fake.Provider) []string
+}
+
+// recordIPs are the IPs the DNS records created by TestResources point to
+var recordIPs = map[string]string{"web": "192.0.2.1", "api": "192.0.2.2"}
+
+var resources = []resource{
+	{
+		kind:   "server",
+		create: fake.CreateServer,
+		add: func(ctx context.Context, p *fake.Provider, name string) (string, func() error, error) {
+			resp, err := p.CreateServer(ctx, name)
+			if resp == nil {
+				return "", nil, err
+			}
+			return resp.ServerIP, func() error { return p.RemoveServer(ctx, resp) }, err
+		},
+		live: func(p *fake.Provider) (out []string) {
+			for _, r := range p.Servers() {
+				out = append(out, r.Name+" "+r.ServerIP)
+			}
+			return out
+		},
+	},
+	{
+		kind:   "server group",
+		create: fake.CreateServerGroup,
+		add: func(ctx context.Context, p *fake.Provider, name string) (string, func() error, error) {
+			resp, err := p.CreateServerGroup(ctx, name)
+			if resp == nil {
+				return "", nil, err
+			}
+			return resp.LoadBalancerIP, func() error { return p.RemoveServerGroup(ctx, resp) }, err
+		},
+		live: func(p *fake.Provider) (out []string) {
+			for _, r := range p.ServerGroups() {
+				out = append(out, r.Name+" "+r.LoadBalancerIP)
+			}
+			return out
+		},
+	},
+	{
+		kind:   "cluster",
+		create: fake.CreateK8s,
+		add: func(ctx context.Context, p *fake.Provider, name string) (string, func() error, error) {
+			resp, err := p.CreateK8s(ctx, name)
+			if resp == nil {
+				return "", nil, err
+			}
+			return resp.EndpointIP, func() error { return p.RemoveK8s(ctx, resp) }, err
+		},
+		live: func(p *fake.Provider) (out []string) {
+			for _, r := range p.Clusters() {
+				out = append(out, r.Name+" "+r.EndpointIP)
+			}
+			return out
+		},
+	},
+	{
+		kind:   "DNS record",
+		create: fake.CreateDNSRecord,
+		add: func(ctx context.Context, p *fake.Provider, name string) (string, func() error, error) {
+			resp, err := p.CreateDNSRecord(ctx, name, recordIPs[name])
+			if resp == nil {
+				return "", nil, err
+			}
+			return resp.SubDomainIP, func() error { return p.RemoveDNSRecord(ctx, resp) }, err
+		},
+		live: func(p *fake.Provider) (out []string) {
+			for _, r := range p.DNSRecords() {
+				out = append(out, r.SubDomain+" "+r.SubDomainIP)
+			}
+			return out
+		},
+	},
+	{
+		kind:   "static IP",
+		create: fake.CreateStaticIP,
+		add: func(ctx context.Context, p *fake.Provider, name string) (string, func() error, error) {
+			resp, err := p.CreateStaticIP(ctx, name, &common.StaticIPRequest{IPType: common.GLOBAL})
+			if resp == nil {
+				return "", nil, err
+			}
+			return resp.StaticIP, func() error { return p.RemoveStaticIP(ctx, resp) }, err
+		},
+		live: func(p *fake.Provider) (out []string) {
+			for _, r := range p.StaticIPs() {
+				out = append(out, r.Name+" "+r.StaticIP)
+			}
+			return out
+		},
+	},
+}
+
+func TestResources(t *testing.T) {
+	ctx := context.Background()
+
+	for _, r := range resources {
+		t.Run(r.kind, func(t *testing.T) {
+			p := fake.NewProvider()
+
+			ip, remove, err := r.add(ctx, p, "web")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if live := r.live(p); len(live) != 1 || live[0] != "web "+ip {
+				t.Fatalf("live %ss = %v, want [web %s]", r.kind, live, ip)
+			}
+
+			// names are unique for DNS records and static IPs only, so a second
+			// resource is named differently
+			ip2, remove2, err := r.add(ctx, p, "api")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ip2 == ip {
+				t.Fatalf("second %s got the same IP %s", r.kind, ip)
+			}
+			if live := r.live(p); len(live) != 2 || live[0] != "api "+ip2 {
+				t.Fatalf("live %ss = %v, want them ordered by name", r.kind, live)
+			}
+
+			if err := remove(); err != nil {
+				t.Fatal(err)
+			}
+			if err := remove(); err == nil {
+				t.Fatalf("removing a removed %s succeeded", r.kind)
+			}
+			if err := remove2(); err != nil {
+				t.Fatal(err)
+			}
+			if live := r.live(p); len(live) != 0 {
+				t.Fatalf("live %ss = %v after removal, want none", r.kind, live)
+			}
+		})
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	errDenied := errors.New("permission denied")
+
+	tests := []struct {
+		name     string
+		failures []error
+		// want are the errors of three consecutive calls
+		want []error
+	}{
+		{"none", nil, []error{nil, nil, nil}},
+		{"first", []error{errQuota}, []error{errQuota, nil, nil}},
+		{"in order", []error{errQuota, errDenied}, []error{errQuota, errDenied, nil}},
+		{"nil entry", []error{nil, errDenied}, []error{nil, errDenied, nil}},
+	}
+
+	ctx := context.Background()
+	for _, r := range resources {
+		for _, tt := range tests {
+			t.Run(r.kind+"/"+tt.name, func(t *testing.T) {
+				p := fake.NewProvider()
+				p.FailNext(r.create, tt.failures...)
+
+				var created int
+				for i, want := range tt.want {
+					_, _, err := r.add(ctx, p, string(rune('a'+i)))
+					if err != want {
+						t.Fatalf("call %d error = %v, want %v", i+1, err, want)
+					}
+					if err == nil {
+						created++
+					}
+				}
+				if live := r.live(p); len(live) != created {
+					t.Fatalf("%d %ss live, want %d", len(live), r.kind, created)
+				}
+				if calls := p.Calls(r.create); calls != len(tt.want) {
+					t.Fatalf("%s called %d times, want %d", r.create, calls, len(tt.want))
+				}
+			})
+		}
+	}
+}
+
+func TestFailAfter(t *testing.T) {
+	errSetup := errors.New("did not become ready")
+	ctx := context.Background()
+
+	for _, r := range resources {
+		t.Run(r.kind, func(t *testing.T) {
+			p := fake.NewProvider()
+			// a call failing with FailNext creates nothing and leaves the partial failure
+			// to the next call
+			p.FailNext(r.create, errors.New("quota exceeded"))
+			p.FailAfter(r.create, errSetup)
+
+			if _, remove, err := r.add(ctx, p, "a"); err == nil || remove != nil {
+				t.Fatalf("first call error = %v, want the FailNext error and nothing created", err)
+			}
+
+			ip, remove, err := r.add(ctx, p, "b")
+			if err != errSetup || remove == nil {
+				t.Fatalf("second call error = %v, want %v with the created %s", err, errSetup, r.kind)
+			}
+			if live := r.live(p); len(live) != 1 || live[0] != "b "+ip {
+				t.Fatalf("live %ss = %v, want [b %s]", r.kind, live, ip)
+			}
+			if err := remove(); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, _, err := r.add(ctx, p, "c"); err != nil {
+				t.Fatalf("third call error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestSetLatency(t *testing.T) {
+	tests := []struct {
+		name     string
+		latency  time.Duration
+		timeout  time.Duration
+		wantErr  error
+		wantLive int
+	}{
+		{"within timeout", time.Millisecond, time.Minute, nil, 1},
+		{"timed out", time.Minute, 20 * time.Millisecond, context.DeadlineExceeded, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := fake.NewProvider()
+			p.SetLatency(fake.CreateServer, tt.latency)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err := p.CreateServer(ctx, "web")
+			if err != tt.wantErr {
+				t.Fatalf("CreateServer() error = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed < tt.latency && elapsed < tt.timeout {
+				t.Fatalf("CreateServer() returned after %v, want at least %v", elapsed, tt.latency)
+			}
+			if live := len(p.Servers()); live != tt.wantLive {
+				t.Fatalf("%d servers live, want %d", live, tt.wantLive)
+			}
+		})
+	}
+}
+
+// listed creates servers to list and returns the provider
+func listed(t *testing.T) *fake.Provider {
+	servers := []struct {
+		name   string
+		region string
+		tags   []string
+	}{
+		{"web-1", "r1", []string{"env=prod", "web"}},
+		{"web-2", "r2", []string{"env=dev"}},
+		{"db-1", "r1", []string{"env=prod"}},
+	}
+
+	p := fake.NewProvider()
+	for _, s := range servers {
+		if _, err := p.CreateServer(context.Background(), s.name, common.ServerRegion(s.region), common.ServerTags(s.tags)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+func TestListServers(t *testing.T) {
+	errDenied := errors.New("permission denied")
+
+	tests := []struct {
+		name    string
+		opts    []common.ListOption
+		failure error
+		want    []string
+	}{
+		{"all", nil, nil, []string{"db-1", "web-1", "web-2"}},
+		{"prefix", []common.ListOption{common.ListPrefix("web")}, nil, []string{"web-1", "web-2"}},
+		{"key value tag", []common.ListOption{common.ListTags("env=prod")}, nil, []string{"db-1", "web-1"}},
+		{"key tag", []common.ListOption{common.ListTags("env")}, nil, []string{"db-1", "web-1", "web-2"}},
+		{"every tag", []common.ListOption{common.ListTags("env=prod", "web")}, nil, []string{"web-1"}},
+		{"region", []common.ListOption{common.ListRegion("r2")}, nil, []string{"web-2"}},
+		{"prefix and region", []common.ListOption{common.ListPrefix("web"), common.ListRegion("r1")}, nil, []string{"web-1"}},
+		{"no match", []common.ListOption{common.ListPrefix("api")}, nil, nil},
+		{"failure", nil, errDenied, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := listed(t)
+			p.FailNext(fake.ListServers, tt.failure)
+
+			servers, err := p.ListServers(context.Background(), tt.opts...)
+			if err != tt.failure {
+				t.Fatalf("ListServers() error = %v, want %v", err, tt.failure)
+			}
+			var got []string
+			for _, s := range servers {
+				got = append(got, s.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListServers() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("ListServers() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  string
+		opts    []common.ListOption
+		wantErr error
+	}{
+		{"found", "web-2", nil, nil},
+		{"found in region", "web-2", []common.ListOption{common.ListRegion("r2")}, nil},
+		{"other region", "web-2", []common.ListOption{common.ListRegion("r1")}, common.ErrNotFound},
+		{"missing tag", "web-2", []common.ListOption{common.ListTags("web")}, common.ErrNotFound},
+		{"unknown", "api-1", nil, common.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := listed(t)
+
+			s, err := p.GetServer(context.Background(), tt.server, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetServer() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s.Name != tt.server {
+				t.Fatalf("GetServer() = %s, want %s", s.Name, tt.server)
+			}
+		})
+	}
+}
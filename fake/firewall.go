@@ -0,0 +1,51 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// firewall adds an in-memory firewall; p.mu must be held
+func (p *Provider) firewall(name string, targetTags []string) *common.CreateFirewallResponse {
+	id := p.id("firewall")
+	resp := &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: ref(common.KindFirewall, id, ""),
+		TargetTags: targetTags,
+	}
+	p.firewalls[id] = resp
+	return resp
+}
+
+// CreateFirewall creates an in-memory firewall
+func (p *Provider) CreateFirewall(ctx context.Context, name string, req *common.FirewallRequest) (*common.CreateFirewallResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := p.begin(ctx, CreateFirewall); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	copied := *p.firewall(name, req.TargetTags)
+	return &copied, p.partial(CreateFirewall)
+}
+
+// RemoveFirewall removes an in-memory firewall
+func (p *Provider) RemoveFirewall(ctx context.Context, firewall *common.CreateFirewallResponse) error {
+	if err := p.begin(ctx, RemoveFirewall); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := firewall.FirewallID.ID
+	if _, ok := p.firewalls[id]; !ok || firewall.FirewallID.Check("fake", common.KindFirewall) != nil {
+		return common.NewError("fake", string(RemoveFirewall), common.ErrNotFound, fmt.Errorf("firewall %v", firewall.FirewallID))
+	}
+	delete(p.firewalls, id)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// CreateImageFromServer creates an in-memory image of an in-memory server
+func (p *Provider) CreateImageFromServer(ctx context.Context, server *common.CreateServerResponse, name string) (*common.CreateImageResponse, error) {
+	if err := p.begin(ctx, CreateImage); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	if _, err := p.server(CreateImage, server); err != nil {
+		return nil, err
+	}
+
+	id := p.id("image")
+	resp := &common.CreateImageResponse{
+		Name:    name,
+		ImageID: ref(common.KindImage, id, ""),
+		Image:   id,
+		Source:  server.Name,
+	}
+	p.images[id] = resp
+
+	copied := *resp
+	return &copied, p.partial(CreateImage)
+}
+
+// ListImages lists the in-memory images ordered by name. Images are global and carry
+// no tags.
+func (p *Provider) ListImages(ctx context.Context, opts ...common.ListOption) ([]*common.CreateImageResponse, error) {
+	l, err := p.listInfo(ctx, ListImages, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	var out []*common.CreateImageResponse
+	for _, img := range p.images {
+		if l.Match(img.Name, nil) {
+			copied := *img
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// RemoveImage removes an in-memory image
+func (p *Provider) RemoveImage(ctx context.Context, image *common.CreateImageResponse) error {
+	if err := p.begin(ctx, RemoveImage); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := image.ImageID.ID
+	if _, ok := p.images[id]; !ok || image.ImageID.Check("fake", common.KindImage) != nil {
+		return common.NewError("fake", string(RemoveImage), common.ErrNotFound, fmt.Errorf("image %v", image.ImageID))
+	}
+	delete(p.images, id)
+
+	return nil
+}
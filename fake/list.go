@@ -0,0 +1,162 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// listInfo applies opts and begins a call to op. On success it returns with p.mu held;
+// the caller must unlock it.
+func (p *Provider) listInfo(ctx context.Context, op Op, opts []common.ListOption) (*common.ListInfo, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.begin(ctx, op); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// match reports whether a resource in the region passes the filters of l
+func match(l *common.ListInfo, name string, region string, tags []string) bool {
+	return (len(l.Region) == 0 || region == l.Region) && l.Match(name, tags)
+}
+
+// ListServers lists the in-memory servers ordered by name
+func (p *Provider) ListServers(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerResponse, error) {
+	l, err := p.listInfo(ctx, ListServers, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	var out []*common.CreateServerResponse
+	for id, r := range p.servers {
+		if match(l, r.Name, r.ServerRegion, p.tags[id]) {
+			copied := *r
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// GetServer returns the in-memory server with the name
+func (p *Provider) GetServer(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateServerResponse, error) {
+	l, err := p.listInfo(ctx, GetServer, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	for id, r := range p.servers {
+		if r.Name == name && match(l, r.Name, r.ServerRegion, p.tags[id]) {
+			copied := *r
+			return &copied, nil
+		}
+	}
+	return nil, common.NewError("fake", string(GetServer), common.ErrNotFound, fmt.Errorf("server %v", name))
+}
+
+// ListServerGroups lists the in-memory server groups ordered by name
+func (p *Provider) ListServerGroups(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerGroupResponse, error) {
+	l, err := p.listInfo(ctx, ListServerGroups, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	var out []*common.CreateServerGroupResponse
+	for id, r := range p.groups {
+		if match(l, r.Name, r.ServerGroupRegion, p.tags[id]) {
+			copied := *r
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// ListK8s lists the in-memory clusters ordered by name
+func (p *Provider) ListK8s(ctx context.Context, opts ...common.ListOption) ([]*common.CreateK8sResponse, error) {
+	l, err := p.listInfo(ctx, ListK8s, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	var out []*common.CreateK8sResponse
+	for id, r := range p.clusters {
+		if match(l, r.Name, r.ClusterRegion, p.tags[id]) {
+			copied := *r
+			creds := *r.Credentials
+			copied.Credentials = &creds
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// GetK8s returns the in-memory cluster with the name
+func (p *Provider) GetK8s(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateK8sResponse, error) {
+	l, err := p.listInfo(ctx, GetK8s, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	for id, r := range p.clusters {
+		if r.Name == name && match(l, r.Name, r.ClusterRegion, p.tags[id]) {
+			copied := *r
+			creds := *r.Credentials
+			copied.Credentials = &creds
+			return &copied, nil
+		}
+	}
+	return nil, common.NewError("fake", string(GetK8s), common.ErrNotFound, fmt.Errorf("cluster %v", name))
+}
+
+// ListDNSRecords lists the in-memory DNS A Records ordered by subdomain. DNS records
+// carry no tags.
+func (p *Provider) ListDNSRecords(ctx context.Context, opts ...common.ListOption) ([]*common.CreateDNSRecordResponse, error) {
+	l, err := p.listInfo(ctx, ListDNSRecords, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	var out []*common.CreateDNSRecordResponse
+	for _, r := range p.records {
+		if l.Match(r.SubDomain, nil) {
+			copied := *r
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SubDomain < out[j].SubDomain })
+	return out, nil
+}
+
+// ListStaticIPs lists the in-memory static IPs ordered by name. Static IPs carry no tags.
+func (p *Provider) ListStaticIPs(ctx context.Context, opts ...common.ListOption) ([]*common.CreateStaticIPResponse, error) {
+	l, err := p.listInfo(ctx, ListStaticIPs, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	var out []*common.CreateStaticIPResponse
+	for _, r := range p.staticIPs {
+		if match(l, r.Name, r.Region, nil) {
+			copied := *r
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// network checks that a server or cluster network, if any, exists; p.mu must be held
+func (p *Provider) network(op Op, network common.Ref) error {
+	if network.IsZero() {
+		return nil
+	}
+	if _, ok := p.networks[network.ID]; !ok || network.Check("fake", common.KindNetwork) != nil {
+		return common.NewError("fake", string(op), common.ErrNotFound, fmt.Errorf("network %v", network))
+	}
+	return nil
+}
+
+// CreateNetwork creates an in-memory network
+func (p *Provider) CreateNetwork(ctx context.Context, name string, req *common.NetworkRequest) (*common.CreateNetworkResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := p.begin(ctx, CreateNetwork); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	id := p.id("network")
+	resp := &common.CreateNetworkResponse{
+		Name:      name,
+		NetworkID: ref(common.KindNetwork, id, req.Region),
+		CIDR:      req.CIDR,
+		Region:    req.Region,
+	}
+	p.networks[id] = resp
+
+	copied := *resp
+	return &copied, p.partial(CreateNetwork)
+}
+
+// RemoveNetwork removes an in-memory network
+func (p *Provider) RemoveNetwork(ctx context.Context, network *common.CreateNetworkResponse) error {
+	if err := p.begin(ctx, RemoveNetwork); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := network.NetworkID.ID
+	if _, ok := p.networks[id]; !ok || network.NetworkID.Check("fake", common.KindNetwork) != nil {
+		return common.NewError("fake", string(RemoveNetwork), common.ErrNotFound, fmt.Errorf("network %v", network.NetworkID))
+	}
+	delete(p.networks, id)
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// server returns the ID of the in-memory server, or an ErrNotFound error for op; p.mu must be held
+func (p *Provider) server(op Op, server *common.CreateServerResponse) (string, error) {
+	id := server.ServerID.ID
+	if _, ok := p.servers[id]; !ok || server.ServerID.Check("fake", common.KindServer) != nil {
+		return "", common.NewError("fake", string(op), common.ErrNotFound, fmt.Errorf("server %v", server.ServerID))
+	}
+	return id, nil
+}
+
+// StopServer stops an in-memory server
+func (p *Provider) StopServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := p.begin(ctx, StopServer); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id, err := p.server(StopServer, server)
+	if err != nil {
+		return err
+	}
+	p.stopped[id] = true
+
+	return nil
+}
+
+// StartServer starts a stopped in-memory server. Like a GCE instance with an ephemeral
+// IP, the server gets a new IP.
+func (p *Provider) StartServer(ctx context.Context, server *common.CreateServerResponse) (*common.CreateServerResponse, error) {
+	if err := p.begin(ctx, StartServer); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	id, err := p.server(StartServer, server)
+	if err != nil {
+		return nil, err
+	}
+	if p.stopped[id] {
+		delete(p.stopped, id)
+		p.servers[id].ServerIP = p.ip()
+	}
+
+	copied := *p.servers[id]
+	return &copied, nil
+}
+
+// RebootServer reboots a running in-memory server
+func (p *Provider) RebootServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := p.begin(ctx, RebootServer); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id, err := p.server(RebootServer, server)
+	if err != nil {
+		return err
+	}
+	if p.stopped[id] {
+		return fmt.Errorf("fake: RebootServer: server %v is stopped", server.ServerID)
+	}
+
+	return nil
+}
+
+// ResizeServer changes the size of an in-memory server
+func (p *Provider) ResizeServer(ctx context.Context, server *common.CreateServerResponse, size string) (*common.CreateServerResponse, error) {
+	if err := p.begin(ctx, ResizeServer); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	id, err := p.server(ResizeServer, server)
+	if err != nil {
+		return nil, err
+	}
+	p.sizes[id] = size
+
+	copied := *p.servers[id]
+	return &copied, nil
+}
+
+// ServerSize returns the size of the in-memory server, or "" if it does not exist
+func (p *Provider) ServerSize(server *common.CreateServerResponse) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sizes[server.ServerID.ID]
+}
+
+// ServerStopped reports whether the in-memory server is stopped
+func (p *Provider) ServerStopped(server *common.CreateServerResponse) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopped[server.ServerID.ID]
+}
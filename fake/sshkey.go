@@ -0,0 +1,53 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// sshKey adds an in-memory SSH key; p.mu must be held
+func (p *Provider) sshKey(name string, publicKey string, fingerprint string) *common.ImportSSHKeyResponse {
+	id := p.id("sshkey")
+	resp := &common.ImportSSHKeyResponse{
+		Name:        name,
+		KeyID:       ref(common.KindSSHKey, id, ""),
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+	}
+	p.sshKeys[id] = resp
+	return resp
+}
+
+// ImportSSHKey imports an in-memory SSH key
+func (p *Provider) ImportSSHKey(ctx context.Context, name string, publicKey string) (*common.ImportSSHKeyResponse, error) {
+	fingerprint, err := common.SSHFingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.begin(ctx, ImportSSHKey); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	copied := *p.sshKey(name, publicKey, fingerprint)
+	return &copied, p.partial(ImportSSHKey)
+}
+
+// RemoveSSHKey removes an in-memory SSH key
+func (p *Provider) RemoveSSHKey(ctx context.Context, key *common.ImportSSHKeyResponse) error {
+	if err := p.begin(ctx, RemoveSSHKey); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id := key.KeyID.ID
+	if _, ok := p.sshKeys[id]; !ok || key.KeyID.Check("fake", common.KindSSHKey) != nil {
+		return common.NewError("fake", string(RemoveSSHKey), common.ErrNotFound, fmt.Errorf("ssh key %v", key.KeyID))
+	}
+	delete(p.sshKeys, id)
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// volume checks that the in-memory volume exists in the region, if one is set, and is not
+// attached, or returns an error for op; p.mu must be held
+func (p *Provider) volume(op Op, ref common.Ref, region string) error {
+	v, ok := p.volumes[ref.ID]
+	if !ok || ref.Check("fake", common.KindVolume) != nil {
+		return common.NewError("fake", string(op), common.ErrNotFound, fmt.Errorf("volume %v", ref))
+	}
+	if len(region) > 0 && v.Region != region {
+		return fmt.Errorf("fake: %s: volume %v is in region %s, not %s", op, ref, v.Region, region)
+	}
+	if serverID, ok := p.attached[ref.ID]; ok {
+		return fmt.Errorf("fake: %s: volume %v is attached to %s", op, ref, serverID)
+	}
+	return nil
+}
+
+// CreateVolume creates an in-memory volume
+func (p *Provider) CreateVolume(ctx context.Context, name string, req *common.VolumeRequest) (*common.CreateVolumeResponse, error) {
+	if err := p.begin(ctx, CreateVolume); err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	id := p.id("volume")
+	resp := &common.CreateVolumeResponse{
+		Name:     name,
+		VolumeID: ref(common.KindVolume, id, req.Region),
+		Region:   req.Region,
+		SizeGB:   req.SizeGB,
+	}
+	p.volumes[id] = resp
+
+	copied := *resp
+	return &copied, p.partial(CreateVolume)
+}
+
+// AttachVolume attaches an in-memory volume to an in-memory server in the same region
+func (p *Provider) AttachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := p.begin(ctx, AttachVolume); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id, err := p.server(AttachVolume, server)
+	if err != nil {
+		return err
+	}
+	if err := p.volume(AttachVolume, volume.VolumeID, p.servers[id].ServerRegion); err != nil {
+		return err
+	}
+	p.attached[volume.VolumeID.ID] = id
+
+	return nil
+}
+
+// DetachVolume detaches an in-memory volume from an in-memory server
+func (p *Provider) DetachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := p.begin(ctx, DetachVolume); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	id, err := p.server(DetachVolume, server)
+	if err != nil {
+		return err
+	}
+	if p.attached[volume.VolumeID.ID] != id {
+		return common.NewError("fake", string(DetachVolume), common.ErrNotFound, fmt.Errorf("volume %v is not attached to %v", volume.VolumeID, server.ServerID))
+	}
+	delete(p.attached, volume.VolumeID.ID)
+
+	return nil
+}
+
+// RemoveVolume removes an in-memory volume that is not attached
+func (p *Provider) RemoveVolume(ctx context.Context, volume *common.CreateVolumeResponse) error {
+	if err := p.begin(ctx, RemoveVolume); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	if err := p.volume(RemoveVolume, volume.VolumeID, ""); err != nil {
+		return err
+	}
+	delete(p.volumes, volume.VolumeID.ID)
+
+	return nil
+}
+
+// VolumeServer returns the ID of the in-memory server the volume is attached to, or ""
+func (p *Provider) VolumeServer(volume *common.CreateVolumeResponse) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attached[volume.VolumeID.ID]
+}
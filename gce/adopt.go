@@ -0,0 +1,210 @@
+package gce
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	compute "google.golang.org/api/compute/v1"
+	container "google.golang.org/api/container/v1"
+	dns "google.golang.org/api/dns/v1"
+)
+
+// lastSegment returns the last path segment of a resource URL, e.g. the machine type name
+func lastSegment(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// ownerLabels returns the labels marking resources created with the owner tag, or nil
+func (p *Provider) ownerLabels() map[string]string {
+	if len(p.info.OwnerTag) == 0 {
+		return nil
+	}
+	return map[string]string{common.OwnerLabel: p.info.OwnerTag}
+}
+
+// resourceLabels returns the owner and expiry labels of a new instance or cluster, or nil
+func (p *Provider) resourceLabels(expires time.Time) map[string]string {
+	labels := p.ownerLabels()
+	if !expires.IsZero() {
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[common.ExpiresLabel] = common.ExpiresValue(expires)
+	}
+	return labels
+}
+
+// owned reports whether labels carry the owner tag, if one is set
+func (p *Provider) owned(labels map[string]string) bool {
+	return len(p.info.OwnerTag) == 0 || labels[common.OwnerLabel] == p.info.OwnerTag
+}
+
+// ownerDescription returns the description marking addresses, which have no labels,
+// created with the owner tag
+func (p *Provider) ownerDescription() string {
+	if len(p.info.OwnerTag) == 0 {
+		return ""
+	}
+	return common.OwnerLabel + "=" + p.info.OwnerTag
+}
+
+// addressDescription returns the description of a new address, carrying the owner and
+// expiry tags separated by spaces
+func (p *Provider) addressDescription(expires time.Time) string {
+	var tags []string
+	if owner := p.ownerDescription(); len(owner) > 0 {
+		tags = append(tags, owner)
+	}
+	if !expires.IsZero() {
+		tags = append(tags, common.ExpiresTag(expires))
+	}
+	return strings.Join(tags, " ")
+}
+
+// notFound reports whether err is a lookup of a missing resource, after which it is created
+func notFound(err error) bool {
+	return err != nil && classify(err) == common.ErrNotFound
+}
+
+// adoptInstance looks up an instance with the name of want, and reports whether it can be
+// adopted. Instances that are still provisioning are adopted and waited for by the caller.
+func (p *Provider) adoptInstance(ctx context.Context, zone string, want *compute.Instance) (bool, error) {
+	var ins *compute.Instance
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		ins, err = p.computeSvc.Instances.Get(p.projectID, zone, want.Name).Context(ctx).Do()
+		return err
+	})
+	if notFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, wrapErr("CreateServer", err)
+	}
+
+	conflict := func(format string, args ...interface{}) (bool, error) {
+		return false, common.SpecConflict("gce", common.OpCreateServer, want.Name, format, args...)
+	}
+
+	if !p.owned(ins.Labels) {
+		return conflict("is not labeled %s=%s", common.OwnerLabel, p.info.OwnerTag)
+	}
+	if got, size := lastSegment(ins.MachineType), lastSegment(want.MachineType); got != size {
+		return conflict("has machine type %s, not %s", got, size)
+	}
+	if want.Tags != nil {
+		for _, tag := range want.Tags.Items {
+			if ins.Tags == nil || !contains(ins.Tags.Items, tag) {
+				return conflict("is not tagged %s", tag)
+			}
+		}
+	}
+	switch ins.Status {
+	case "PROVISIONING", "STAGING", "RUNNING":
+	default:
+		return conflict("is %s", ins.Status)
+	}
+
+	return true, nil
+}
+
+// adoptCluster looks up a cluster with the name of want, and reports whether it can be
+// adopted. Clusters that are still provisioning are adopted and waited for by the caller.
+func (p *Provider) adoptCluster(ctx context.Context, zone string, want *container.Cluster) (bool, error) {
+	var cls *container.Cluster
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		cls, err = p.containerSvc.Get(p.projectID, zone, want.Name).Context(ctx).Do()
+		return err
+	})
+	if notFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, wrapErr("CreateK8s", err)
+	}
+
+	conflict := func(format string, args ...interface{}) (bool, error) {
+		return false, common.SpecConflict("gce", common.OpCreateK8s, want.Name, format, args...)
+	}
+
+	if !p.owned(cls.ResourceLabels) {
+		return conflict("is not labeled %s=%s", common.OwnerLabel, p.info.OwnerTag)
+	}
+	if len(cls.NodePools) > 0 && cls.NodePools[0].Config != nil {
+		if got, size := cls.NodePools[0].Config.MachineType, want.NodePools[0].Config.MachineType; got != size {
+			return conflict("has machine type %s, not %s", got, size)
+		}
+	}
+	if want.InitialClusterVersion != "" && !strings.HasPrefix(cls.CurrentMasterVersion, want.InitialClusterVersion) {
+		return conflict("runs version %s, not %s", cls.CurrentMasterVersion, want.InitialClusterVersion)
+	}
+	switch cls.Status {
+	case "PROVISIONING", "RECONCILING", "RUNNING":
+	default:
+		return conflict("is %s", cls.Status)
+	}
+
+	return true, nil
+}
+
+// adoptAddress looks up a static IP with the name of want, global if region is empty, and
+// reports whether it can be adopted
+func (p *Provider) adoptAddress(ctx context.Context, region string, want *compute.Address) (bool, error) {
+	var addr *compute.Address
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		if len(region) == 0 {
+			addr, err = p.computeSvc.GlobalAddresses.Get(p.projectID, want.Name).Context(ctx).Do()
+		} else {
+			addr, err = p.computeSvc.Addresses.Get(p.projectID, region, want.Name).Context(ctx).Do()
+		}
+		return err
+	})
+	if notFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, wrapErr("CreateStaticIP", err)
+	}
+
+	if owner := p.ownerDescription(); len(owner) > 0 && !contains(strings.Fields(addr.Description), owner) {
+		return false, common.SpecConflict("gce", common.OpCreateStaticIP, want.Name, "is not described as %s", owner)
+	}
+
+	return true, nil
+}
+
+// adoptRecord looks up an A record with the name of want, and reports whether it
+// points to the same addresses
+func (p *Provider) adoptRecord(ctx context.Context, want *dns.ResourceRecordSet) (bool, error) {
+	var resp *dns.ResourceRecordSetsListResponse
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		resp, err = p.dnsSvc.ResourceRecordSets.List(p.projectID, p.dnsZone).Name(want.Name).Type(want.Type).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return false, wrapErr("CreateDNSRecord", err)
+	}
+
+	for _, rrset := range resp.Rrsets {
+		if strings.Join(rrset.Rrdatas, ",") != strings.Join(want.Rrdatas, ",") {
+			return false, common.SpecConflict("gce", common.OpCreateDNSRecord, want.Name, "points to %s", strings.Join(rrset.Rrdatas, ","))
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
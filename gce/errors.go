@@ -0,0 +1,47 @@
+package gce
+
+import (
+	"net/http"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"google.golang.org/api/googleapi"
+)
+
+// classify maps a Google API error to one of the common error classes
+func classify(err error) error {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return nil
+	}
+
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "quotaExceeded", "limitExceeded":
+			return common.ErrQuotaExceeded
+		case "rateLimitExceeded", "userRateLimitExceeded", "backendError", "internalError":
+			return common.ErrTransient
+		case "alreadyExists", "duplicate":
+			return common.ErrAlreadyExists
+		case "notFound":
+			return common.ErrNotFound
+		}
+	}
+
+	switch code := apiErr.Code; {
+	case code == http.StatusNotFound:
+		return common.ErrNotFound
+	case code == http.StatusConflict:
+		return common.ErrAlreadyExists
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return common.ErrPermissionDenied
+	case code == http.StatusTooManyRequests, code >= 500:
+		return common.ErrTransient
+	}
+
+	return nil
+}
+
+// wrapErr classifies err for the given operation, preserving it as the cause
+func wrapErr(op string, err error) error {
+	return common.NewError("gce", op, classify(err), err)
+}
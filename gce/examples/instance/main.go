@@ -57,7 +57,7 @@ func main() {
 
 	fmt.Println(serverResp)
 
-	subDomain := serverResp.Name + "-" + serverResp.ServerID.(string) + "." + "instances"
+	subDomain := serverResp.Name + "-" + serverResp.ServerID.ID + "." + "instances"
 
 	fmt.Println("Creating DNS record")
 	dnsResp, err := p.CreateDNSRecord(ctx, subDomain, serverResp.ServerIP)
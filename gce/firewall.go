@@ -0,0 +1,190 @@
+package gce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+	compute "google.golang.org/api/compute/v1"
+)
+
+// firewall returns the GCE firewall of the request. A GCE firewall has a single set of
+// source ranges, so every rule must have the same sources.
+func (p *Provider) firewall(name string, req *common.FirewallRequest) (*compute.Firewall, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	network, _, err := p.networkURLs(req.Network, req.Network.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := common.SourcesOf(req.Rules[0])
+	var allowed []*compute.FirewallAllowed
+	for _, r := range req.Rules {
+		if strings.Join(common.SourcesOf(r), ",") != strings.Join(sources, ",") {
+			return nil, fmt.Errorf("gce: the rules of firewall %s must have the same sources", name)
+		}
+		allowed = append(allowed, &compute.FirewallAllowed{
+			IPProtocol: r.Protocol,
+			Ports:      r.Ports,
+		})
+	}
+
+	return &compute.Firewall{
+		Name:         name,
+		Network:      network,
+		Direction:    "INGRESS",
+		SourceRanges: sources,
+		TargetTags:   req.TargetTags,
+		Allowed:      allowed,
+	}, nil
+}
+
+// waitGlobalOp polls a global operation until it is done and returns its error, if any
+func (p *Provider) waitGlobalOp(ctx context.Context, log *common.OpLog, op *compute.Operation) error {
+	return waitOp(ctx, log, func(ctx context.Context) (*compute.Operation, error) {
+		return p.computeSvc.GlobalOperations.Get(p.projectID, op.Name).Context(ctx).Do()
+	})
+}
+
+// waitOp polls an operation with get until it is done and returns its error, if any
+func waitOp(ctx context.Context, log *common.OpLog, get func(ctx context.Context) (*compute.Operation, error)) error {
+	return wait.Poll(ctx, wait.Operation, func(ctx context.Context) (bool, error) {
+		current, err := get(ctx)
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		log.Status(current.Status)
+		if current.Status != "DONE" {
+			return false, nil
+		}
+		if current.Error != nil && len(current.Error.Errors) > 0 {
+			return false, fmt.Errorf("%s: %s", current.Error.Errors[0].Code, current.Error.Errors[0].Message)
+		}
+		return true, nil
+	})
+}
+
+// CreateFirewall creates a firewall on the network, or the default network of the
+// project, on GCE and waits until it is applied. Without target tags it applies to every instance.
+func (p *Provider) CreateFirewall(ctx context.Context, name string, req *common.FirewallRequest) (*common.CreateFirewallResponse, error) {
+	firewall, err := p.firewall(name, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: p.ref(common.KindFirewall, name, ""),
+		TargetTags: req.TargetTags,
+	}
+
+	log := p.info.StartOp(ctx, "gce", common.OpCreateFirewall, name)
+	log.Info("creating firewall", "tags", req.TargetTags)
+	if log.DryRun("compute.firewalls.insert", firewall) {
+		return resp, log.Done(nil)
+	}
+
+	var op *compute.Operation
+	reqID := requestID()
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		op, err = p.computeSvc.Firewalls.Insert(p.projectID, firewall).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateFirewall", err))
+	}
+	log.Started(name)
+
+	if err := p.waitGlobalOp(ctx, log, op); err != nil {
+		return nil, log.Done(wrapErr("CreateFirewall", err))
+	}
+
+	return resp, log.Done(nil)
+}
+
+// RemoveFirewall removes a firewall on GCE
+func (p *Provider) RemoveFirewall(ctx context.Context, firewall *common.CreateFirewallResponse) error {
+	if err := firewall.FirewallID.Check("gce", common.KindFirewall); err != nil {
+		return err
+	}
+	name, _ := resourceName(firewall.Name, "", firewall.FirewallID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveFirewall, name)
+	log.Info("deleting firewall")
+	if log.DryRun("compute.firewalls.delete", map[string]string{"project": p.projectID, "firewall": name}) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Firewalls.Delete(p.projectID, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveFirewall", err))
+	}
+	log.Started(name)
+
+	return log.Done(nil)
+}
+
+// serverFirewall creates the firewall of a server created with ServerFirewall, targeting
+// the network tag of the same name on the network of the server. An existing firewall is
+// kept when adopting.
+func (p *Provider) serverFirewall(ctx context.Context, server string, network common.Ref, rules []common.FirewallRule) (*common.CreateFirewallResponse, error) {
+	name := common.FirewallName(server)
+	req := &common.FirewallRequest{
+		Rules:      rules,
+		TargetTags: []string{name},
+		Network:    network,
+	}
+
+	resp, err := p.CreateFirewall(ctx, name, req)
+	if p.adoptable(err) {
+		return &common.CreateFirewallResponse{
+			Name:       name,
+			FirewallID: p.ref(common.KindFirewall, name, ""),
+			TargetTags: req.TargetTags,
+		}, nil
+	}
+	return resp, err
+}
+
+// existingFirewall returns the firewall with the name, or an empty response if there is
+// none, for adopted instances that keep the firewall they were created with
+func (p *Provider) existingFirewall(ctx context.Context, name string) (*common.CreateFirewallResponse, error) {
+	var firewall *compute.Firewall
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		firewall, err = p.computeSvc.Firewalls.Get(p.projectID, name).Context(ctx).Do()
+		return err
+	})
+	if err = wrapErr("CreateServer", err); errors.Is(err, common.ErrNotFound) {
+		return &common.CreateFirewallResponse{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &common.CreateFirewallResponse{
+		Name:       name,
+		FirewallID: p.ref(common.KindFirewall, name, ""),
+		TargetTags: firewall.TargetTags,
+	}, nil
+}
+
+// removeServerFirewall removes the firewall of a server that could not be created, if any
+func (p *Provider) removeServerFirewall(ctx context.Context, log *common.OpLog, firewall *common.CreateFirewallResponse) {
+	if firewall.FirewallID.IsZero() {
+		return
+	}
+	if err := p.RemoveFirewall(context.WithoutCancel(ctx), firewall); err != nil {
+		log.Warn("could not remove the firewall of the failed server", "firewall", firewall.Name, "error", err)
+	}
+}
@@ -2,12 +2,14 @@ package gce
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/compute/v1"
@@ -23,15 +25,40 @@ type Provider struct {
 	dnsSvc       *dns.Service
 	domain       string
 	dnsZone      string
+	info         *common.ProviderInfo
 }
 
-// NewProvider returns a new Provider instance
-func NewProvider(projectID string, domain string, dnsZone string) (*Provider, error) {
+// NewProvider returns a new Provider instance using application default credentials
+func NewProvider(projectID string, domain string, dnsZone string, opts ...common.ProviderOption) (*Provider, error) {
 	oauthClient, err := google.DefaultClient(oauth2.NoContext, compute.CloudPlatformScope, dns.CloudPlatformScope)
 	if err != nil {
 		return nil, err
 	}
 
+	return newProviderFromClient(oauthClient, projectID, domain, dnsZone, opts)
+}
+
+// NewProviderFromCredentialsFile returns a new Provider instance using a service account file
+func NewProviderFromCredentialsFile(credentialsFile string, projectID string, domain string, dnsZone string, opts ...common.ProviderOption) (*Provider, error) {
+	data, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := google.CredentialsFromJSON(oauth2.NoContext, data, compute.CloudPlatformScope, dns.CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return newProviderFromClient(oauth2.NewClient(oauth2.NoContext, creds.TokenSource), projectID, domain, dnsZone, opts)
+}
+
+func newProviderFromClient(oauthClient *http.Client, projectID string, domain string, dnsZone string, opts []common.ProviderOption) (*Provider, error) {
+	info, err := common.NewProviderInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	computeSvc, err := compute.New(oauthClient)
 	if err != nil {
 		return nil, err
@@ -48,65 +75,75 @@ func NewProvider(projectID string, domain string, dnsZone string) (*Provider, er
 		return nil, err
 	}
 
-	return &Provider{projectID, computeSvc, containerSvc, dnsSvc, domain, dnsZone}, nil
+	return &Provider{projectID, computeSvc, containerSvc, dnsSvc, domain, dnsZone, info}, nil
 }
 
-func (p *Provider) firewallsPreflight(prefix string) error {
-	firewallHTTP := &compute.Firewall{
-		Name:         "default-allow-http",
-		SourceRanges: []string{"0.0.0.0/0"},
-		Network:      prefix + "/global/networks/default",
-		TargetTags:   []string{"http-server"},
-		Allowed: []*compute.FirewallAllowed{
-			&compute.FirewallAllowed{
-				IPProtocol: "tcp",
-				Ports:      []string{"80"},
-			},
-		},
+// ref returns the reference to a resource in the project of p
+func (p *Provider) ref(kind common.Kind, id string, zone string) common.Ref {
+	return common.Ref{
+		Provider: "gce",
+		Kind:     kind,
+		ID:       id,
+		Region:   zone,
+		Project:  p.projectID,
 	}
+}
 
-	firewallHTTPS := &compute.Firewall{
-		Name:         "default-allow-https",
-		SourceRanges: []string{"0.0.0.0/0"},
-		Network:      prefix + "/global/networks/default",
-		TargetTags:   []string{"https-server"},
-		Allowed: []*compute.FirewallAllowed{
-			&compute.FirewallAllowed{
-				IPProtocol: "tcp",
-				Ports:      []string{"443"},
-			},
+// Capabilities returns the operations and options supported by GCE
+func (p *Provider) Capabilities() *common.Capabilities {
+	return &common.Capabilities{
+		Provider: "gce",
+		Operations: map[common.Operation][]common.OptionName{
+			common.OpCreateServer:    {common.OptRegion, common.OptSize, common.OptImage, common.OptUserData, common.OptTags, common.OptExpires, common.OptVolumes, common.OptFirewall, common.OptSSHKeys, common.OptNetwork},
+			common.OpRemoveServer:    nil,
+			common.OpCreateK8s:       {common.OptRegion, common.OptSize, common.OptAutoScale, common.OptK8sVersion, common.OptExpires, common.OptNetwork},
+			common.OpRemoveK8s:       nil,
+			common.OpCreateDNSRecord: nil,
+			common.OpRemoveDNSRecord: nil,
+			common.OpCreateStaticIP:  nil,
+			common.OpRemoveStaticIP:  nil,
+			common.OpListServers:     nil,
+			common.OpGetServer:       nil,
+			common.OpListDNSRecords:  nil,
+			common.OpStopServer:      nil,
+			common.OpStartServer:     nil,
+			common.OpRebootServer:    nil,
+			common.OpResizeServer:    nil,
+			common.OpCreateImage:     nil,
+			common.OpListImages:      nil,
+			common.OpRemoveImage:     nil,
+			common.OpCreateVolume:    nil,
+			common.OpAttachVolume:    nil,
+			common.OpDetachVolume:    nil,
+			common.OpRemoveVolume:    nil,
+			common.OpCreateFirewall:  nil,
+			common.OpRemoveFirewall:  nil,
+			common.OpImportSSHKey:    nil,
+			common.OpRemoveSSHKey:    nil,
+			common.OpCreateNetwork:   nil,
+			common.OpRemoveNetwork:   nil,
+			common.OpListK8s:         nil,
+			common.OpGetK8s:          nil,
+			common.OpListStaticIPs:   nil,
 		},
+		StaticIPTypes: []common.StaticIPType{common.GLOBAL, common.REGIONAL},
+		// insert requests count against the per-project API rate quota
+		CreateInterval: 250 * time.Millisecond,
 	}
-
-	_, err := p.computeSvc.Firewalls.Update(p.projectID, "default-allow-http", firewallHTTP).Do()
-	if err != nil {
-		return err
-	}
-	_, err = p.computeSvc.Firewalls.Update(p.projectID, "default-allow-https", firewallHTTPS).Do()
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
-// CreateServer creates a droplet on GCP
-// TODO reimplement waiting for IP using a ticker
+// CreateServer creates a droplet on GCP. If the instance is inserted but does not start,
+// it is returned with the error so it can be removed.
 func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerResponse, error) {
-	s := &common.ServerInfo{
-		Name: name,
-	}
-
-	for _, opt := range opts {
-		opt.Set(s)
+	s, err := common.NewServerInfo(name, opts...)
+	if err != nil {
+		return nil, err
 	}
 
 	prefix := "https://www.googleapis.com/compute/v1/projects/" + p.projectID
 	zone := s.Region
 	machineType := s.Size
 
-	// p.firewallsPreflight(prefix)
-
 	var imageURL string
 	if len(s.Image) == 0 {
 		imageURL = os.Getenv("GCP_SOURCE_IMAGE")
@@ -114,6 +151,27 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 		imageURL = s.Image
 	}
 
+	volumes, err := p.volumeDisks(zone, s.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	sshKeys, privateKey, err := instanceSSHKeys(s)
+	if err != nil {
+		return nil, err
+	}
+
+	network, subnetwork, err := p.networkURLs(s.Network, zoneRegion(zone))
+	if err != nil {
+		return nil, err
+	}
+
+	tags := s.Tags
+	if len(s.Firewall) > 0 {
+		// the firewall targets the instance by a network tag of the same name
+		tags = append(append([]string{}, s.Tags...), common.FirewallName(name))
+	}
+
 	instance := &compute.Instance{
 		Name:        name,
 		MachineType: prefix + "/zones/" + zone + "/machineTypes/" + machineType,
@@ -125,7 +183,7 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 				},
 			},
 		},
-		Disks: []*compute.AttachedDisk{
+		Disks: append([]*compute.AttachedDisk{
 			&compute.AttachedDisk{
 				AutoDelete: true,
 				Boot:       true,
@@ -135,7 +193,7 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 					SourceImage: imageURL,
 				},
 			},
-		},
+		}, volumes...),
 		NetworkInterfaces: []*compute.NetworkInterface{
 			&compute.NetworkInterface{
 				AccessConfigs: []*compute.AccessConfig{
@@ -144,12 +202,14 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 						Name: "External NAT",
 					},
 				},
-				Network: prefix + "/global/networks/default",
+				Network:    network,
+				Subnetwork: subnetwork,
 			},
 		},
 		Tags: &compute.Tags{
-			Items: s.Tags,
+			Items: tags,
 		},
+		Labels: p.resourceLabels(s.Expires),
 		ServiceAccounts: []*compute.ServiceAccount{
 			&compute.ServiceAccount{
 				Email: "default",
@@ -159,36 +219,96 @@ func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common
 			},
 		},
 	}
+	if sshKeys != nil {
+		instance.Metadata.Items = append(instance.Metadata.Items, sshKeys)
+	}
 
-	_, err := p.computeSvc.Instances.Insert(p.projectID, zone, instance).Do()
-	if err != nil {
-		return nil, err
+	log := p.info.StartOp(ctx, "gce", common.OpCreateServer, name)
+	log.Info("creating instance", "zone", zone, "size", machineType)
+	if log.DryRun("compute.instances.insert", instance) {
+		resp := &common.CreateServerResponse{
+			Name:          name,
+			ServerID:      p.ref(common.KindServer, name, zone),
+			ServerRegion:  zone,
+			ServerIP:      common.PlaceholderIP,
+			Expires:       s.Expires,
+			SSHPrivateKey: privateKey,
+		}
+		if len(s.Firewall) > 0 {
+			firewall, err := p.serverFirewall(ctx, name, s.Network, s.Firewall)
+			if err != nil {
+				return nil, log.Done(err)
+			}
+			resp.FirewallID = firewall.FirewallID
+		}
+		return resp, log.Done(nil)
 	}
 
-	time.Sleep(60 * time.Second)
+	adopted := false
+	if p.info.Adopt {
+		if adopted, err = p.adoptInstance(ctx, zone, instance); err != nil {
+			return nil, log.Done(err)
+		}
+	}
 
-	var serverIP string
-	ready := false
-	for !ready {
+	firewall := &common.CreateFirewallResponse{}
+	if adopted {
+		log.Info("adopting existing instance")
+		// an adopted instance keeps the keys and firewall it was created with
+		privateKey = ""
+		if len(s.Firewall) > 0 {
+			if firewall, err = p.existingFirewall(ctx, common.FirewallName(name)); err != nil {
+				return nil, log.Done(err)
+			}
+		}
+	} else {
+		if len(s.Firewall) > 0 {
+			if firewall, err = p.serverFirewall(ctx, name, s.Network, s.Firewall); err != nil {
+				return nil, log.Done(err)
+			}
+		}
+
+		reqID := requestID()
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			_, err := p.computeSvc.Instances.Insert(p.projectID, zone, instance).RequestId(reqID).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			p.removeServerFirewall(ctx, log, firewall)
+			return nil, log.Done(wrapErr("CreateServer", err))
+		}
+	}
+	log.Started(name)
+
+	resp := &common.CreateServerResponse{
+		Name:          name,
+		ServerID:      p.ref(common.KindServer, name, zone),
+		ServerRegion:  zone,
+		Expires:       s.Expires,
+		FirewallID:    firewall.FirewallID,
+		SSHPrivateKey: privateKey,
+	}
+
+	err = wait.Poll(ctx, wait.Server, func(ctx context.Context) (bool, error) {
 		ins, err := p.computeSvc.Instances.Get(p.projectID, zone, name).Context(ctx).Do()
 		if err != nil {
-			return nil, err
+			return false, pollErr(err)
 		}
 
-		if ins.Status == "RUNNING" {
-			ready = true
-			serverIP = ins.NetworkInterfaces[0].AccessConfigs[0].NatIP
-		} else {
-			time.Sleep(15 * time.Second)
+		log.Status(ins.Status)
+		if ins.Status != "RUNNING" {
+			return false, nil
 		}
+		resp.ServerIP = ins.NetworkInterfaces[0].AccessConfigs[0].NatIP
+		return true, nil
+	})
+	if err != nil {
+		// the instance was inserted, so it is returned to be removed with its firewall
+		return resp, log.Done(wrapErr("CreateServer", err))
 	}
+	log.IPAssigned(resp.ServerIP)
 
-	return &common.CreateServerResponse{
-		Name:         name,
-		ServerID:     name,
-		ServerRegion: zone,
-		ServerIP:     serverIP,
-	}, nil
+	return resp, log.Done(nil)
 }
 
 // CreateDNSRecord creates a DNS A Record on GCP
@@ -204,25 +324,92 @@ func (p *Provider) CreateDNSRecord(ctx context.Context, subDomain string, IP str
 		},
 	}
 
-	resp, err := p.dnsSvc.Changes.Create(p.projectID, p.dnsZone, rb).Context(ctx).Do()
+	log := p.info.StartOp(ctx, "gce", common.OpCreateDNSRecord, subDomain)
+	log.Info("creating DNS record", "ip", IP)
+	if log.DryRun("dns.changes.create", rb) {
+		return &common.CreateDNSRecordResponse{
+			SubDomain:   subDomain,
+			SubDomainID: p.ref(common.KindDNSRecord, common.PlaceholderID(subDomain), ""),
+			SubDomainIP: IP,
+		}, log.Done(nil)
+	}
+
+	if p.info.Adopt {
+		adopted, err := p.adoptRecord(ctx, rb.Additions[0])
+		if err != nil {
+			return nil, log.Done(err)
+		}
+		if adopted {
+			log.Info("adopting existing DNS record")
+			log.Started(rb.Additions[0].Name)
+			return &common.CreateDNSRecordResponse{
+				SubDomain:   subDomain,
+				SubDomainID: p.ref(common.KindDNSRecord, rb.Additions[0].Name, ""),
+				SubDomainIP: IP,
+			}, log.Done(nil)
+		}
+	}
+
+	var resp *dns.Change
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		resp, err = p.dnsSvc.Changes.Create(p.projectID, p.dnsZone, rb).Context(ctx).Do()
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, log.Done(wrapErr("CreateDNSRecord", err))
 	}
+	log.Started(resp.Id)
 
 	return &common.CreateDNSRecordResponse{
 		SubDomain:   subDomain,
-		SubDomainID: resp.Id,
+		SubDomainID: p.ref(common.KindDNSRecord, rb.Additions[0].Name, ""),
 		SubDomainIP: IP,
-	}, nil
+	}, log.Done(nil)
+}
+
+// resourceName returns the name and zone of an instance, cluster or image. GCE names are
+// their IDs, so the reference is used when it is set.
+func resourceName(name string, zone string, id common.Ref) (string, string) {
+	if len(id.ID) > 0 {
+		name = id.ID
+	}
+	if len(zone) == 0 {
+		zone = id.Region
+	}
+	return name, zone
 }
 
 // RemoveServer removes a droplet on GCP
 func (p *Provider) RemoveServer(ctx context.Context, server *common.CreateServerResponse) error {
-	_, err := p.computeSvc.Instances.Delete(p.projectID, server.ServerRegion, server.Name).Context(ctx).Do()
-	if err != nil {
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
 		return err
 	}
-	return nil
+	name, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveServer, name).With("zone", zone)
+	log.Info("deleting instance")
+	if log.DryRun("compute.instances.delete", map[string]string{"project": p.projectID, "zone": zone, "instance": name}) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Instances.Delete(p.projectID, zone, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveServer", err))
+	}
+	log.Started(name)
+
+	if !server.FirewallID.IsZero() {
+		return log.Done(p.RemoveFirewall(ctx, &common.CreateFirewallResponse{
+			Name:       common.FirewallName(name),
+			FirewallID: server.FirewallID,
+		}))
+	}
+	return log.Done(nil)
 }
 
 // RemoveDNSRecord removes a DNS A Record from GCP
@@ -238,37 +425,46 @@ func (p *Provider) RemoveDNSRecord(ctx context.Context, subDomain *common.Create
 		},
 	}
 
-	_, err := p.dnsSvc.Changes.Create(p.projectID, p.dnsZone, rb).Context(ctx).Do()
-	if err != nil {
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveDNSRecord, subDomain.SubDomain)
+	log.Info("deleting DNS record", "ip", subDomain.SubDomainIP)
+	if log.DryRun("dns.changes.create", rb) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, false, func(ctx context.Context) error {
+		_, err := p.dnsSvc.Changes.Create(p.projectID, p.dnsZone, rb).Context(ctx).Do()
 		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveDNSRecord", err))
 	}
+	log.Started(subDomain.SubDomainID.ID)
 
-	return nil
+	return log.Done(nil)
 }
 
 // CreateServerGroup unimplemented for GCE
 func (p *Provider) CreateServerGroup(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerGroupResponse, error) {
-	return nil, errors.New("Unimplemented")
+	return nil, common.NotImplemented("gce", "CreateServerGroup")
 }
 
 // RemoveServerGroup unimplemented for GCE
 func (p *Provider) RemoveServerGroup(ctx context.Context, group *common.CreateServerGroupResponse) error {
-	return errors.New("Unimplemented")
+	return common.NotImplemented("gce", "RemoveServerGroup")
 }
 
 // CreateK8s creates a new cluster on GCE
 func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateK8sResponse, error) {
-	s := &common.ServerInfo{
-		Name: name,
-	}
-
-	for _, opt := range opts {
-		opt.Set(s)
+	s, err := common.NewServerInfo(name, opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	prefix := "projects/" + p.projectID
 	zone := s.Region
-	region := zone[:len(zone)-2]
+	if len(zone) < 3 {
+		return nil, fmt.Errorf("gce: CreateK8s requires a zone, got %q", zone)
+	}
+	region := zoneRegion(zone)
 	machineType := s.Size
 	initialCount := int64(3)
 	autoScaling := &container.NodePoolAutoscaling{}
@@ -283,6 +479,11 @@ func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.Se
 		}
 	}
 
+	network, subnetwork, err := p.networkURLs(s.Network, region)
+	if err != nil {
+		return nil, err
+	}
+
 	cluster := &container.Cluster{
 		Name: name,
 		MasterAuth: &container.MasterAuth{
@@ -293,14 +494,14 @@ func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.Se
 		},
 		LoggingService:    "logging.googleapis.com",
 		MonitoringService: "monitoring.googleapis.com",
-		Network:           prefix + "/global/networks/default",
+		Network:           network,
 		AddonsConfig: &container.AddonsConfig{
 			HttpLoadBalancing: &container.HttpLoadBalancing{},
 			KubernetesDashboard: &container.KubernetesDashboard{
 				Disabled: true,
 			},
 		},
-		Subnetwork: prefix + "/regions/" + region + "/subnetworks/default",
+		Subnetwork: subnetwork,
 		NodePools: []*container.NodePool{
 			&container.NodePool{
 				Name: "default-pool",
@@ -332,149 +533,250 @@ func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.Se
 		},
 		InitialClusterVersion: version,
 		Location:              zone,
+		ResourceLabels:        p.resourceLabels(s.Expires),
 	}
 
-	_, err := p.containerSvc.Create(
-		p.projectID,
-		zone,
-		&container.CreateClusterRequest{Cluster: cluster},
-	).Context(ctx).Do()
-	if err != nil {
-		return nil, err
+	log := p.info.StartOp(ctx, "gce", common.OpCreateK8s, name)
+	log.Info("creating cluster", "zone", zone, "size", machineType, "version", version)
+	if log.DryRun("container.projects.zones.clusters.create", &container.CreateClusterRequest{Cluster: cluster}) {
+		return &common.CreateK8sResponse{
+			Name:          name,
+			ClusterID:     p.ref(common.KindK8s, name, zone),
+			ClusterRegion: zone,
+			EndpointIP:    common.PlaceholderIP,
+			EndpointPort:  common.PlaceholderEndpointPort,
+			Credentials:   &common.ClusterCredentials{},
+			Expires:       s.Expires,
+		}, log.Done(nil)
+	}
+
+	adopted := false
+	if p.info.Adopt {
+		if adopted, err = p.adoptCluster(ctx, zone, cluster); err != nil {
+			return nil, log.Done(err)
+		}
+	}
+
+	if adopted {
+		log.Info("adopting existing cluster")
+	} else {
+		err = p.call(ctx, false, func(ctx context.Context) error {
+			_, err := p.containerSvc.Create(
+				p.projectID,
+				zone,
+				&container.CreateClusterRequest{Cluster: cluster},
+			).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, log.Done(wrapErr("CreateK8s", err))
+		}
 	}
+	log.Started(name)
 
-	time.Sleep(120 * time.Second)
+	resp := &common.CreateK8sResponse{
+		Name:          name,
+		ClusterID:     p.ref(common.KindK8s, name, zone),
+		ClusterRegion: zone,
+		Expires:       s.Expires,
+	}
 
-	var endpointIP string
-	var credentials *common.ClusterCredentials
-	ready := false
-	for !ready {
+	err = wait.Poll(ctx, wait.Cluster, func(ctx context.Context) (bool, error) {
 		cls, err := p.containerSvc.Get(p.projectID, zone, name).Context(ctx).Do()
 		if err != nil {
-			return nil, err
+			return false, pollErr(err)
 		}
 
-		if cls.Status == "RUNNING" {
-			ready = true
-			endpointIP = cls.Endpoint
-			credentials = &common.ClusterCredentials{
-				Username:    cls.MasterAuth.Username,
-				Password:    cls.MasterAuth.Password,
-				Certificate: cls.MasterAuth.ClusterCaCertificate,
-			}
-		} else {
-			time.Sleep(15 * time.Second)
+		log.Status(cls.Status)
+		if cls.Status != "RUNNING" {
+			return false, nil
 		}
+		resp.EndpointIP = cls.Endpoint
+		resp.EndpointPort = "443"
+		resp.Credentials = &common.ClusterCredentials{
+			Username:    cls.MasterAuth.Username,
+			Password:    cls.MasterAuth.Password,
+			Certificate: cls.MasterAuth.ClusterCaCertificate,
+		}
+		return true, nil
+	})
+	if err != nil {
+		return resp, log.Done(wrapErr("CreateK8s", err))
 	}
+	log.IPAssigned(resp.EndpointIP)
 
-	return &common.CreateK8sResponse{
-		Name:          name,
-		ClusterID:     name,
-		ClusterRegion: zone,
-		EndpointIP:    endpointIP,
-		EndpointPort:  "443",
-		Credentials:   credentials,
-	}, nil
+	return resp, log.Done(nil)
 }
 
 // RemoveK8s removes a cluster on GCE
 func (p *Provider) RemoveK8s(ctx context.Context, k8s *common.CreateK8sResponse) error {
-	_, err := p.containerSvc.Delete(p.projectID, k8s.ClusterRegion, k8s.Name).Context(ctx).Do()
-	if err != nil {
+	if err := k8s.ClusterID.Check("gce", common.KindK8s); err != nil {
 		return err
 	}
-	return nil
+	name, zone := resourceName(k8s.Name, k8s.ClusterRegion, k8s.ClusterID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveK8s, name).With("zone", zone)
+	log.Info("deleting cluster")
+	if log.DryRun("container.projects.zones.clusters.delete", map[string]string{"project": p.projectID, "zone": zone, "cluster": name}) {
+		return log.Done(nil)
+	}
+
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.containerSvc.Delete(p.projectID, zone, name).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveK8s", err))
+	}
+	log.Started(name)
+	return log.Done(nil)
 }
 
 // CreateStaticIP creates a static IP on GCE
 func (p *Provider) CreateStaticIP(ctx context.Context, name string, req *common.StaticIPRequest) (*common.CreateStaticIPResponse, error) {
-	addr := ""
+	// once inserted, the address is returned along with any error
+	resp := &common.CreateStaticIPResponse{
+		Name:    name,
+		Type:    req.IPType,
+		Region:  req.Region,
+		Expires: req.Expires,
+	}
 
+	log := p.info.StartOp(ctx, "gce", common.OpCreateStaticIP, name).With("type", req.IPType, "region", req.Region)
+	log.Info("reserving static IP")
 	switch req.IPType {
 	case common.GLOBAL:
 		address := &compute.Address{
-			Name:      name,
-			IpVersion: "IPV4",
+			Name:        name,
+			IpVersion:   "IPV4",
+			Description: p.addressDescription(req.Expires),
+		}
+		if log.DryRun("compute.globalAddresses.insert", address) {
+			resp.StaticIP = common.PlaceholderIP
+			break
 		}
 
-		_, err := p.computeSvc.GlobalAddresses.Insert(p.projectID, address).Context(ctx).Do()
+		err := p.insertAddress(ctx, log, "", address)
 		if err != nil {
-			return nil, err
+			return nil, log.Done(err)
 		}
+		log.Started(name)
 
-		time.Sleep(15 * time.Second)
-
-		ready := false
-		for !ready {
-			resp, err := p.computeSvc.GlobalAddresses.Get(p.projectID, name).Context(ctx).Do()
+		err = wait.Poll(ctx, wait.Address, func(ctx context.Context) (bool, error) {
+			reserved, err := p.computeSvc.GlobalAddresses.Get(p.projectID, name).Context(ctx).Do()
 			if err != nil {
-				return nil, err
+				return false, pollErr(err)
 			}
 
-			if len(resp.Address) > 0 {
-				ready = true
-				addr = resp.Address
-			} else {
-				time.Sleep(15 * time.Second)
-			}
+			log.Status(reserved.Status)
+			resp.StaticIP = reserved.Address
+			return len(resp.StaticIP) > 0, nil
+		})
+		if err != nil {
+			return resp, log.Done(wrapErr("CreateStaticIP", err))
 		}
 	case common.REGIONAL:
 		address := &compute.Address{
-			Name: name,
+			Name:        name,
+			Description: p.addressDescription(req.Expires),
+		}
+		if log.DryRun("compute.addresses.insert", address) {
+			resp.StaticIP = common.PlaceholderIP
+			break
 		}
 
-		_, err := p.computeSvc.Addresses.Insert(p.projectID, req.Region, address).Context(ctx).Do()
+		err := p.insertAddress(ctx, log, req.Region, address)
 		if err != nil {
-			return nil, err
+			return nil, log.Done(err)
 		}
+		log.Started(name)
 
-		time.Sleep(15 * time.Second)
-		ready := false
-		for !ready {
-			resp, err := p.computeSvc.Addresses.Get(p.projectID, req.Region, name).Context(ctx).Do()
+		err = wait.Poll(ctx, wait.Address, func(ctx context.Context) (bool, error) {
+			reserved, err := p.computeSvc.Addresses.Get(p.projectID, req.Region, name).Context(ctx).Do()
 			if err != nil {
-				return nil, err
+				return false, pollErr(err)
 			}
 
-			if len(resp.Address) > 0 {
-				ready = true
-				addr = resp.Address
-			} else {
-				time.Sleep(15 * time.Second)
-			}
+			log.Status(reserved.Status)
+			resp.StaticIP = reserved.Address
+			return len(resp.StaticIP) > 0, nil
+		})
+		if err != nil {
+			return resp, log.Done(wrapErr("CreateStaticIP", err))
 		}
 	default:
-		return nil, fmt.Errorf("Static IP Type: %v is not supported", req.IPType)
+		return nil, log.Done(fmt.Errorf("Static IP Type: %v is not supported", req.IPType))
 	}
+	log.IPAssigned(resp.StaticIP)
 
-	return &common.CreateStaticIPResponse{
-		Name:     name,
-		StaticIP: addr,
-		Type:     req.IPType,
-		Region:   req.Region,
-	}, nil
+	return resp, log.Done(nil)
 }
 
 // RemoveStaticIP removes a global static IP on GCE
 func (p *Provider) RemoveStaticIP(ctx context.Context, staticIP *common.CreateStaticIPResponse) error {
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveStaticIP, staticIP.Name).With("type", staticIP.Type, "region", staticIP.Region)
+	log.Info("releasing static IP")
 	switch ipType := staticIP.Type; ipType {
 	case common.GLOBAL:
-		_, err := p.computeSvc.GlobalAddresses.Delete(p.projectID, staticIP.Name).Context(ctx).Do()
-		if err != nil {
+		if log.DryRun("compute.globalAddresses.delete", map[string]string{"project": p.projectID, "address": staticIP.Name}) {
+			break
+		}
+
+		reqID := requestID()
+		err := p.call(ctx, true, func(ctx context.Context) error {
+			_, err := p.computeSvc.GlobalAddresses.Delete(p.projectID, staticIP.Name).RequestId(reqID).Context(ctx).Do()
 			return err
+		})
+		if err != nil {
+			return log.Done(wrapErr("RemoveStaticIP", err))
 		}
 	case common.REGIONAL:
 		region := "us-east1"
 		if len(staticIP.Region) > 0 {
 			region = staticIP.Region
 		}
-		_, err := p.computeSvc.Addresses.Delete(p.projectID, region, staticIP.Name).Context(ctx).Do()
-		if err != nil {
+		if log.DryRun("compute.addresses.delete", map[string]string{"project": p.projectID, "region": region, "address": staticIP.Name}) {
+			break
+		}
+
+		reqID := requestID()
+		err := p.call(ctx, true, func(ctx context.Context) error {
+			_, err := p.computeSvc.Addresses.Delete(p.projectID, region, staticIP.Name).RequestId(reqID).Context(ctx).Do()
 			return err
+		})
+		if err != nil {
+			return log.Done(wrapErr("RemoveStaticIP", err))
 		}
 	default:
-		return fmt.Errorf("Static IP Type: %v is not supported", ipType)
+		return log.Done(fmt.Errorf("Static IP Type: %v is not supported", ipType))
+	}
+	log.Started(staticIP.Name)
+
+	return log.Done(nil)
+}
+
+// insertAddress reserves a static IP, global if region is empty, unless an existing one is adopted
+func (p *Provider) insertAddress(ctx context.Context, log *common.OpLog, region string, address *compute.Address) error {
+	if p.info.Adopt {
+		adopted, err := p.adoptAddress(ctx, region, address)
+		if err != nil {
+			return err
+		}
+		if adopted {
+			log.Info("adopting existing static IP")
+			return nil
+		}
 	}
 
-	return nil
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		if len(region) == 0 {
+			_, err = p.computeSvc.GlobalAddresses.Insert(p.projectID, address).RequestId(reqID).Context(ctx).Do()
+		} else {
+			_, err = p.computeSvc.Addresses.Insert(p.projectID, region, address).RequestId(reqID).Context(ctx).Do()
+		}
+		return err
+	})
+	return wrapErr("CreateStaticIP", err)
 }
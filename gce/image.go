@@ -0,0 +1,124 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+	compute "google.golang.org/api/compute/v1"
+)
+
+// imageResponse returns the response describing an image
+func (p *Provider) imageResponse(img *compute.Image) *common.CreateImageResponse {
+	return &common.CreateImageResponse{
+		Name:    img.Name,
+		ImageID: p.ref(common.KindImage, img.Name, ""),
+		Image:   "projects/" + p.projectID + "/global/images/" + img.Name,
+		Source:  strings.TrimSuffix(lastSegment(img.SourceDisk), "-root-pd"),
+	}
+}
+
+// CreateImageFromServer creates a disk image from the -root-pd boot disk of an instance
+// on GCE and waits until it is ready. The disk of a running instance is imaged as is;
+// stop the instance first for a consistent image.
+func (p *Provider) CreateImageFromServer(ctx context.Context, server *common.CreateServerResponse, name string) (*common.CreateImageResponse, error) {
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
+		return nil, err
+	}
+	serverName, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+
+	image := &compute.Image{
+		Name:       name,
+		SourceDisk: "projects/" + p.projectID + "/zones/" + zone + "/disks/" + serverName + "-root-pd",
+		Labels:     p.resourceLabels(time.Time{}),
+	}
+
+	log := p.info.StartOp(ctx, "gce", common.OpCreateImage, name).With("server", serverName)
+	log.Info("creating image")
+	if log.DryRun("compute.images.insert", image) {
+		return p.imageResponse(image), log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Images.Insert(p.projectID, image).ForceCreate(true).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateImageFromServer", err))
+	}
+	log.Started(name)
+
+	err = wait.Poll(ctx, wait.Image, func(ctx context.Context) (bool, error) {
+		img, err := p.computeSvc.Images.Get(p.projectID, name).Context(ctx).Do()
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		log.Status(img.Status)
+		if img.Status == "FAILED" {
+			return false, fmt.Errorf("image %s failed", name)
+		}
+		return img.Status == "READY", nil
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateImageFromServer", err))
+	}
+
+	return p.imageResponse(image), log.Done(nil)
+}
+
+// ListImages lists the images of the project
+func (p *Provider) ListImages(ctx context.Context, opts ...common.ListOption) ([]*common.CreateImageResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*common.CreateImageResponse
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		images = nil
+		return p.computeSvc.Images.List(p.projectID).Pages(ctx, func(page *compute.ImageList) error {
+			for _, img := range page.Items {
+				if l.Match(img.Name, common.LabelTags(img.Labels)) {
+					images = append(images, p.imageResponse(img))
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, wrapErr("ListImages", err)
+	}
+
+	return images, nil
+}
+
+// RemoveImage removes an image on GCE
+func (p *Provider) RemoveImage(ctx context.Context, image *common.CreateImageResponse) error {
+	if err := image.ImageID.Check("gce", common.KindImage); err != nil {
+		return err
+	}
+	name, _ := resourceName(image.Name, "", image.ImageID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveImage, name)
+	log.Info("deleting image")
+	if log.DryRun("compute.images.delete", map[string]string{"project": p.projectID, "image": name}) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Images.Delete(p.projectID, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveImage", err))
+	}
+	log.Started(name)
+
+	return log.Done(nil)
+}
@@ -0,0 +1,302 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	compute "google.golang.org/api/compute/v1"
+	container "google.golang.org/api/container/v1"
+	dns "google.golang.org/api/dns/v1"
+)
+
+// serverResponse returns the response describing an instance
+func (p *Provider) serverResponse(ins *compute.Instance) *common.CreateServerResponse {
+	var serverIP string
+	if len(ins.NetworkInterfaces) > 0 && len(ins.NetworkInterfaces[0].AccessConfigs) > 0 {
+		serverIP = ins.NetworkInterfaces[0].AccessConfigs[0].NatIP
+	}
+
+	return &common.CreateServerResponse{
+		Name:         ins.Name,
+		ServerID:     p.ref(common.KindServer, ins.Name, lastSegment(ins.Zone)),
+		ServerRegion: lastSegment(ins.Zone),
+		ServerIP:     serverIP,
+		Expires:      common.Expires(common.LabelTags(ins.Labels)),
+	}
+}
+
+// instanceTags returns the network tags and labels of an instance
+func instanceTags(ins *compute.Instance) []string {
+	var tags []string
+	if ins.Tags != nil {
+		tags = append(tags, ins.Tags.Items...)
+	}
+	return append(tags, common.LabelTags(ins.Labels)...)
+}
+
+// ListServers lists the instances in the zone set with common.ListRegion, or in every zone
+func (p *Provider) ListServers(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []*common.CreateServerResponse
+	add := func(instances []*compute.Instance) {
+		for _, ins := range instances {
+			if l.Match(ins.Name, instanceTags(ins)) {
+				servers = append(servers, p.serverResponse(ins))
+			}
+		}
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		servers = nil
+		if len(l.Region) > 0 {
+			return p.computeSvc.Instances.List(p.projectID, l.Region).Pages(ctx, func(page *compute.InstanceList) error {
+				add(page.Items)
+				return nil
+			})
+		}
+		return p.computeSvc.Instances.AggregatedList(p.projectID).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+			for _, scoped := range page.Items {
+				add(scoped.Instances)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, wrapErr("ListServers", err)
+	}
+
+	return servers, nil
+}
+
+// GetServer returns the instance with the name, in the zone set with common.ListRegion or
+// in any zone
+func (p *Provider) GetServer(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateServerResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l.Region) > 0 {
+		var ins *compute.Instance
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			var err error
+			ins, err = p.computeSvc.Instances.Get(p.projectID, l.Region, name).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, wrapErr("GetServer", err)
+		}
+		return p.serverResponse(ins), nil
+	}
+
+	servers, err := p.ListServers(ctx, common.ListPrefix(name))
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		if server.Name == name {
+			return server, nil
+		}
+	}
+
+	return nil, common.NewError("gce", "GetServer", common.ErrNotFound, fmt.Errorf("instance %s", name))
+}
+
+// ListServerGroups unimplemented for GCE
+func (p *Provider) ListServerGroups(ctx context.Context, opts ...common.ListOption) ([]*common.CreateServerGroupResponse, error) {
+	return nil, common.NotImplemented("gce", "ListServerGroups")
+}
+
+// clusterResponse returns the response describing a cluster
+func (p *Provider) clusterResponse(cls *container.Cluster) *common.CreateK8sResponse {
+	credentials := &common.ClusterCredentials{}
+	if cls.MasterAuth != nil {
+		credentials = &common.ClusterCredentials{
+			Username:    cls.MasterAuth.Username,
+			Password:    cls.MasterAuth.Password,
+			Certificate: cls.MasterAuth.ClusterCaCertificate,
+		}
+	}
+
+	return &common.CreateK8sResponse{
+		Name:          cls.Name,
+		ClusterID:     p.ref(common.KindK8s, cls.Name, cls.Location),
+		ClusterRegion: cls.Location,
+		EndpointIP:    cls.Endpoint,
+		EndpointPort:  "443",
+		Credentials:   credentials,
+		Expires:       common.Expires(common.LabelTags(cls.ResourceLabels)),
+	}
+}
+
+// ListK8s lists the clusters in the zone set with common.ListRegion, or in every zone
+func (p *Provider) ListK8s(ctx context.Context, opts ...common.ListOption) ([]*common.CreateK8sResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := l.Region
+	if len(zone) == 0 {
+		zone = "-"
+	}
+
+	var resp *container.ListClustersResponse
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		resp, err = p.containerSvc.List(p.projectID, zone).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, wrapErr("ListK8s", err)
+	}
+
+	var clusters []*common.CreateK8sResponse
+	for _, cls := range resp.Clusters {
+		if l.Match(cls.Name, common.LabelTags(cls.ResourceLabels)) {
+			clusters = append(clusters, p.clusterResponse(cls))
+		}
+	}
+
+	return clusters, nil
+}
+
+// GetK8s returns the cluster with the name, in the zone set with common.ListRegion or
+// in any zone
+func (p *Provider) GetK8s(ctx context.Context, name string, opts ...common.ListOption) (*common.CreateK8sResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l.Region) > 0 {
+		var cls *container.Cluster
+		err = p.call(ctx, true, func(ctx context.Context) error {
+			var err error
+			cls, err = p.containerSvc.Get(p.projectID, l.Region, name).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, wrapErr("GetK8s", err)
+		}
+		return p.clusterResponse(cls), nil
+	}
+
+	clusters, err := p.ListK8s(ctx, common.ListPrefix(name))
+	if err != nil {
+		return nil, err
+	}
+	for _, cls := range clusters {
+		if cls.Name == name {
+			return cls, nil
+		}
+	}
+
+	return nil, common.NewError("gce", "GetK8s", common.ErrNotFound, fmt.Errorf("cluster %s", name))
+}
+
+// ListDNSRecords lists the A records of the domain. DNS records carry no tags.
+func (p *Provider) ListDNSRecords(ctx context.Context, opts ...common.ListOption) ([]*common.CreateDNSRecordResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := "." + p.domain + "."
+	var records []*common.CreateDNSRecordResponse
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		records = nil
+		return p.dnsSvc.ResourceRecordSets.List(p.projectID, p.dnsZone).Pages(ctx, func(page *dns.ResourceRecordSetsListResponse) error {
+			for _, rrset := range page.Rrsets {
+				if rrset.Type != "A" || !strings.HasSuffix(rrset.Name, suffix) || len(rrset.Rrdatas) == 0 {
+					continue
+				}
+
+				subDomain := strings.TrimSuffix(rrset.Name, suffix)
+				if l.Match(subDomain, nil) {
+					records = append(records, &common.CreateDNSRecordResponse{
+						SubDomain:   subDomain,
+						SubDomainID: p.ref(common.KindDNSRecord, rrset.Name, ""),
+						SubDomainIP: rrset.Rrdatas[0],
+					})
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, wrapErr("ListDNSRecords", err)
+	}
+
+	return records, nil
+}
+
+// addressTags returns the owner and expiry tags of an address, which are kept in its description
+func addressTags(addr *compute.Address) []string {
+	var tags []string
+	for _, tag := range strings.Fields(addr.Description) {
+		if strings.HasPrefix(tag, common.OwnerLabel+"=") || strings.HasPrefix(tag, common.ExpiresLabel+"=") {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ListStaticIPs lists the regional static IPs in the region set with common.ListRegion,
+// or the global and regional static IPs in every region
+func (p *Provider) ListStaticIPs(ctx context.Context, opts ...common.ListOption) ([]*common.CreateStaticIPResponse, error) {
+	l, err := common.NewListInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var staticIPs []*common.CreateStaticIPResponse
+	add := func(addresses []*compute.Address, ipType common.StaticIPType) {
+		for _, addr := range addresses {
+			if l.Match(addr.Name, addressTags(addr)) {
+				staticIPs = append(staticIPs, &common.CreateStaticIPResponse{
+					Name:     addr.Name,
+					StaticIP: addr.Address,
+					Type:     ipType,
+					Region:   lastSegment(addr.Region),
+					Expires:  common.Expires(addressTags(addr)),
+				})
+			}
+		}
+	}
+
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		staticIPs = nil
+		if len(l.Region) > 0 {
+			return p.computeSvc.Addresses.List(p.projectID, l.Region).Pages(ctx, func(page *compute.AddressList) error {
+				add(page.Items, common.REGIONAL)
+				return nil
+			})
+		}
+
+		err := p.computeSvc.GlobalAddresses.List(p.projectID).Pages(ctx, func(page *compute.AddressList) error {
+			add(page.Items, common.GLOBAL)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return p.computeSvc.Addresses.AggregatedList(p.projectID).Pages(ctx, func(page *compute.AddressAggregatedList) error {
+			for _, scoped := range page.Items {
+				add(scoped.Addresses, common.REGIONAL)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, wrapErr("ListStaticIPs", err)
+	}
+
+	return staticIPs, nil
+}
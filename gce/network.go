@@ -0,0 +1,164 @@
+package gce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	compute "google.golang.org/api/compute/v1"
+)
+
+// zoneRegion returns the region of a zone such as us-east1-c
+func zoneRegion(zone string) string {
+	if len(zone) < 3 {
+		return zone
+	}
+	return zone[:len(zone)-2]
+}
+
+// networkURLs returns the network and subnetwork in the region of a server or cluster,
+// the default ones if network is zero. A network created with CreateNetwork has a single
+// subnetwork of the same name.
+func (p *Provider) networkURLs(network common.Ref, region string) (string, string, error) {
+	prefix := "projects/" + p.projectID
+	if network.IsZero() {
+		return prefix + "/global/networks/default", prefix + "/regions/" + region + "/subnetworks/default", nil
+	}
+	if err := network.Check("gce", common.KindNetwork); err != nil {
+		return "", "", err
+	}
+	if len(network.Region) > 0 && network.Region != region {
+		return "", "", fmt.Errorf("gce: network %s is in region %s, not %s", network.ID, network.Region, region)
+	}
+	return prefix + "/global/networks/" + network.ID, prefix + "/regions/" + region + "/subnetworks/" + network.ID, nil
+}
+
+// waitRegionOp polls a regional operation until it is done and returns its error, if any
+func (p *Provider) waitRegionOp(ctx context.Context, log *common.OpLog, region string, op *compute.Operation) error {
+	return waitOp(ctx, log, func(ctx context.Context) (*compute.Operation, error) {
+		return p.computeSvc.RegionOperations.Get(p.projectID, region, op.Name).Context(ctx).Do()
+	})
+}
+
+// adoptable reports whether an insert failed only because the resource exists and
+// existing resources are adopted
+func (p *Provider) adoptable(err error) bool {
+	return p.info.Adopt && errors.Is(err, common.ErrAlreadyExists)
+}
+
+// CreateNetwork creates a custom mode VPC network on GCE with a subnetwork of the same
+// name covering the CIDR in the region. Firewalls of the network are created with
+// FirewallRequest.Network.
+func (p *Provider) CreateNetwork(ctx context.Context, name string, req *common.NetworkRequest) (*common.CreateNetworkResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if len(req.Region) == 0 {
+		return nil, fmt.Errorf("gce: CreateNetwork requires a region")
+	}
+
+	network := &compute.Network{
+		Name:                  name,
+		AutoCreateSubnetworks: false,
+		ForceSendFields:       []string{"AutoCreateSubnetworks"},
+	}
+	subnetwork := &compute.Subnetwork{
+		Name:        name,
+		Network:     "projects/" + p.projectID + "/global/networks/" + name,
+		IpCidrRange: req.CIDR,
+		Region:      req.Region,
+	}
+	resp := &common.CreateNetworkResponse{
+		Name:      name,
+		NetworkID: p.ref(common.KindNetwork, name, req.Region),
+		CIDR:      req.CIDR,
+		Region:    req.Region,
+	}
+
+	log := p.info.StartOp(ctx, "gce", common.OpCreateNetwork, name).With("region", req.Region)
+	log.Info("creating network", "cidr", req.CIDR)
+	if log.DryRun("compute.networks.insert", network) {
+		log.DryRun("compute.subnetworks.insert", subnetwork)
+		return resp, log.Done(nil)
+	}
+
+	var op *compute.Operation
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		op, err = p.computeSvc.Networks.Insert(p.projectID, network).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err = wrapErr("CreateNetwork", err); err != nil && !p.adoptable(err) {
+		return nil, log.Done(err)
+	}
+	if err == nil {
+		log.Started(name)
+		if err := p.waitGlobalOp(ctx, log, op); err != nil {
+			return nil, log.Done(wrapErr("CreateNetwork", err))
+		}
+	}
+
+	reqID = requestID()
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		op, err = p.computeSvc.Subnetworks.Insert(p.projectID, req.Region, subnetwork).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err == nil {
+		err = p.waitRegionOp(ctx, log, req.Region, op)
+	}
+	if err = wrapErr("CreateNetwork", err); err != nil && !p.adoptable(err) {
+		if rmErr := p.RemoveNetwork(context.WithoutCancel(ctx), resp); rmErr != nil {
+			log.Warn("could not remove the network", "error", rmErr)
+		}
+		return nil, log.Done(err)
+	}
+
+	return resp, log.Done(nil)
+}
+
+// RemoveNetwork removes a network and its subnetwork on GCE. Its instances, clusters and
+// firewalls must be removed first.
+func (p *Provider) RemoveNetwork(ctx context.Context, network *common.CreateNetworkResponse) error {
+	if err := network.NetworkID.Check("gce", common.KindNetwork); err != nil {
+		return err
+	}
+	name, region := resourceName(network.Name, network.Region, network.NetworkID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveNetwork, name).With("region", region)
+	log.Info("deleting network")
+	if log.DryRun("compute.subnetworks.delete", map[string]string{"project": p.projectID, "region": region, "subnetwork": name}) {
+		log.DryRun("compute.networks.delete", map[string]string{"project": p.projectID, "network": name})
+		return log.Done(nil)
+	}
+
+	var op *compute.Operation
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		op, err = p.computeSvc.Subnetworks.Delete(p.projectID, region, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err == nil {
+		err = p.waitRegionOp(ctx, log, region, op)
+	}
+	// a network whose subnetwork could not be created has none to delete
+	if err = wrapErr("RemoveNetwork", err); err != nil && !errors.Is(err, common.ErrNotFound) {
+		return log.Done(err)
+	}
+
+	reqID = requestID()
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		op, err = p.computeSvc.Networks.Delete(p.projectID, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveNetwork", err))
+	}
+	log.Started(name)
+
+	return log.Done(wrapErr("RemoveNetwork", p.waitGlobalOp(ctx, log, op)))
+}
@@ -0,0 +1,181 @@
+package gce
+
+import (
+	"context"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+	compute "google.golang.org/api/compute/v1"
+)
+
+// waitInstance polls the instance until it has the status
+func (p *Provider) waitInstance(ctx context.Context, log *common.OpLog, zone string, name string, status string) (*compute.Instance, error) {
+	var ins *compute.Instance
+	err := wait.Poll(ctx, wait.Server, func(ctx context.Context) (bool, error) {
+		var err error
+		ins, err = p.computeSvc.Instances.Get(p.projectID, zone, name).Context(ctx).Do()
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		log.Status(ins.Status)
+		return ins.Status == status, nil
+	})
+	return ins, err
+}
+
+// StopServer stops an instance on GCE and waits until it is terminated
+func (p *Provider) StopServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
+		return err
+	}
+	name, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpStopServer, name).With("zone", zone)
+	log.Info("stopping instance")
+	if log.DryRun("compute.instances.stop", map[string]string{"project": p.projectID, "zone": zone, "instance": name}) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Instances.Stop(p.projectID, zone, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("StopServer", err))
+	}
+	log.Started(name)
+
+	if _, err := p.waitInstance(ctx, log, zone, name, "TERMINATED"); err != nil {
+		return log.Done(wrapErr("StopServer", err))
+	}
+	return log.Done(nil)
+}
+
+// StartServer starts a stopped instance on GCE and waits until it is running. Instances
+// without a static IP get a new ephemeral IP.
+func (p *Provider) StartServer(ctx context.Context, server *common.CreateServerResponse) (*common.CreateServerResponse, error) {
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
+		return nil, err
+	}
+	name, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpStartServer, name).With("zone", zone)
+	log.Info("starting instance")
+	if log.DryRun("compute.instances.start", map[string]string{"project": p.projectID, "zone": zone, "instance": name}) {
+		return server, log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Instances.Start(p.projectID, zone, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("StartServer", err))
+	}
+	log.Started(name)
+
+	ins, err := p.waitInstance(ctx, log, zone, name, "RUNNING")
+	if err != nil {
+		return nil, log.Done(wrapErr("StartServer", err))
+	}
+	resp := p.serverResponse(ins)
+	log.IPAssigned(resp.ServerIP)
+
+	return resp, log.Done(nil)
+}
+
+// RebootServer resets an instance on GCE and waits until it is running
+func (p *Provider) RebootServer(ctx context.Context, server *common.CreateServerResponse) error {
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
+		return err
+	}
+	name, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRebootServer, name).With("zone", zone)
+	log.Info("resetting instance")
+	if log.DryRun("compute.instances.reset", map[string]string{"project": p.projectID, "zone": zone, "instance": name}) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Instances.Reset(p.projectID, zone, name).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RebootServer", err))
+	}
+	log.Started(name)
+
+	if _, err := p.waitInstance(ctx, log, zone, name, "RUNNING"); err != nil {
+		return log.Done(wrapErr("RebootServer", err))
+	}
+	return log.Done(nil)
+}
+
+// ResizeServer changes the machine type of an instance on GCE. A running instance is
+// stopped for the change and started again.
+func (p *Provider) ResizeServer(ctx context.Context, server *common.CreateServerResponse, size string) (*common.CreateServerResponse, error) {
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
+		return nil, err
+	}
+	name, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+
+	req := &compute.InstancesSetMachineTypeRequest{
+		MachineType: "projects/" + p.projectID + "/zones/" + zone + "/machineTypes/" + size,
+	}
+
+	log := p.info.StartOp(ctx, "gce", common.OpResizeServer, name).With("zone", zone, "size", size)
+	log.Info("changing machine type")
+	if log.DryRun("compute.instances.setMachineType", req) {
+		return server, log.Done(nil)
+	}
+
+	var ins *compute.Instance
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		ins, err = p.computeSvc.Instances.Get(p.projectID, zone, name).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("ResizeServer", err))
+	}
+
+	running := ins.Status != "TERMINATED"
+	if running {
+		if err := p.StopServer(ctx, server); err != nil {
+			return nil, log.Done(err)
+		}
+	}
+
+	reqID := requestID()
+	err = p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Instances.SetMachineType(p.projectID, zone, name, req).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("ResizeServer", err))
+	}
+	log.Started(name)
+
+	err = wait.Poll(ctx, wait.Server, func(ctx context.Context) (bool, error) {
+		ins, err = p.computeSvc.Instances.Get(p.projectID, zone, name).Context(ctx).Do()
+		if err != nil {
+			return false, pollErr(err)
+		}
+		return lastSegment(ins.MachineType) == size, nil
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("ResizeServer", err))
+	}
+
+	if !running {
+		return p.serverResponse(ins), log.Done(nil)
+	}
+
+	resp, err := p.StartServer(ctx, server)
+	return resp, log.Done(err)
+}
@@ -0,0 +1,79 @@
+package gce
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/retry"
+	"google.golang.org/api/googleapi"
+)
+
+// retryable reports whether a failed idempotent call should be retried
+func retryable(err error) (bool, time.Duration) {
+	if classify(err) != common.ErrTransient {
+		return false, 0
+	}
+
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		return true, retry.After(apiErr.Header)
+	}
+	return true, 0
+}
+
+// throttled reports whether a failed call was rejected by rate limiting before being
+// processed, so that even non-idempotent calls can be retried
+func throttled(err error) (bool, time.Duration) {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false, 0
+	}
+
+	limited := apiErr.Code == http.StatusTooManyRequests
+	for _, item := range apiErr.Errors {
+		if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+			limited = true
+		}
+	}
+	if !limited {
+		return false, 0
+	}
+
+	return true, retry.After(apiErr.Header)
+}
+
+// call runs fn with the retry policy of the provider. Idempotent calls are retried on
+// any transient error, other calls only when rate limited.
+func (p *Provider) call(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	classifier := throttled
+	if idempotent {
+		classifier = retryable
+	}
+	return p.info.Retry.Do(ctx, func(err error) (bool, time.Duration) {
+		ok, after := classifier(err)
+		if ok {
+			p.info.Log.Warn("retrying cloud API call", "provider", "gce", "error", err, "after", after)
+		}
+		return ok, after
+	}, fn)
+}
+
+// requestID returns a random UUID for making compute API inserts and deletes idempotent
+func requestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// pollErr returns nil for transient errors so that polling continues through them
+func pollErr(err error) error {
+	if ok, _ := retryable(err); ok {
+		return nil
+	}
+	return err
+}
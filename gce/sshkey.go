@@ -0,0 +1,160 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	compute "google.golang.org/api/compute/v1"
+)
+
+// sshKeysMetadata is the metadata key listing the keys of a project or instance as
+// user:key lines
+const sshKeysMetadata = "ssh-keys"
+
+// updateSSHKeys rewrites the ssh-keys lines of the project metadata and waits until the
+// update is applied
+func (p *Provider) updateSSHKeys(ctx context.Context, log *common.OpLog, op string, update func(lines []string) ([]string, error)) error {
+	var project *compute.Project
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		var err error
+		project, err = p.computeSvc.Projects.Get(p.projectID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return wrapErr(op, err)
+	}
+
+	md := project.CommonInstanceMetadata
+	if md == nil {
+		md = &compute.Metadata{}
+	}
+	var item *compute.MetadataItems
+	for _, i := range md.Items {
+		if i.Key == sshKeysMetadata {
+			item = i
+		}
+	}
+	if item == nil {
+		item = &compute.MetadataItems{Key: sshKeysMetadata, Value: new(string)}
+		md.Items = append(md.Items, item)
+	}
+
+	var lines []string
+	if item.Value != nil && len(*item.Value) > 0 {
+		lines = strings.Split(*item.Value, "\n")
+	}
+	lines, err = update(lines)
+	if err != nil {
+		return err
+	}
+	value := strings.Join(lines, "\n")
+	item.Value = &value
+
+	// the metadata fingerprint rejects concurrent updates, so a retried update would fail
+	var setOp *compute.Operation
+	err = p.call(ctx, false, func(ctx context.Context) error {
+		var err error
+		setOp, err = p.computeSvc.Projects.SetCommonInstanceMetadata(p.projectID, md).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return wrapErr(op, err)
+	}
+
+	return wrapErr(op, p.waitGlobalOp(ctx, log, setOp))
+}
+
+// withoutUser returns the ssh-keys lines that are not keys of the user
+func withoutUser(lines []string, user string) []string {
+	var out []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, user+":") {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// ImportSSHKey adds a public key to the ssh-keys metadata of the project on GCE, replacing
+// any key with the same name. The key name is the login user, and the key is accepted by
+// every instance of the project that does not block project keys.
+func (p *Provider) ImportSSHKey(ctx context.Context, name string, publicKey string) (*common.ImportSSHKeyResponse, error) {
+	fingerprint, err := common.SSHFingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	line := name + ":" + strings.TrimSpace(publicKey)
+	resp := &common.ImportSSHKeyResponse{
+		Name:        name,
+		KeyID:       p.ref(common.KindSSHKey, name, ""),
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+	}
+
+	log := p.info.StartOp(ctx, "gce", common.OpImportSSHKey, name)
+	log.Info("adding project SSH key", "fingerprint", fingerprint)
+	if log.DryRun("compute.projects.setCommonInstanceMetadata", map[string]string{"project": p.projectID, sshKeysMetadata: line}) {
+		return resp, log.Done(nil)
+	}
+
+	err = p.updateSSHKeys(ctx, log, "ImportSSHKey", func(lines []string) ([]string, error) {
+		return append(withoutUser(lines, name), line), nil
+	})
+	if err != nil {
+		return nil, log.Done(err)
+	}
+	log.Started(name)
+
+	return resp, log.Done(nil)
+}
+
+// RemoveSSHKey removes the keys of the name from the ssh-keys metadata of the project on GCE
+func (p *Provider) RemoveSSHKey(ctx context.Context, key *common.ImportSSHKeyResponse) error {
+	if err := key.KeyID.Check("gce", common.KindSSHKey); err != nil {
+		return err
+	}
+	name, _ := resourceName(key.Name, "", key.KeyID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveSSHKey, name)
+	log.Info("removing project SSH key")
+	if log.DryRun("compute.projects.setCommonInstanceMetadata", map[string]string{"project": p.projectID, "remove": name}) {
+		return log.Done(nil)
+	}
+
+	err := p.updateSSHKeys(ctx, log, "RemoveSSHKey", func(lines []string) ([]string, error) {
+		out := withoutUser(lines, name)
+		if len(out) == len(lines) {
+			return nil, common.NewError("gce", "RemoveSSHKey", common.ErrNotFound, fmt.Errorf("ssh key %s", name))
+		}
+		return out, nil
+	})
+	if err != nil {
+		return log.Done(err)
+	}
+	log.Started(name)
+
+	return log.Done(nil)
+}
+
+// instanceSSHKeys returns the ssh-keys metadata of an instance and the private key
+// generated with ServerEphemeralSSHKey. Imported keys are project metadata that
+// instances already accept, so they are only checked.
+func instanceSSHKeys(s *common.ServerInfo) (*compute.MetadataItems, string, error) {
+	for _, k := range s.SSHKeys {
+		if err := k.Check("gce", common.KindSSHKey); err != nil {
+			return nil, "", err
+		}
+	}
+	if !s.GenerateSSHKey {
+		return nil, "", nil
+	}
+
+	publicKey, privateKey, err := common.GenerateSSHKey(s.Name)
+	if err != nil {
+		return nil, "", err
+	}
+	line := common.SSHUser + ":" + publicKey
+	return &compute.MetadataItems{Key: sshKeysMetadata, Value: &line}, privateKey, nil
+}
@@ -0,0 +1,205 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/common/wait"
+	compute "google.golang.org/api/compute/v1"
+)
+
+// diskURL returns the partial URL of a persistent disk
+func (p *Provider) diskURL(zone string, name string) string {
+	return "projects/" + p.projectID + "/zones/" + zone + "/disks/" + name
+}
+
+// attachedDisk returns the attached disk of a persistent disk. The device name is the
+// disk name, so it can be detached by name.
+func (p *Provider) attachedDisk(zone string, name string) *compute.AttachedDisk {
+	return &compute.AttachedDisk{
+		Source:     p.diskURL(zone, name),
+		DeviceName: name,
+		Type:       "PERSISTENT",
+		Mode:       "READ_WRITE",
+		AutoDelete: false,
+	}
+}
+
+// volumeDisks returns the attached disks of the volumes of a server in the zone
+func (p *Provider) volumeDisks(zone string, volumes []common.Ref) ([]*compute.AttachedDisk, error) {
+	var disks []*compute.AttachedDisk
+	for _, v := range volumes {
+		if err := v.Check("gce", common.KindVolume); err != nil {
+			return nil, err
+		}
+		if len(v.Region) > 0 && v.Region != zone {
+			return nil, fmt.Errorf("gce: volume %s is in zone %s, not %s", v.ID, v.Region, zone)
+		}
+		disks = append(disks, p.attachedDisk(zone, v.ID))
+	}
+	return disks, nil
+}
+
+// waitDiskUser polls the disk until the instance is, or is no longer, one of its users
+func (p *Provider) waitDiskUser(ctx context.Context, log *common.OpLog, zone string, disk string, instance string, attached bool) error {
+	return wait.Poll(ctx, wait.Volume, func(ctx context.Context) (bool, error) {
+		d, err := p.computeSvc.Disks.Get(p.projectID, zone, disk).Context(ctx).Do()
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		found := false
+		for _, user := range d.Users {
+			if lastSegment(user) == instance {
+				found = true
+			}
+		}
+		log.Status(d.Status)
+		return found == attached, nil
+	})
+}
+
+// CreateVolume creates a persistent disk on GCE and waits until it is ready. The type is
+// a disk type such as pd-standard or pd-ssd.
+func (p *Provider) CreateVolume(ctx context.Context, name string, req *common.VolumeRequest) (*common.CreateVolumeResponse, error) {
+	zone := req.Region
+	disk := &compute.Disk{
+		Name:   name,
+		SizeGb: req.SizeGB,
+		Labels: p.resourceLabels(time.Time{}),
+	}
+	if len(req.Type) > 0 {
+		disk.Type = "projects/" + p.projectID + "/zones/" + zone + "/diskTypes/" + req.Type
+	}
+	resp := &common.CreateVolumeResponse{
+		Name:     name,
+		VolumeID: p.ref(common.KindVolume, name, zone),
+		Region:   zone,
+		SizeGB:   req.SizeGB,
+	}
+
+	log := p.info.StartOp(ctx, "gce", common.OpCreateVolume, name).With("zone", zone)
+	log.Info("creating disk", "size", req.SizeGB)
+	if log.DryRun("compute.disks.insert", disk) {
+		return resp, log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Disks.Insert(p.projectID, zone, disk).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateVolume", err))
+	}
+	log.Started(name)
+
+	err = wait.Poll(ctx, wait.Volume, func(ctx context.Context) (bool, error) {
+		d, err := p.computeSvc.Disks.Get(p.projectID, zone, name).Context(ctx).Do()
+		if err != nil {
+			return false, pollErr(err)
+		}
+
+		log.Status(d.Status)
+		if d.Status == "FAILED" {
+			return false, fmt.Errorf("disk %s failed", name)
+		}
+		resp.SizeGB = d.SizeGb
+		return d.Status == "READY", nil
+	})
+	if err != nil {
+		return nil, log.Done(wrapErr("CreateVolume", err))
+	}
+
+	return resp, log.Done(nil)
+}
+
+// AttachVolume attaches a persistent disk to an instance on GCE in read-write mode. The
+// disk is not deleted with the instance.
+func (p *Provider) AttachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := volume.VolumeID.Check("gce", common.KindVolume); err != nil {
+		return err
+	}
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
+		return err
+	}
+	disk, _ := resourceName(volume.Name, volume.Region, volume.VolumeID)
+	instance, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+	attached := p.attachedDisk(zone, disk)
+
+	log := p.info.StartOp(ctx, "gce", common.OpAttachVolume, disk).With("zone", zone, "server", instance)
+	log.Info("attaching disk")
+	if log.DryRun("compute.instances.attachDisk", attached) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Instances.AttachDisk(p.projectID, zone, instance, attached).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("AttachVolume", err))
+	}
+	log.Started(disk)
+
+	return log.Done(wrapErr("AttachVolume", p.waitDiskUser(ctx, log, zone, disk, instance, true)))
+}
+
+// DetachVolume detaches a persistent disk from an instance on GCE
+func (p *Provider) DetachVolume(ctx context.Context, volume *common.CreateVolumeResponse, server *common.CreateServerResponse) error {
+	if err := volume.VolumeID.Check("gce", common.KindVolume); err != nil {
+		return err
+	}
+	if err := server.ServerID.Check("gce", common.KindServer); err != nil {
+		return err
+	}
+	disk, _ := resourceName(volume.Name, volume.Region, volume.VolumeID)
+	instance, zone := resourceName(server.Name, server.ServerRegion, server.ServerID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpDetachVolume, disk).With("zone", zone, "server", instance)
+	log.Info("detaching disk")
+	if log.DryRun("compute.instances.detachDisk", map[string]string{"project": p.projectID, "zone": zone, "instance": instance, "deviceName": disk}) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Instances.DetachDisk(p.projectID, zone, instance, disk).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("DetachVolume", err))
+	}
+	log.Started(disk)
+
+	return log.Done(wrapErr("DetachVolume", p.waitDiskUser(ctx, log, zone, disk, instance, false)))
+}
+
+// RemoveVolume removes a persistent disk on GCE. The disk must not be attached.
+func (p *Provider) RemoveVolume(ctx context.Context, volume *common.CreateVolumeResponse) error {
+	if err := volume.VolumeID.Check("gce", common.KindVolume); err != nil {
+		return err
+	}
+	disk, zone := resourceName(volume.Name, volume.Region, volume.VolumeID)
+
+	log := p.info.StartOp(ctx, "gce", common.OpRemoveVolume, disk).With("zone", zone)
+	log.Info("deleting disk")
+	if log.DryRun("compute.disks.delete", map[string]string{"project": p.projectID, "zone": zone, "disk": disk}) {
+		return log.Done(nil)
+	}
+
+	reqID := requestID()
+	err := p.call(ctx, true, func(ctx context.Context) error {
+		_, err := p.computeSvc.Disks.Delete(p.projectID, zone, disk).RequestId(reqID).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return log.Done(wrapErr("RemoveVolume", err))
+	}
+	log.Started(disk)
+
+	return log.Done(nil)
+}
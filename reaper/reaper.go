@@ -0,0 +1,250 @@
+// Package reaper removes expired on-demand resources, labeled at creation with
+// common.ServerTTL, common.ServerExpires or StaticIPRequest.Expires
+package reaper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/state"
+)
+
+// Item is an expired resource
+type Item struct {
+	Provider string
+	Kind     state.Kind
+	Name     string
+	ID       string
+	IP       string
+	// Expires is when the resource expired. DNS records carry no expiry of their own and
+	// expire with the server, cluster or static IP whose IP they point to.
+	Expires time.Time
+	// Removed reports whether the resource was removed
+	Removed bool
+	// Err is the error removing the resource, if any
+	Err error
+
+	remove func(ctx context.Context) error
+}
+
+// Report lists expired resources in removal order
+type Report struct {
+	Items []*Item
+}
+
+// Err returns an error listing every failed removal, or nil
+func (r *Report) Err() error {
+	var failures []string
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failures = append(failures, fmt.Sprintf("removing %s %s %v: %v", item.Provider, item.Kind, item.Name, item.Err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("reap incomplete: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Write writes the report as a table
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tTYPE\tNAME\tID\tIP\tEXPIRES\tSTATUS")
+	for _, item := range r.Items {
+		status := "expired"
+		switch {
+		case item.Err != nil:
+			status = "failed: " + item.Err.Error()
+		case item.Removed:
+			status = "removed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			item.Provider, item.Kind, item.Name, item.ID, item.IP,
+			item.Expires.Local().Format(time.RFC3339), status)
+	}
+	return tw.Flush()
+}
+
+type namedProvider struct {
+	name string
+	p    cpt.CloudProvider
+}
+
+// Reaper finds and removes expired servers, clusters, static IPs and the DNS records
+// pointing to them across a set of providers
+type Reaper struct {
+	providers []namedProvider
+	grace     time.Duration
+}
+
+// New returns a Reaper that only considers resources expired once grace has passed
+// since their expiry
+func New(grace time.Duration) *Reaper {
+	return &Reaper{grace: grace}
+}
+
+// Add adds a provider to reap under the provider name
+func (r *Reaper) Add(name string, p cpt.CloudProvider) {
+	r.providers = append(r.providers, namedProvider{name, p})
+}
+
+// removeOrder lists resource types in the order they can safely be removed
+var removeOrder = []state.Kind{state.DNSRECORD, state.SERVER, state.K8S, state.STATICIP}
+
+// Find lists the resources of every provider and returns those that expired, in
+// removal order, without removing them. Resource types a provider cannot list are
+// skipped. A provider that fails to list its resources contributes none; the resources
+// of the other providers are returned along with the list errors.
+func (r *Reaper) Find(ctx context.Context) (*Report, error) {
+	byKind := make(map[state.Kind][]*Item)
+	var errs []error
+	for _, np := range r.providers {
+		items, err := r.find(ctx, np.name, np.p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listing %s: %w", np.name, err))
+			continue
+		}
+		for _, item := range items {
+			byKind[item.Kind] = append(byKind[item.Kind], item)
+		}
+	}
+
+	report := &Report{}
+	for _, kind := range removeOrder {
+		report.Items = append(report.Items, byKind[kind]...)
+	}
+	return report, errors.Join(errs...)
+}
+
+// find returns the expired resources of one provider
+func (r *Reaper) find(ctx context.Context, name string, p cpt.CloudProvider) ([]*Item, error) {
+	now := time.Now()
+	expired := func(t time.Time) bool {
+		return !t.IsZero() && now.After(t.Add(r.grace))
+	}
+	caps := p.Capabilities()
+
+	var items []*Item
+	// expiry of expired resources by IP, for the DNS records pointing to them
+	owners := make(map[string]time.Time)
+	// IPs of static IPs that have not expired, which outlive the resources using them
+	kept := make(map[string]bool)
+
+	if caps.Supports(common.OpListServers) {
+		servers, err := p.ListServers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range servers {
+			if !expired(s.Expires) {
+				continue
+			}
+			s := s
+			items = append(items, &Item{
+				Provider: name,
+				Kind:     state.SERVER,
+				Name:     s.Name,
+				ID:       s.ServerID.ID,
+				IP:       s.ServerIP,
+				Expires:  s.Expires,
+				remove:   func(ctx context.Context) error { return p.RemoveServer(ctx, s) },
+			})
+			owners[s.ServerIP] = s.Expires
+		}
+	}
+
+	if caps.Supports(common.OpListK8s) {
+		clusters, err := p.ListK8s(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range clusters {
+			if !expired(k.Expires) {
+				continue
+			}
+			k := k
+			items = append(items, &Item{
+				Provider: name,
+				Kind:     state.K8S,
+				Name:     k.Name,
+				ID:       k.ClusterID.ID,
+				IP:       k.EndpointIP,
+				Expires:  k.Expires,
+				remove:   func(ctx context.Context) error { return p.RemoveK8s(ctx, k) },
+			})
+			owners[k.EndpointIP] = k.Expires
+		}
+	}
+
+	if caps.Supports(common.OpListStaticIPs) {
+		staticIPs, err := p.ListStaticIPs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range staticIPs {
+			if !expired(ip.Expires) {
+				kept[ip.StaticIP] = true
+				continue
+			}
+			ip := ip
+			items = append(items, &Item{
+				Provider: name,
+				Kind:     state.STATICIP,
+				Name:     ip.Name,
+				ID:       ip.Name,
+				IP:       ip.StaticIP,
+				Expires:  ip.Expires,
+				remove:   func(ctx context.Context) error { return p.RemoveStaticIP(ctx, ip) },
+			})
+			owners[ip.StaticIP] = ip.Expires
+		}
+	}
+
+	if caps.Supports(common.OpListDNSRecords) && len(owners) > 0 {
+		records, err := p.ListDNSRecords(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range records {
+			expires, ok := owners[d.SubDomainIP]
+			if !ok || len(d.SubDomainIP) == 0 || kept[d.SubDomainIP] {
+				continue
+			}
+			d := d
+			items = append(items, &Item{
+				Provider: name,
+				Kind:     state.DNSRECORD,
+				Name:     d.SubDomain,
+				ID:       d.SubDomainID.ID,
+				IP:       d.SubDomainIP,
+				Expires:  expires,
+				remove:   func(ctx context.Context) error { return p.RemoveDNSRecord(ctx, d) },
+			})
+		}
+	}
+
+	return items, nil
+}
+
+// Reap removes the resources returned by Find in dependency-safe order: DNS records,
+// servers, clusters and finally static IPs. It keeps going after failures, which are
+// recorded in the report and returned together by Report.Err, along with the list
+// errors of Find.
+func (r *Reaper) Reap(ctx context.Context) (*Report, error) {
+	report, listErr := r.Find(ctx)
+
+	for _, item := range report.Items {
+		item.Err = item.remove(ctx)
+		item.Removed = item.Err == nil
+	}
+
+	return report, errors.Join(listErr, report.Err())
+}
@@ -0,0 +1,194 @@
+package reaper_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/fake"
+	"github.com/sas-fe/cloud-provider-tools/reaper"
+)
+
+// setup returns a provider with expired and live servers and static IPs, and DNS records
+// pointing to their IPs
+func setup(t *testing.T) *fake.Provider {
+	ctx := context.Background()
+	expired := time.Now().Add(-time.Hour)
+	live := time.Now().Add(time.Hour)
+
+	p := fake.NewProvider()
+	old, err := p.CreateServer(ctx, "demo-1", common.ServerExpires(expired))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := p.CreateServer(ctx, "demo-2", common.ServerExpires(live))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.CreateServer(ctx, "demo-3"); err != nil {
+		t.Fatal(err)
+	}
+	ip, err := p.CreateStaticIP(ctx, "ip-1", &common.StaticIPRequest{IPType: common.GLOBAL, Expires: expired})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foo, err := p.CreateStaticIP(ctx, "foo-ip", &common.StaticIPRequest{IPType: common.GLOBAL, Expires: expired})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := p.CreateStaticIP(ctx, "ip-2", &common.StaticIPRequest{IPType: common.GLOBAL, Expires: live})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []struct {
+		subDomain string
+		ip        string
+	}{
+		{"demo-1", old.ServerIP},
+		{"demo-1.instances", old.ServerIP},
+		// named after the expired server, but pointing elsewhere
+		{"demo-1.alias", current.ServerIP},
+		// pointing to the expired server, but named after another resource
+		{"other", old.ServerIP},
+		{"demo-2", current.ServerIP},
+		{"ip-1", ip.StaticIP},
+		// pointing to a static IP named after it, as in examples/cluster
+		{"foo", foo.StaticIP},
+		{"ip-2", kept.StaticIP},
+	}
+	for _, r := range records {
+		if _, err := p.CreateDNSRecord(ctx, r.subDomain, r.ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return p
+}
+
+func names(items []*reaper.Item) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, fmt.Sprintf("%s %s", item.Kind, item.Name))
+	}
+	return out
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name  string
+		grace time.Duration
+		want  []string
+	}{
+		{"expired", 0, []string{"dns demo-1", "dns demo-1.instances", "dns foo", "dns ip-1", "dns other", "server demo-1", "staticip foo-ip", "staticip ip-1"}},
+		{"within grace", 2 * time.Hour, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setup(t)
+			r := reaper.New(tt.grace)
+			r.Add("fake", p)
+
+			report, err := r.Find(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(report.Items); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("Find() = %v, want %v", got, tt.want)
+			}
+			if len(p.Servers()) != 3 || len(p.DNSRecords()) != 8 {
+				t.Fatalf("Find() removed resources")
+			}
+		})
+	}
+}
+
+func TestReap(t *testing.T) {
+	denied := common.NewError("fake", "Remove", common.ErrPermissionDenied, errors.New("forbidden"))
+
+	tests := []struct {
+		name     string
+		failures map[fake.Op][]error
+		listErr  bool
+		// wantFailed are the items that could not be removed
+		wantFailed []string
+		// wantServers and wantRecords are the servers and DNS records left live
+		wantServers int
+		wantRecords int
+	}{
+		{name: "removed", wantServers: 2, wantRecords: 3},
+		{
+			name:        "server fails",
+			failures:    map[fake.Op][]error{fake.RemoveServer: {denied}},
+			wantFailed:  []string{"server demo-1"},
+			wantServers: 3,
+			wantRecords: 3,
+		},
+		{
+			name:        "record fails",
+			failures:    map[fake.Op][]error{fake.RemoveDNSRecord: {nil, denied}},
+			wantFailed:  []string{"dns demo-1.instances"},
+			wantServers: 2,
+			wantRecords: 4,
+		},
+		{
+			name:        "list fails",
+			failures:    map[fake.Op][]error{fake.ListStaticIPs: {denied}},
+			listErr:     true,
+			wantServers: 3,
+			wantRecords: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setup(t)
+			for op, errs := range tt.failures {
+				p.FailNext(op, errs...)
+			}
+			// a provider failing to list does not keep the others from being reaped
+			other := setup(t)
+			r := reaper.New(0)
+			r.Add("fake", p)
+			r.Add("other", other)
+
+			report, err := r.Reap(context.Background())
+			if (err != nil) != (len(tt.wantFailed) > 0 || tt.listErr) {
+				t.Fatalf("Reap() error = %v, want failures %v and list error %v", err, tt.wantFailed, tt.listErr)
+			}
+			if tt.listErr && !errors.Is(err, common.ErrPermissionDenied) {
+				t.Fatalf("Reap() error = %v, want the list error", err)
+			}
+
+			var failed []*reaper.Item
+			for _, item := range report.Items {
+				if item.Err != nil {
+					failed = append(failed, item)
+				} else if !item.Removed {
+					t.Fatalf("%s %s neither removed nor failed", item.Kind, item.Name)
+				}
+			}
+			if got := names(failed); fmt.Sprint(got) != fmt.Sprint(tt.wantFailed) {
+				t.Fatalf("failed to remove %v, want %v", got, tt.wantFailed)
+			}
+
+			// removal keeps going after failures
+			wantStaticIPs := 1
+			if tt.listErr {
+				wantStaticIPs = 3
+			}
+			if len(p.Servers()) != tt.wantServers || len(p.DNSRecords()) != tt.wantRecords || len(p.StaticIPs()) != wantStaticIPs {
+				t.Fatalf("%d servers, %d DNS records and %d static IPs live, want %d, %d and %d",
+					len(p.Servers()), len(p.DNSRecords()), len(p.StaticIPs()), tt.wantServers, tt.wantRecords, wantStaticIPs)
+			}
+			if len(other.Servers()) != 2 || len(other.DNSRecords()) != 3 || len(other.StaticIPs()) != 1 {
+				t.Fatalf("other provider has %d servers, %d DNS records and %d static IPs live, want 2, 3 and 1",
+					len(other.Servers()), len(other.DNSRecords()), len(other.StaticIPs()))
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package cpt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// Factory creates a CloudProvider instance from a validated Config
+type Factory func(cfg *Config) (CloudProvider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a provider available by name to NewCloudProviderByName.
+// Provider packages outside this repository call it from an init function.
+// It panics if the factory is nil or the name is already registered.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("cpt: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("cpt: Register called twice for provider " + name)
+	}
+	factories[name] = factory
+}
+
+// Providers returns a sorted list of the names of the registered providers
+func Providers() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCloudProviderByName returns a CloudProvider instance from the factory registered
+// under name, configured from the environment
+func NewCloudProviderByName(name string) (CloudProvider, error) {
+	return NewCloudProviderFromConfig(ConfigFromEnv(name))
+}
+
+// NewCloudProviderFromConfig validates cfg and returns a CloudProvider instance from the
+// factory registered under cfg.Provider. The default region, size and image in cfg are
+// applied to every server and cluster created through it that supports them; a default
+// the provider supports for none of them is a config error. The provider is then wrapped,
+// and its Unwrap method returns the one the factory created.
+func NewCloudProviderFromConfig(cfg *Config) (CloudProvider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[cfg.Provider]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("Provider Not Implemented: %q (registered: %v)", cfg.Provider, Providers())
+	}
+
+	p, err := factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts := cfg.serverOptions(); len(opts) > 0 {
+		dp, err := newDefaultsProvider(p, opts)
+		if err != nil {
+			return nil, err
+		}
+		p = dp
+	}
+	return p, nil
+}
+
+// defaultOps are the operations default server options apply to
+var defaultOps = []common.Operation{common.OpCreateServer, common.OpCreateServerGroup, common.OpCreateK8s}
+
+// defaultsProvider prepends default server options to every server and cluster creation.
+// Each operation only gets the defaults the provider supports for it, so adding them never
+// makes options that pass Capabilities().Check unsupported.
+type defaultsProvider struct {
+	CloudProvider
+	defaults map[common.Operation][]common.ServerOption
+}
+
+// newDefaultsProvider returns p applying the default options, or a *ConfigError if p
+// supports one of them for none of the operations
+func newDefaultsProvider(p CloudProvider, opts []common.ServerOption) (*defaultsProvider, error) {
+	caps := p.Capabilities()
+	defaults := make(map[common.Operation][]common.ServerOption)
+
+	var problems []string
+	for _, opt := range opts {
+		supported := false
+		for _, op := range defaultOps {
+			if caps.Supports(op) && caps.Check(op, opt) == nil {
+				defaults[op] = append(defaults[op], opt)
+				supported = true
+			}
+		}
+		if !supported {
+			problems = append(problems, fmt.Sprintf("%s is not supported by provider %q", strings.ToLower(string(common.NameOf(opt))), caps.Provider))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ConfigError{problems}
+	}
+	return &defaultsProvider{p, defaults}, nil
+}
+
+// Unwrap returns the provider created by the factory, for type assertions
+func (p *defaultsProvider) Unwrap() CloudProvider {
+	return p.CloudProvider
+}
+
+func (p *defaultsProvider) withDefaults(op common.Operation, opts []common.ServerOption) []common.ServerOption {
+	return append(append([]common.ServerOption{}, p.defaults[op]...), opts...)
+}
+
+func (p *defaultsProvider) CreateServer(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerResponse, error) {
+	return p.CloudProvider.CreateServer(ctx, name, p.withDefaults(common.OpCreateServer, opts)...)
+}
+
+func (p *defaultsProvider) CreateServerGroup(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerGroupResponse, error) {
+	return p.CloudProvider.CreateServerGroup(ctx, name, p.withDefaults(common.OpCreateServerGroup, opts)...)
+}
+
+func (p *defaultsProvider) CreateK8s(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateK8sResponse, error) {
+	return p.CloudProvider.CreateK8s(ctx, name, p.withDefaults(common.OpCreateK8s, opts)...)
+}
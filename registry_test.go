@@ -0,0 +1,70 @@
+package cpt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/fake"
+)
+
+// limited is a fake provider creating servers in any region, and clusters in the
+// default region only
+var limited = fake.NewProvider()
+
+func init() {
+	limited.SetCapabilities(&common.Capabilities{
+		Provider: "limited",
+		Operations: map[common.Operation][]common.OptionName{
+			common.OpCreateServer: {common.OptRegion, common.OptSize},
+			common.OpCreateK8s:    {common.OptSize},
+		},
+	})
+	cpt.Register("limited", func(cfg *cpt.Config) (cpt.CloudProvider, error) {
+		return limited, nil
+	})
+}
+
+func TestConfigDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := cpt.NewCloudProviderFromConfig(&cpt.Config{Provider: "limited", Region: "r1", Size: "small"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := p.(interface{ Unwrap() cpt.CloudProvider }); !ok || u.Unwrap() != limited {
+		t.Fatalf("NewCloudProviderFromConfig() = %T, want it to unwrap to the fake provider", p)
+	}
+
+	server, err := p.CreateServer(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ServerRegion != "r1" {
+		t.Fatalf("server created in region %q, want the default r1", server.ServerRegion)
+	}
+	server, err = p.CreateServer(ctx, "api", common.ServerRegion("r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ServerRegion != "r2" {
+		t.Fatalf("server created in region %q, want r2", server.ServerRegion)
+	}
+
+	// the default region is not applied to clusters, which do not support it
+	cluster, err := p.CreateK8s(ctx, "k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster.ClusterRegion != "" {
+		t.Fatalf("cluster created in region %q, want the default", cluster.ClusterRegion)
+	}
+
+	_, err = cpt.NewCloudProviderFromConfig(&cpt.Config{Provider: "limited", Image: "ubuntu"})
+	var configErr *cpt.ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 1 {
+		t.Fatalf("NewCloudProviderFromConfig() error = %v, want the unsupported image reported", err)
+	}
+}
@@ -0,0 +1,382 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// Provider wraps a cpt.CloudProvider and records every created resource in a state File
+type Provider struct {
+	cpt.CloudProvider
+	name  string
+	state *File
+}
+
+// Track returns a Provider recording resources created through p, under the provider
+// name, in state. Resources are recorded as soon as creation returns, including those a
+// provider returns along with an error, and forgotten once removal succeeds or finds them
+// already gone.
+func Track(p cpt.CloudProvider, name string, state *File) *Provider {
+	return &Provider{p, name, state}
+}
+
+func serverInfo(name string, opts []common.ServerOption) *common.ServerInfo {
+	s := &common.ServerInfo{
+		Name: name,
+	}
+
+	for _, opt := range opts {
+		opt.Set(s)
+	}
+
+	return s
+}
+
+// forget drops the record of a resource once removing it succeeded, or found that it
+// was already gone
+func (p *Provider) forget(kind Kind, id common.Ref, err error) error {
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		return err
+	}
+	return p.state.Remove(p.name, kind, id)
+}
+
+// recordErr reports a resource that was created but could not be recorded
+func recordErr(kind Kind, name string, err error) error {
+	return fmt.Errorf("%s %v was created but not recorded in state: %v", kind, name, err)
+}
+
+// CreateServer creates a server and records it
+func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerResponse, error) {
+	resp, err := p.CloudProvider.CreateServer(ctx, name, opts...)
+	if resp == nil {
+		return nil, err
+	}
+
+	r := &Resource{
+		Provider: p.name,
+		Type:     SERVER,
+		ID:       resp.ServerID,
+		Name:     resp.Name,
+		Region:   resp.ServerRegion,
+		IP:       resp.ServerIP,
+		Tags:     serverInfo(name, opts).Tags,
+	}
+	if !resp.FirewallID.IsZero() {
+		r.FirewallID = &resp.FirewallID
+	}
+	if !resp.SSHKeyID.IsZero() {
+		r.SSHKeyID = &resp.SSHKeyID
+	}
+	if addErr := p.state.Add(r); addErr != nil {
+		return resp, recordErr(SERVER, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveServer removes a server and forgets it
+func (p *Provider) RemoveServer(ctx context.Context, server *common.CreateServerResponse) error {
+	return p.forget(SERVER, server.ServerID, p.CloudProvider.RemoveServer(ctx, server))
+}
+
+// CreateServerGroup creates a server group and records it
+func (p *Provider) CreateServerGroup(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerGroupResponse, error) {
+	resp, err := p.CloudProvider.CreateServerGroup(ctx, name, opts...)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider:       p.name,
+		Type:           SERVERGROUP,
+		ID:             resp.ServerGroupID,
+		Name:           resp.Name,
+		Region:         resp.ServerGroupRegion,
+		IP:             resp.LoadBalancerIP,
+		Tags:           serverInfo(name, opts).Tags,
+		LoadBalancerID: resp.LoadBalancerID,
+	})
+	if addErr != nil {
+		return resp, recordErr(SERVERGROUP, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveServerGroup removes a server group and forgets it
+func (p *Provider) RemoveServerGroup(ctx context.Context, group *common.CreateServerGroupResponse) error {
+	return p.forget(SERVERGROUP, group.ServerGroupID, p.CloudProvider.RemoveServerGroup(ctx, group))
+}
+
+// CreateK8s creates a k8s cluster and records it
+func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateK8sResponse, error) {
+	resp, err := p.CloudProvider.CreateK8s(ctx, name, opts...)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider: p.name,
+		Type:     K8S,
+		ID:       resp.ClusterID,
+		Name:     resp.Name,
+		Region:   resp.ClusterRegion,
+		IP:       resp.EndpointIP,
+		Tags:     serverInfo(name, opts).Tags,
+		Port:     resp.EndpointPort,
+	})
+	if addErr != nil {
+		return resp, recordErr(K8S, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveK8s removes a k8s cluster and forgets it
+func (p *Provider) RemoveK8s(ctx context.Context, k8s *common.CreateK8sResponse) error {
+	return p.forget(K8S, k8s.ClusterID, p.CloudProvider.RemoveK8s(ctx, k8s))
+}
+
+// CreateDNSRecord creates a DNS A Record and records it
+func (p *Provider) CreateDNSRecord(ctx context.Context, subDomain string, IP string) (*common.CreateDNSRecordResponse, error) {
+	resp, err := p.CloudProvider.CreateDNSRecord(ctx, subDomain, IP)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider: p.name,
+		Type:     DNSRECORD,
+		ID:       resp.SubDomainID,
+		Name:     resp.SubDomain,
+		IP:       IP,
+	})
+	if addErr != nil {
+		return resp, recordErr(DNSRECORD, subDomain, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveDNSRecord removes a DNS A Record and forgets it
+func (p *Provider) RemoveDNSRecord(ctx context.Context, subDomain *common.CreateDNSRecordResponse) error {
+	return p.forget(DNSRECORD, subDomain.SubDomainID, p.CloudProvider.RemoveDNSRecord(ctx, subDomain))
+}
+
+// CreateStaticIP creates a static IP and records it. Static IPs are recorded by name.
+func (p *Provider) CreateStaticIP(ctx context.Context, name string, req *common.StaticIPRequest) (*common.CreateStaticIPResponse, error) {
+	resp, err := p.CloudProvider.CreateStaticIP(ctx, name, req)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider:     p.name,
+		Type:         STATICIP,
+		ID:           common.Ref{Provider: p.name, Kind: common.KindStaticIP, ID: resp.Name, Region: resp.Region},
+		Name:         resp.Name,
+		Region:       resp.Region,
+		IP:           resp.StaticIP,
+		StaticIPType: resp.Type,
+	})
+	if addErr != nil {
+		return resp, recordErr(STATICIP, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveStaticIP removes a static IP and forgets it
+func (p *Provider) RemoveStaticIP(ctx context.Context, staticIP *common.CreateStaticIPResponse) error {
+	id := common.Ref{Provider: p.name, Kind: STATICIP, ID: staticIP.Name}
+	return p.forget(STATICIP, id, p.CloudProvider.RemoveStaticIP(ctx, staticIP))
+}
+
+// CreateImageFromServer creates a server image and records it
+func (p *Provider) CreateImageFromServer(ctx context.Context, server *common.CreateServerResponse, name string) (*common.CreateImageResponse, error) {
+	resp, err := p.CloudProvider.CreateImageFromServer(ctx, server, name)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider: p.name,
+		Type:     IMAGE,
+		ID:       resp.ImageID,
+		Name:     resp.Name,
+		Image:    resp.Image,
+	})
+	if addErr != nil {
+		return resp, recordErr(IMAGE, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveImage removes a server image and forgets it
+func (p *Provider) RemoveImage(ctx context.Context, image *common.CreateImageResponse) error {
+	return p.forget(IMAGE, image.ImageID, p.CloudProvider.RemoveImage(ctx, image))
+}
+
+// CreateVolume creates a volume and records it
+func (p *Provider) CreateVolume(ctx context.Context, name string, req *common.VolumeRequest) (*common.CreateVolumeResponse, error) {
+	resp, err := p.CloudProvider.CreateVolume(ctx, name, req)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider: p.name,
+		Type:     VOLUME,
+		ID:       resp.VolumeID,
+		Name:     resp.Name,
+		Region:   resp.Region,
+		SizeGB:   resp.SizeGB,
+	})
+	if addErr != nil {
+		return resp, recordErr(VOLUME, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveVolume removes a volume and forgets it
+func (p *Provider) RemoveVolume(ctx context.Context, volume *common.CreateVolumeResponse) error {
+	return p.forget(VOLUME, volume.VolumeID, p.CloudProvider.RemoveVolume(ctx, volume))
+}
+
+// CreateFirewall creates a firewall and records it
+func (p *Provider) CreateFirewall(ctx context.Context, name string, req *common.FirewallRequest) (*common.CreateFirewallResponse, error) {
+	resp, err := p.CloudProvider.CreateFirewall(ctx, name, req)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider: p.name,
+		Type:     FIREWALL,
+		ID:       resp.FirewallID,
+		Name:     resp.Name,
+		Tags:     resp.TargetTags,
+	})
+	if addErr != nil {
+		return resp, recordErr(FIREWALL, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveFirewall removes a firewall and forgets it
+func (p *Provider) RemoveFirewall(ctx context.Context, firewall *common.CreateFirewallResponse) error {
+	return p.forget(FIREWALL, firewall.FirewallID, p.CloudProvider.RemoveFirewall(ctx, firewall))
+}
+
+// ImportSSHKey imports an SSH key and records it
+func (p *Provider) ImportSSHKey(ctx context.Context, name string, publicKey string) (*common.ImportSSHKeyResponse, error) {
+	resp, err := p.CloudProvider.ImportSSHKey(ctx, name, publicKey)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider:    p.name,
+		Type:        SSHKEY,
+		ID:          resp.KeyID,
+		Name:        resp.Name,
+		Fingerprint: resp.Fingerprint,
+	})
+	if addErr != nil {
+		return resp, recordErr(SSHKEY, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveSSHKey removes an SSH key and forgets it
+func (p *Provider) RemoveSSHKey(ctx context.Context, key *common.ImportSSHKeyResponse) error {
+	return p.forget(SSHKEY, key.KeyID, p.CloudProvider.RemoveSSHKey(ctx, key))
+}
+
+// CreateNetwork creates a network and records it
+func (p *Provider) CreateNetwork(ctx context.Context, name string, req *common.NetworkRequest) (*common.CreateNetworkResponse, error) {
+	resp, err := p.CloudProvider.CreateNetwork(ctx, name, req)
+	if resp == nil {
+		return nil, err
+	}
+
+	addErr := p.state.Add(&Resource{
+		Provider: p.name,
+		Type:     NETWORK,
+		ID:       resp.NetworkID,
+		Name:     resp.Name,
+		Region:   resp.Region,
+		CIDR:     resp.CIDR,
+	})
+	if addErr != nil {
+		return resp, recordErr(NETWORK, name, addErr)
+	}
+
+	return resp, err
+}
+
+// RemoveNetwork removes a network and forgets it
+func (p *Provider) RemoveNetwork(ctx context.Context, network *common.CreateNetworkResponse) error {
+	return p.forget(NETWORK, network.NetworkID, p.CloudProvider.RemoveNetwork(ctx, network))
+}
+
+// destroyOrder lists resource types in the order they can safely be removed
+var destroyOrder = []Kind{DNSRECORD, SERVER, SERVERGROUP, K8S, STATICIP, FIREWALL, SSHKEY, NETWORK, VOLUME, IMAGE}
+
+// Destroy removes every resource recorded in the state under the name of p, in
+// dependency-safe order: DNS records, servers, server groups, clusters, static IPs,
+// firewalls, SSH keys, networks, volumes and finally server images. It keeps going after
+// failures and reports all of them together.
+func (p *Provider) Destroy(ctx context.Context) error {
+	var failures []string
+
+	for _, kind := range destroyOrder {
+		for _, r := range p.state.Resources() {
+			if r.Provider != p.name || r.Type != kind {
+				continue
+			}
+
+			var err error
+			switch kind {
+			case DNSRECORD:
+				err = p.RemoveDNSRecord(ctx, r.DNSRecord())
+			case SERVER:
+				err = p.RemoveServer(ctx, r.Server())
+			case SERVERGROUP:
+				err = p.RemoveServerGroup(ctx, r.ServerGroup())
+			case K8S:
+				err = p.RemoveK8s(ctx, r.K8s())
+			case STATICIP:
+				err = p.RemoveStaticIP(ctx, r.StaticIP())
+			case FIREWALL:
+				err = p.RemoveFirewall(ctx, r.Firewall())
+			case SSHKEY:
+				err = p.RemoveSSHKey(ctx, r.SSHKey())
+			case NETWORK:
+				err = p.RemoveNetwork(ctx, r.Network())
+			case VOLUME:
+				err = p.RemoveVolume(ctx, r.Volume())
+			case IMAGE:
+				err = p.RemoveImage(ctx, r.ServerImage())
+			}
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("removing %s %v: %v", kind, r.Name, err))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("destroy incomplete: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
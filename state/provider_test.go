@@ -0,0 +1,180 @@
+package state_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/fake"
+	"github.com/sas-fe/cloud-provider-tools/state"
+)
+
+var errSetup = errors.New("server did not become ready")
+
+// errContains reports whether err contains want, or is nil if want is empty
+func errContains(err error, want string) bool {
+	if err == nil {
+		return len(want) == 0
+	}
+	return len(want) > 0 && strings.Contains(err.Error(), want)
+}
+
+func TestCreateServer(t *testing.T) {
+	transient := common.NewError("fake", "CreateServer", common.ErrTransient, errors.New("rate limited"))
+
+	tests := []struct {
+		name        string
+		partial     bool
+		failure     error
+		badPath     bool
+		wantErr     string
+		wantResp    bool
+		wantRecords int
+	}{
+		{name: "created", wantResp: true, wantRecords: 1},
+		{name: "failed", failure: transient, wantErr: "transient"},
+		{name: "partial", partial: true, wantErr: errSetup.Error(), wantResp: true, wantRecords: 1},
+		{name: "not recorded", badPath: true, wantErr: "created but not recorded", wantResp: true},
+		{name: "partial not recorded", partial: true, badPath: true, wantErr: "created but not recorded", wantResp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if tt.badPath {
+				path = filepath.Join(t.TempDir(), "missing", "state.json")
+			}
+			f, err := state.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fp := fake.NewProvider()
+			fp.FailNext(fake.CreateServer, tt.failure)
+			if tt.partial {
+				fp.FailAfter(fake.CreateServer, errSetup)
+			}
+			p := state.Track(fp, "fake", f)
+
+			resp, err := p.CreateServer(context.Background(), "web", common.ServerTags([]string{"web"}))
+			if !errContains(err, tt.wantErr) {
+				t.Fatalf("CreateServer() error = %v, want %q", err, tt.wantErr)
+			}
+			if (resp != nil) != tt.wantResp {
+				t.Fatalf("CreateServer() response = %+v, want response %v", resp, tt.wantResp)
+			}
+
+			servers := f.Servers()
+			if len(servers) != tt.wantRecords {
+				t.Fatalf("recorded %d servers, want %d", len(servers), tt.wantRecords)
+			}
+			if tt.wantRecords == 0 {
+				return
+			}
+			if servers[0].ServerID != resp.ServerID || servers[0].ServerIP != resp.ServerIP {
+				t.Fatalf("recorded %+v, want %+v", servers[0], resp)
+			}
+
+			// the state file can be reopened
+			reopened, err := state.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := reopened.Servers(); len(got) != 1 || got[0].ServerID != resp.ServerID {
+				t.Fatalf("reopened state has servers %+v, want %v", got, resp.ServerID)
+			}
+
+			if err := p.RemoveServer(context.Background(), servers[0]); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Servers(); len(got) != 0 {
+				t.Fatalf("removed server is still recorded: %+v", got)
+			}
+		})
+	}
+}
+
+func TestDestroy(t *testing.T) {
+	transient := common.NewError("fake", "Remove", common.ErrTransient, errors.New("rate limited"))
+
+	tests := []struct {
+		name    string
+		op      fake.Op
+		failure error
+		// gone removes the server behind the back of the state first
+		gone bool
+		// wantLeft are the kinds left recorded after the first Destroy
+		wantLeft []state.Kind
+	}{
+		{"removed", fake.RemoveServer, nil, false, nil},
+		{"server fails", fake.RemoveServer, transient, false, []state.Kind{state.SERVER}},
+		{"record fails", fake.RemoveDNSRecord, transient, false, []state.Kind{state.DNSRECORD}},
+		{"server gone", fake.RemoveServer, nil, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fp := fake.NewProvider()
+			p := state.Track(fp, "fake", f)
+
+			server, err := p.CreateServer(ctx, "web")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.CreateDNSRecord(ctx, "web", server.ServerIP); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.CreateStaticIP(ctx, "web-ip", &common.StaticIPRequest{IPType: common.GLOBAL}); err != nil {
+				t.Fatal(err)
+			}
+			// resources recorded under other names are left alone, even with the same ID
+			other, err := state.Track(fake.NewProvider(), "other", f).CreateServer(ctx, "db")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if other.ServerID.ID != server.ServerID.ID {
+				t.Fatalf("other server has ID %v, want %v", other.ServerID.ID, server.ServerID.ID)
+			}
+			if tt.gone {
+				if err := fp.RemoveServer(ctx, server); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fp.FailNext(tt.op, tt.failure)
+			err = p.Destroy(ctx)
+			if (err != nil) != (tt.failure != nil) {
+				t.Fatalf("Destroy() error = %v, want failure %v", err, tt.failure)
+			}
+
+			var left []state.Kind
+			for _, r := range f.Resources() {
+				if r.Provider == "fake" {
+					left = append(left, r.Type)
+				}
+			}
+			if len(left) != len(tt.wantLeft) || len(left) > 0 && left[0] != tt.wantLeft[0] {
+				t.Fatalf("left %v recorded, want %v", left, tt.wantLeft)
+			}
+			if live := len(fp.Servers()) + len(fp.DNSRecords()) + len(fp.StaticIPs()); live != len(left) {
+				t.Fatalf("%d resources live, want %d", live, len(left))
+			}
+
+			// a second Destroy removes what is left
+			if err := p.Destroy(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Resources(); len(got) != 1 || got[0].Provider != "other" {
+				t.Fatalf("recorded %+v after destroy, want only the other server", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,430 @@
+// Package state records created cloud resources in a local JSON file so they can be torn down later
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// Kind enums the type of a recorded resource
+type Kind = common.Kind
+
+const (
+	// SERVER resource
+	SERVER = common.KindServer
+	// SERVERGROUP resource
+	SERVERGROUP = common.KindServerGroup
+	// K8S cluster resource
+	K8S = common.KindK8s
+	// DNSRECORD resource
+	DNSRECORD = common.KindDNSRecord
+	// STATICIP resource
+	STATICIP = common.KindStaticIP
+	// IMAGE resource
+	IMAGE = common.KindImage
+	// VOLUME resource
+	VOLUME = common.KindVolume
+	// FIREWALL resource
+	FIREWALL = common.KindFirewall
+	// SSHKEY resource
+	SSHKEY = common.KindSSHKey
+	// NETWORK resource
+	NETWORK = common.KindNetwork
+)
+
+// Resource contains the recorded information about a created resource
+type Resource struct {
+	Provider string     `json:"provider"`
+	Type     Kind       `json:"type"`
+	ID       common.Ref `json:"id"`
+	Name     string     `json:"name"`
+	Region   string     `json:"region,omitempty"`
+	IP       string     `json:"ip,omitempty"`
+	Created  time.Time  `json:"created"`
+	Tags     []string   `json:"tags,omitempty"`
+
+	// Port is the endpoint port of a k8s cluster
+	Port string `json:"port,omitempty"`
+	// LoadBalancerID is the load balancer of a server group
+	LoadBalancerID string `json:"loadBalancerID,omitempty"`
+	// StaticIPType is the type of a static IP
+	StaticIPType common.StaticIPType `json:"staticIPType,omitempty"`
+	// Image is the value to pass to common.ServerImage for a server image
+	Image string `json:"image,omitempty"`
+	// SizeGB is the size of a volume
+	SizeGB int64 `json:"sizeGB,omitempty"`
+	// FirewallID is the firewall created with a server
+	FirewallID *common.Ref `json:"firewallID,omitempty"`
+	// SSHKeyID is the key generated for a server. Private keys are never recorded.
+	SSHKeyID *common.Ref `json:"sshKeyID,omitempty"`
+	// Fingerprint is the fingerprint of an SSH key
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// CIDR is the address range of a network
+	CIDR string `json:"cidr,omitempty"`
+}
+
+// key identifies the resource among those recorded. IDs are only unique within a
+// provider and project, so those are part of it.
+func (r *Resource) key() string {
+	return strings.Join([]string{r.Provider, string(r.Type), r.ID.Provider, r.ID.Project, r.ID.ID}, "/")
+}
+
+// is reports whether r is the resource recorded under the provider name with the type
+// and ID. Providers and projects are only compared when both references have them, since
+// references built from a name or an ID alone, or recorded by older versions, have neither.
+func (r *Resource) is(provider string, kind Kind, id common.Ref) bool {
+	unset := func(a, b string) bool { return len(a) == 0 || len(b) == 0 }
+	return r.Provider == provider && r.Type == kind && r.ID.ID == id.ID &&
+		(unset(r.ID.Provider, id.Provider) || r.ID.Provider == id.Provider) &&
+		(unset(r.ID.Project, id.Project) || r.ID.Project == id.Project)
+}
+
+// File is a state file of recorded resources. It is safe for concurrent use.
+type File struct {
+	path string
+
+	mu        sync.Mutex
+	resources []*Resource
+}
+
+type fileContents struct {
+	Resources []*Resource `json:"resources"`
+}
+
+// Open loads the state file at path, or returns an empty state if it does not exist yet
+func Open(path string) (*File, error) {
+	f := &File{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var contents fileContents
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, fmt.Errorf("parsing state file %v: %v", path, err)
+	}
+	f.resources = contents.Resources
+
+	return f, nil
+}
+
+// Path returns the location of the state file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Resources returns the recorded resources in creation order
+func (f *File) Resources() []*Resource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]*Resource, len(f.resources))
+	copy(out, f.resources)
+	return out
+}
+
+// Add records a resource, replacing any previous record of the same provider with the
+// same type and ID, and saves the state file
+func (f *File) Add(r *Resource) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Created.IsZero() {
+		r.Created = time.Now().UTC()
+	}
+
+	key := r.key()
+	resources := f.without(func(recorded *Resource) bool { return recorded.key() == key })
+	resources = append(resources, r)
+	if err := f.save(resources); err != nil {
+		return err
+	}
+	f.resources = resources
+
+	return nil
+}
+
+// Remove forgets the resource recorded under the provider name with the given type and
+// ID and saves the state file. Removing a resource that is not recorded is not an error.
+func (f *File) Remove(provider string, kind Kind, id common.Ref) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	resources := f.without(func(r *Resource) bool { return r.is(provider, kind, id) })
+	if len(resources) == len(f.resources) {
+		return nil
+	}
+	if err := f.save(resources); err != nil {
+		return err
+	}
+	f.resources = resources
+
+	return nil
+}
+
+// without returns the recorded resources except those matching; f.mu must be held
+func (f *File) without(match func(*Resource) bool) []*Resource {
+	out := make([]*Resource, 0, len(f.resources)+1)
+	for _, r := range f.resources {
+		if !match(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// save atomically replaces the state file by writing a temporary file and renaming it
+func (f *File) save(resources []*Resource) error {
+	data, err := json.MarshalIndent(fileContents{resources}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Server returns the resource as a server response
+func (r *Resource) Server() *common.CreateServerResponse {
+	resp := &common.CreateServerResponse{
+		Name:         r.Name,
+		ServerID:     r.ID,
+		ServerRegion: r.Region,
+		ServerIP:     r.IP,
+	}
+	if r.FirewallID != nil {
+		resp.FirewallID = *r.FirewallID
+	}
+	if r.SSHKeyID != nil {
+		resp.SSHKeyID = *r.SSHKeyID
+	}
+	return resp
+}
+
+// ServerGroup returns the resource as a server group response
+func (r *Resource) ServerGroup() *common.CreateServerGroupResponse {
+	return &common.CreateServerGroupResponse{
+		Name:              r.Name,
+		ServerGroupID:     r.ID,
+		ServerGroupRegion: r.Region,
+		LoadBalancerID:    r.LoadBalancerID,
+		LoadBalancerIP:    r.IP,
+	}
+}
+
+// K8s returns the resource as a k8s cluster response. Credentials are not recorded.
+func (r *Resource) K8s() *common.CreateK8sResponse {
+	return &common.CreateK8sResponse{
+		Name:          r.Name,
+		ClusterID:     r.ID,
+		ClusterRegion: r.Region,
+		EndpointIP:    r.IP,
+		EndpointPort:  r.Port,
+	}
+}
+
+// DNSRecord returns the resource as a DNS record response
+func (r *Resource) DNSRecord() *common.CreateDNSRecordResponse {
+	return &common.CreateDNSRecordResponse{
+		SubDomain:   r.Name,
+		SubDomainID: r.ID,
+		SubDomainIP: r.IP,
+	}
+}
+
+// StaticIP returns the resource as a static IP response
+func (r *Resource) StaticIP() *common.CreateStaticIPResponse {
+	return &common.CreateStaticIPResponse{
+		Name:     r.Name,
+		StaticIP: r.IP,
+		Type:     r.StaticIPType,
+		Region:   r.Region,
+	}
+}
+
+// ServerImage returns the resource as a server image response
+func (r *Resource) ServerImage() *common.CreateImageResponse {
+	return &common.CreateImageResponse{
+		Name:    r.Name,
+		ImageID: r.ID,
+		Image:   r.Image,
+	}
+}
+
+// Volume returns the resource as a volume response
+func (r *Resource) Volume() *common.CreateVolumeResponse {
+	return &common.CreateVolumeResponse{
+		Name:     r.Name,
+		VolumeID: r.ID,
+		Region:   r.Region,
+		SizeGB:   r.SizeGB,
+	}
+}
+
+// Firewall returns the resource as a firewall response
+func (r *Resource) Firewall() *common.CreateFirewallResponse {
+	return &common.CreateFirewallResponse{
+		Name:       r.Name,
+		FirewallID: r.ID,
+		TargetTags: r.Tags,
+	}
+}
+
+// SSHKey returns the resource as an SSH key response. Public keys are not recorded.
+func (r *Resource) SSHKey() *common.ImportSSHKeyResponse {
+	return &common.ImportSSHKeyResponse{
+		Name:        r.Name,
+		KeyID:       r.ID,
+		Fingerprint: r.Fingerprint,
+	}
+}
+
+// Network returns the resource as a network response
+func (r *Resource) Network() *common.CreateNetworkResponse {
+	return &common.CreateNetworkResponse{
+		Name:      r.Name,
+		NetworkID: r.ID,
+		CIDR:      r.CIDR,
+		Region:    r.Region,
+	}
+}
+
+// Servers returns the recorded servers
+func (f *File) Servers() []*common.CreateServerResponse {
+	var out []*common.CreateServerResponse
+	for _, r := range f.Resources() {
+		if r.Type == SERVER {
+			out = append(out, r.Server())
+		}
+	}
+	return out
+}
+
+// ServerGroups returns the recorded server groups
+func (f *File) ServerGroups() []*common.CreateServerGroupResponse {
+	var out []*common.CreateServerGroupResponse
+	for _, r := range f.Resources() {
+		if r.Type == SERVERGROUP {
+			out = append(out, r.ServerGroup())
+		}
+	}
+	return out
+}
+
+// Clusters returns the recorded k8s clusters
+func (f *File) Clusters() []*common.CreateK8sResponse {
+	var out []*common.CreateK8sResponse
+	for _, r := range f.Resources() {
+		if r.Type == K8S {
+			out = append(out, r.K8s())
+		}
+	}
+	return out
+}
+
+// DNSRecords returns the recorded DNS records
+func (f *File) DNSRecords() []*common.CreateDNSRecordResponse {
+	var out []*common.CreateDNSRecordResponse
+	for _, r := range f.Resources() {
+		if r.Type == DNSRECORD {
+			out = append(out, r.DNSRecord())
+		}
+	}
+	return out
+}
+
+// StaticIPs returns the recorded static IPs
+func (f *File) StaticIPs() []*common.CreateStaticIPResponse {
+	var out []*common.CreateStaticIPResponse
+	for _, r := range f.Resources() {
+		if r.Type == STATICIP {
+			out = append(out, r.StaticIP())
+		}
+	}
+	return out
+}
+
+// Images returns the recorded server images
+func (f *File) Images() []*common.CreateImageResponse {
+	var out []*common.CreateImageResponse
+	for _, r := range f.Resources() {
+		if r.Type == IMAGE {
+			out = append(out, r.ServerImage())
+		}
+	}
+	return out
+}
+
+// Volumes returns the recorded volumes
+func (f *File) Volumes() []*common.CreateVolumeResponse {
+	var out []*common.CreateVolumeResponse
+	for _, r := range f.Resources() {
+		if r.Type == VOLUME {
+			out = append(out, r.Volume())
+		}
+	}
+	return out
+}
+
+// Firewalls returns the recorded firewalls
+func (f *File) Firewalls() []*common.CreateFirewallResponse {
+	var out []*common.CreateFirewallResponse
+	for _, r := range f.Resources() {
+		if r.Type == FIREWALL {
+			out = append(out, r.Firewall())
+		}
+	}
+	return out
+}
+
+// SSHKeys returns the recorded SSH keys
+func (f *File) SSHKeys() []*common.ImportSSHKeyResponse {
+	var out []*common.ImportSSHKeyResponse
+	for _, r := range f.Resources() {
+		if r.Type == SSHKEY {
+			out = append(out, r.SSHKey())
+		}
+	}
+	return out
+}
+
+// Networks returns the recorded networks
+func (f *File) Networks() []*common.CreateNetworkResponse {
+	var out []*common.CreateNetworkResponse
+	for _, r := range f.Resources() {
+		if r.Type == NETWORK {
+			out = append(out, r.Network())
+		}
+	}
+	return out
+}
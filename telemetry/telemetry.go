@@ -0,0 +1,238 @@
+// Package telemetry instruments a cpt.CloudProvider with OpenTelemetry traces and metrics
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/sas-fe/cloud-provider-tools/telemetry"
+
+// Attribute keys recorded on spans and metrics. ResourceKey is only recorded
+// on spans, to keep the cardinality of metrics low.
+const (
+	ProviderKey  = attribute.Key("cpt.provider")
+	OperationKey = attribute.Key("cpt.operation")
+	ResourceKey  = attribute.Key("cpt.resource.name")
+	RegionKey    = attribute.Key("cloud.region")
+	SizeKey      = attribute.Key("cpt.server.size")
+	ErrorKey     = attribute.Key("error.type")
+)
+
+// errorClasses maps the common error classes to ErrorKey values
+var errorClasses = []struct {
+	err   error
+	class string
+}{
+	{common.ErrNotImplemented, "not_implemented"},
+	{common.ErrNotFound, "not_found"},
+	{common.ErrAlreadyExists, "already_exists"},
+	{common.ErrQuotaExceeded, "quota_exceeded"},
+	{common.ErrPermissionDenied, "permission_denied"},
+	{common.ErrTransient, "transient"},
+	{context.DeadlineExceeded, "deadline_exceeded"},
+	{context.Canceled, "canceled"},
+}
+
+// errorClass returns the ErrorKey value for err
+func errorClass(err error) string {
+	for _, c := range errorClasses {
+		if errors.Is(err, c.err) {
+			return c.class
+		}
+	}
+	return "other"
+}
+
+// Provider wraps a cpt.CloudProvider, recording a span, the operation latency and
+// failures for every Create* and Remove* call
+type Provider struct {
+	cpt.CloudProvider
+	name     string
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	failures metric.Int64Counter
+}
+
+// Instrument returns a Provider instrumenting p under the provider name. Nil tracer and
+// meter providers fall back to the global ones registered with the otel package.
+func Instrument(p cpt.CloudProvider, name string, tp trace.TracerProvider, mp metric.MeterProvider) (*Provider, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	meter := mp.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram(
+		"cpt.operation.duration",
+		metric.WithDescription("Duration of cloud provider operations"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := meter.Int64Counter(
+		"cpt.operation.failures",
+		metric.WithDescription("Number of failed cloud provider operations"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{p, name, tp.Tracer(instrumentationName), duration, failures}, nil
+}
+
+// call is a single instrumented operation
+type call struct {
+	p     *Provider
+	span  trace.Span
+	attrs []attribute.KeyValue
+	start time.Time
+}
+
+// start begins the span of an operation on the named resource. The returned context
+// also adds the progress events of the operation to the span.
+func (p *Provider) start(ctx context.Context, op common.Operation, name string, region string, size string) (context.Context, *call) {
+	attrs := []attribute.KeyValue{
+		ProviderKey.String(p.name),
+		OperationKey.String(string(op)),
+	}
+	if len(region) > 0 {
+		attrs = append(attrs, RegionKey.String(region))
+	}
+	if len(size) > 0 {
+		attrs = append(attrs, SizeKey.String(size))
+	}
+
+	ctx, span := p.tracer.Start(ctx, "cpt."+string(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(ResourceKey.String(name)),
+	)
+
+	parent := common.EventSinkFrom(ctx)
+	ctx = common.WithEventSink(ctx, func(e common.Event) {
+		eventAttrs := []attribute.KeyValue{attribute.String("cpt.event", string(e.Type))}
+		if len(e.Status) > 0 {
+			eventAttrs = append(eventAttrs, attribute.String("cpt.status", e.Status))
+		}
+		if len(e.IP) > 0 {
+			eventAttrs = append(eventAttrs, attribute.String("cpt.ip", e.IP))
+		}
+		span.AddEvent(string(e.Type), trace.WithTimestamp(e.Time), trace.WithAttributes(eventAttrs...))
+
+		if parent != nil {
+			parent(e)
+		}
+	})
+
+	return ctx, &call{p, span, attrs, time.Now()}
+}
+
+// startServer begins the span of an operation configured by server options
+func (p *Provider) startServer(ctx context.Context, op common.Operation, name string, opts []common.ServerOption) (context.Context, *call) {
+	s := &common.ServerInfo{
+		Name: name,
+	}
+
+	for _, opt := range opts {
+		opt.Set(s)
+	}
+
+	return p.start(ctx, op, name, s.Region, s.Size)
+}
+
+// end records the outcome of the operation and returns err
+func (c *call) end(ctx context.Context, err error) error {
+	attrs := c.attrs
+	if err != nil {
+		class := ErrorKey.String(errorClass(err))
+		attrs = append(append([]attribute.KeyValue{}, c.attrs...), class)
+
+		c.span.RecordError(err)
+		c.span.SetStatus(codes.Error, err.Error())
+		c.span.SetAttributes(class)
+		c.p.failures.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+
+	c.p.duration.Record(ctx, time.Since(c.start).Seconds(), metric.WithAttributes(attrs...))
+	c.span.End()
+	return err
+}
+
+// CreateServer creates a server within a span
+func (p *Provider) CreateServer(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerResponse, error) {
+	ctx, c := p.startServer(ctx, common.OpCreateServer, name, opts)
+	resp, err := p.CloudProvider.CreateServer(ctx, name, opts...)
+	return resp, c.end(ctx, err)
+}
+
+// RemoveServer removes a server within a span
+func (p *Provider) RemoveServer(ctx context.Context, server *common.CreateServerResponse) error {
+	ctx, c := p.start(ctx, common.OpRemoveServer, server.Name, server.ServerRegion, "")
+	return c.end(ctx, p.CloudProvider.RemoveServer(ctx, server))
+}
+
+// CreateServerGroup creates a server group within a span
+func (p *Provider) CreateServerGroup(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateServerGroupResponse, error) {
+	ctx, c := p.startServer(ctx, common.OpCreateServerGroup, name, opts)
+	resp, err := p.CloudProvider.CreateServerGroup(ctx, name, opts...)
+	return resp, c.end(ctx, err)
+}
+
+// RemoveServerGroup removes a server group within a span
+func (p *Provider) RemoveServerGroup(ctx context.Context, group *common.CreateServerGroupResponse) error {
+	ctx, c := p.start(ctx, common.OpRemoveServerGroup, group.Name, group.ServerGroupRegion, "")
+	return c.end(ctx, p.CloudProvider.RemoveServerGroup(ctx, group))
+}
+
+// CreateK8s creates a cluster within a span
+func (p *Provider) CreateK8s(ctx context.Context, name string, opts ...common.ServerOption) (*common.CreateK8sResponse, error) {
+	ctx, c := p.startServer(ctx, common.OpCreateK8s, name, opts)
+	resp, err := p.CloudProvider.CreateK8s(ctx, name, opts...)
+	return resp, c.end(ctx, err)
+}
+
+// RemoveK8s removes a cluster within a span
+func (p *Provider) RemoveK8s(ctx context.Context, k8s *common.CreateK8sResponse) error {
+	ctx, c := p.start(ctx, common.OpRemoveK8s, k8s.Name, k8s.ClusterRegion, "")
+	return c.end(ctx, p.CloudProvider.RemoveK8s(ctx, k8s))
+}
+
+// CreateDNSRecord creates a DNS record within a span
+func (p *Provider) CreateDNSRecord(ctx context.Context, subDomain string, IP string) (*common.CreateDNSRecordResponse, error) {
+	ctx, c := p.start(ctx, common.OpCreateDNSRecord, subDomain, "", "")
+	resp, err := p.CloudProvider.CreateDNSRecord(ctx, subDomain, IP)
+	return resp, c.end(ctx, err)
+}
+
+// RemoveDNSRecord removes a DNS record within a span
+func (p *Provider) RemoveDNSRecord(ctx context.Context, subDomain *common.CreateDNSRecordResponse) error {
+	ctx, c := p.start(ctx, common.OpRemoveDNSRecord, subDomain.SubDomain, "", "")
+	return c.end(ctx, p.CloudProvider.RemoveDNSRecord(ctx, subDomain))
+}
+
+// CreateStaticIP creates a static IP within a span
+func (p *Provider) CreateStaticIP(ctx context.Context, name string, req *common.StaticIPRequest) (*common.CreateStaticIPResponse, error) {
+	ctx, c := p.start(ctx, common.OpCreateStaticIP, name, req.Region, "")
+	resp, err := p.CloudProvider.CreateStaticIP(ctx, name, req)
+	return resp, c.end(ctx, err)
+}
+
+// RemoveStaticIP removes a static IP within a span
+func (p *Provider) RemoveStaticIP(ctx context.Context, staticIP *common.CreateStaticIPResponse) error {
+	ctx, c := p.start(ctx, common.OpRemoveStaticIP, staticIP.Name, staticIP.Region, "")
+	return c.end(ctx, p.CloudProvider.RemoveStaticIP(ctx, staticIP))
+}
@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/fake"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestErrorClass(t *testing.T) {
+	sdkErr := errors.New("sdk error")
+
+	tests := []struct {
+		name    string
+		err     error
+		latency time.Duration
+		want    string
+	}{
+		{"not implemented", common.NotImplemented("fake", "CreateServer"), 0, "not_implemented"},
+		{"not found", common.NewError("fake", "CreateServer", common.ErrNotFound, sdkErr), 0, "not_found"},
+		{"already exists", common.NewError("fake", "CreateServer", common.ErrAlreadyExists, sdkErr), 0, "already_exists"},
+		{"quota exceeded", common.NewError("fake", "CreateServer", common.ErrQuotaExceeded, sdkErr), 0, "quota_exceeded"},
+		{"permission denied", common.NewError("fake", "CreateServer", common.ErrPermissionDenied, sdkErr), 0, "permission_denied"},
+		{"transient", common.NewError("fake", "CreateServer", common.ErrTransient, sdkErr), 0, "transient"},
+		{"wrapped", fmt.Errorf("creating web: %w", common.NewError("fake", "CreateServer", common.ErrTransient, sdkErr)), 0, "transient"},
+		{"unclassified", common.NewError("fake", "CreateServer", nil, sdkErr), 0, "other"},
+		{"plain", sdkErr, 0, "other"},
+		{"deadline exceeded", nil, time.Minute, "deadline_exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := fake.NewProvider()
+			fp.FailNext(fake.CreateServer, tt.err)
+			fp.SetLatency(fake.CreateServer, tt.latency)
+			p, err := Instrument(fp, "fake", tracenoop.NewTracerProvider(), metricnoop.NewMeterProvider())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			// the error of the provider is returned unchanged
+			resp, err := p.CreateServer(ctx, "web")
+			if tt.err != nil && err != tt.err || err == nil || resp != nil {
+				t.Fatalf("CreateServer() = %v, %v, want %v", resp, err, tt.err)
+			}
+			if got := errorClass(err); got != tt.want {
+				t.Fatalf("errorClass(%v) = %q, want %q", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartialResponse(t *testing.T) {
+	errSetup := common.NewError("fake", "CreateServer", common.ErrTransient, errors.New("server did not become ready"))
+
+	fp := fake.NewProvider()
+	fp.FailAfter(fake.CreateServer, errSetup)
+	p, err := Instrument(fp, "fake", tracenoop.NewTracerProvider(), metricnoop.NewMeterProvider())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the live server is returned along with the error
+	resp, err := p.CreateServer(context.Background(), "web")
+	if resp == nil || err != errSetup {
+		t.Fatalf("CreateServer() = %v, %v, want the live server and %v", resp, err, errSetup)
+	}
+	if got := errorClass(err); got != "transient" {
+		t.Fatalf("errorClass(%v) = %q, want %q", err, got, "transient")
+	}
+	if err := p.RemoveServer(context.Background(), resp); err != nil {
+		t.Fatal(err)
+	}
+	if servers := fp.Servers(); len(servers) != 0 {
+		t.Fatalf("%d servers live after removal, want 0", len(servers))
+	}
+}
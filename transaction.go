@@ -0,0 +1,190 @@
+package cpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sas-fe/cloud-provider-tools/common"
+)
+
+// Step is a create operation with the remove operation that compensates it
+type Step struct {
+	// Name describes the step in errors
+	Name string
+	// Create creates the resource
+	Create func(ctx context.Context) error
+	// Remove removes the resource after Create succeeded. It may be nil for steps
+	// that need no compensation.
+	Remove func(ctx context.Context) error
+	// Partial reports whether a failed Create left a resource that Remove must remove.
+	// It may be nil for steps that create nothing when they fail.
+	Partial func() bool
+}
+
+// Transaction runs steps in order. If a step fails or the context is cancelled, the
+// completed steps, and the failed one if it left a resource, are removed in reverse order.
+type Transaction struct {
+	steps []Step
+
+	// RollbackTimeout bounds the rollback, which is not cancelled with the context
+	// passed to Run. Zero means no timeout.
+	RollbackTimeout time.Duration
+}
+
+// NewTransaction returns a Transaction running steps in order
+func NewTransaction(steps ...Step) *Transaction {
+	return &Transaction{steps: steps}
+}
+
+// Add appends steps to the transaction
+func (t *Transaction) Add(steps ...Step) {
+	t.steps = append(t.steps, steps...)
+}
+
+// RollbackError is a failure to remove the resource of a completed step
+type RollbackError struct {
+	Step string
+	Err  error
+}
+
+// TransactionError reports the step that failed and any failures rolling back the
+// completed steps. It unwraps to the error of the failed step.
+type TransactionError struct {
+	Step      string
+	Err       error
+	Rollbacks []*RollbackError
+}
+
+func (e *TransactionError) Error() string {
+	msg := fmt.Sprintf("%s failed: %v", e.Step, e.Err)
+	if len(e.Rollbacks) == 0 {
+		return msg + "; rolled back"
+	}
+
+	failures := make([]string, len(e.Rollbacks))
+	for i, r := range e.Rollbacks {
+		failures[i] = fmt.Sprintf("removing %s: %v", r.Step, r.Err)
+	}
+	return msg + "; rollback failed: " + strings.Join(failures, "; ")
+}
+
+// Unwrap returns the error of the failed step
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// Run runs the steps in order and returns nil if they all succeed. Otherwise it removes
+// the resources of the completed steps, and of the failed step if it is Partial, in
+// reverse order, keeping going after failures, and returns a *TransactionError.
+func (t *Transaction) Run(ctx context.Context) error {
+	for i, step := range t.steps {
+		err := ctx.Err()
+		if err == nil {
+			err = step.Create(ctx)
+		}
+		if err != nil {
+			done := t.steps[:i:i]
+			if step.Partial != nil && step.Partial() {
+				done = append(done, step)
+			}
+			return &TransactionError{
+				Step:      step.Name,
+				Err:       err,
+				Rollbacks: t.rollback(ctx, done),
+			}
+		}
+	}
+	return nil
+}
+
+// rollback removes the resources of the completed steps in reverse order
+func (t *Transaction) rollback(ctx context.Context, done []Step) []*RollbackError {
+	ctx = context.WithoutCancel(ctx)
+	if t.RollbackTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.RollbackTimeout)
+		defer cancel()
+	}
+
+	var failures []*RollbackError
+	for i := len(done) - 1; i >= 0; i-- {
+		if done[i].Remove == nil {
+			continue
+		}
+		if err := done[i].Remove(ctx); err != nil {
+			failures = append(failures, &RollbackError{done[i].Name, err})
+		}
+	}
+	return failures
+}
+
+// ServerStep returns a Step creating a server with p, storing the response in resp
+func ServerStep(p CloudProvider, name string, resp **common.CreateServerResponse, opts ...common.ServerOption) Step {
+	return Step{
+		Name: "server " + name,
+		Create: func(ctx context.Context) (err error) {
+			*resp, err = p.CreateServer(ctx, name, opts...)
+			return err
+		},
+		Remove: func(ctx context.Context) error {
+			return p.RemoveServer(ctx, *resp)
+		},
+		Partial: func() bool {
+			return *resp != nil
+		},
+	}
+}
+
+// K8sStep returns a Step creating a k8s cluster with p, storing the response in resp
+func K8sStep(p CloudProvider, name string, resp **common.CreateK8sResponse, opts ...common.ServerOption) Step {
+	return Step{
+		Name: "k8s cluster " + name,
+		Create: func(ctx context.Context) (err error) {
+			*resp, err = p.CreateK8s(ctx, name, opts...)
+			return err
+		},
+		Remove: func(ctx context.Context) error {
+			return p.RemoveK8s(ctx, *resp)
+		},
+		Partial: func() bool {
+			return *resp != nil
+		},
+	}
+}
+
+// StaticIPStep returns a Step creating a static IP with p, storing the response in resp
+func StaticIPStep(p CloudProvider, name string, req *common.StaticIPRequest, resp **common.CreateStaticIPResponse) Step {
+	return Step{
+		Name: "static IP " + name,
+		Create: func(ctx context.Context) (err error) {
+			*resp, err = p.CreateStaticIP(ctx, name, req)
+			return err
+		},
+		Remove: func(ctx context.Context) error {
+			return p.RemoveStaticIP(ctx, *resp)
+		},
+		Partial: func() bool {
+			return *resp != nil
+		},
+	}
+}
+
+// DNSRecordStep returns a Step creating a DNS A record with p, storing the response in
+// resp. The IP is read when the step runs, so it can come from an earlier step.
+func DNSRecordStep(p CloudProvider, subDomain string, ip func() string, resp **common.CreateDNSRecordResponse) Step {
+	return Step{
+		Name: "DNS record " + subDomain,
+		Create: func(ctx context.Context) (err error) {
+			*resp, err = p.CreateDNSRecord(ctx, subDomain, ip())
+			return err
+		},
+		Remove: func(ctx context.Context) error {
+			return p.RemoveDNSRecord(ctx, *resp)
+		},
+		Partial: func() bool {
+			return *resp != nil
+		},
+	}
+}
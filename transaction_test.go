@@ -0,0 +1,130 @@
+package cpt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cpt "github.com/sas-fe/cloud-provider-tools"
+	"github.com/sas-fe/cloud-provider-tools/common"
+	"github.com/sas-fe/cloud-provider-tools/fake"
+)
+
+func TestTransaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		partial  bool
+		failures map[fake.Op][]error
+		latency  map[fake.Op]time.Duration
+		timeout  time.Duration
+
+		wantStep      string
+		wantErr       error
+		wantRollbacks int
+		// wantServers, wantIPs and wantRecords are the resources left live
+		wantServers int
+		wantIPs     int
+		wantRecords int
+	}{
+		{
+			name:        "committed",
+			wantServers: 1,
+			wantIPs:     1,
+			wantRecords: 1,
+		},
+		{
+			name:     "first step fails",
+			failures: map[fake.Op][]error{fake.CreateServer: {transient}},
+			wantStep: "server web",
+			wantErr:  common.ErrTransient,
+		},
+		{
+			name:     "last step fails",
+			failures: map[fake.Op][]error{fake.CreateDNSRecord: {transient}},
+			wantStep: "DNS record web",
+			wantErr:  common.ErrTransient,
+		},
+		{
+			name:     "partial step",
+			partial:  true,
+			wantStep: "server web",
+			wantErr:  errSetup,
+		},
+		{
+			name: "rollback fails",
+			failures: map[fake.Op][]error{
+				fake.CreateDNSRecord: {transient},
+				fake.RemoveServer:    {transient},
+			},
+			wantStep:      "DNS record web",
+			wantErr:       common.ErrTransient,
+			wantRollbacks: 1,
+			wantServers:   1,
+		},
+		{
+			name:     "cancelled",
+			latency:  map[fake.Op]time.Duration{fake.CreateStaticIP: time.Minute},
+			timeout:  20 * time.Millisecond,
+			wantStep: "static IP web-ip",
+			wantErr:  context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := fake.NewProvider()
+			for op, errs := range tt.failures {
+				fp.FailNext(op, errs...)
+			}
+			for op, d := range tt.latency {
+				fp.SetLatency(op, d)
+			}
+			if tt.partial {
+				fp.FailAfter(fake.CreateServer, errSetup)
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			var server *common.CreateServerResponse
+			var staticIP *common.CreateStaticIPResponse
+			var record *common.CreateDNSRecordResponse
+			err := cpt.NewTransaction(
+				cpt.ServerStep(fp, "web", &server),
+				cpt.StaticIPStep(fp, "web-ip", &common.StaticIPRequest{IPType: common.GLOBAL}, &staticIP),
+				cpt.DNSRecordStep(fp, "web", func() string { return staticIP.StaticIP }, &record),
+			).Run(ctx)
+
+			if len(tt.wantStep) == 0 && err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(tt.wantStep) > 0 {
+				var txErr *cpt.TransactionError
+				if !errors.As(err, &txErr) {
+					t.Fatalf("Run() error = %v, want *cpt.TransactionError", err)
+				}
+				if txErr.Step != tt.wantStep || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Run() failed at %s with %v, want %s with %v", txErr.Step, txErr.Err, tt.wantStep, tt.wantErr)
+				}
+				if len(txErr.Rollbacks) != tt.wantRollbacks {
+					t.Fatalf("Run() had %d rollback failures, want %d", len(txErr.Rollbacks), tt.wantRollbacks)
+				}
+			}
+
+			if got := len(fp.Servers()); got != tt.wantServers {
+				t.Fatalf("%d servers live, want %d", got, tt.wantServers)
+			}
+			if got := len(fp.StaticIPs()); got != tt.wantIPs {
+				t.Fatalf("%d static IPs live, want %d", got, tt.wantIPs)
+			}
+			if got := len(fp.DNSRecords()); got != tt.wantRecords {
+				t.Fatalf("%d DNS records live, want %d", got, tt.wantRecords)
+			}
+		})
+	}
+}